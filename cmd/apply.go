@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/drift"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+var applyCmd = &cobra.Command{
+	Use:   "apply <plan-file>",
+	Short: "Execute a plan saved by \"hookdeck-deploy plan -o\"",
+	Long: `Apply deploys exactly the input recorded in a plan file, without re-reading
+manifests, variables, or transformation code files. Resources the plan found
+unchanged are skipped; everything else is upserted.
+
+Before deploying, apply re-fetches every planned resource and fails if any of
+them changed on Hookdeck since the plan was created. Re-run plan in that case.
+The plan's credential profile and API base URL are used unless --profile or
+--api-base-url is given, and the project's client settings are taken from
+the plan. Plans created for a protected environment ask for
+confirmation like deploy does, unless --confirm is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runApply,
+}
+
+func init() {
+	applyCmd.Flags().BoolVar(&flagConfirm, "confirm", false, "confirm applying a plan created for an environment listed in the project's protected_environments")
+	rootCmd.AddCommand(applyCmd)
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	plan, err := deploy.ReadPlan(args[0])
+	if err != nil {
+		return err
+	}
+	if flagEnv != "" && flagEnv != plan.Env {
+		return fmt.Errorf("plan was created for env %q, not %q", plan.Env, flagEnv)
+	}
+	logger.Infof("Applying plan created %s", plan.CreatedAt.Local().Format(time.RFC1123))
+
+	profile := flagProfile
+	if profile == "" {
+		profile = plan.Profile
+	}
+	creds, err := credentials.Resolve(profile)
+	if err != nil {
+		return fmt.Errorf("resolving credentials: %w", err)
+	}
+	var clientConfig *project.ClientConfig
+	if len(plan.Client) > 0 {
+		clientConfig = &project.ClientConfig{}
+		if err := json.Unmarshal(plan.Client, clientConfig); err != nil {
+			return fmt.Errorf("parsing plan client settings: %w", err)
+		}
+	}
+	client := newAPIClient(creds, plan.APIBaseURL, clientConfig)
+
+	// Refuse to apply on top of remote state the plan didn't see.
+	checker := drift.NewChecker(client)
+	var changed []string
+	for _, c := range plan.Changes {
+		if c.Action == deploy.ActionSkippedDisabled {
+			continue
+		}
+		_, fingerprint, err := fingerprintResource(ctx, checker, plan.Input, c.Kind, c.Name)
+		if err != nil {
+			return fmt.Errorf("fetching %s %q: %w", c.Kind, c.Name, err)
+		}
+		if fingerprint != c.Fingerprint {
+			changed = append(changed, fmt.Sprintf("%s %q", c.Kind, c.Name))
+		}
+	}
+	if len(changed) > 0 {
+		return fmt.Errorf("remote state changed since the plan was created; re-run plan:\n  %s", strings.Join(changed, "\n  "))
+	}
+
+	if err := confirmProtectedEnv(plan.Env, plan.Protected); err != nil {
+		return err
+	}
+
+	opts := deploy.Options{
+		Reporter:        newStreamReporter(nil),
+		SkipUnchanged:   true,
+		Checker:         plan.Checker(),
+		Code:            plan.Code,
+		SourceTypes:     checker,
+		AllowTypeChange: plan.AllowTypeChange,
+		MaxCodeSize:     plan.MaxCodeSize,
+	}
+	result, err := deploy.Deploy(ctx, client, plan.Input, opts)
+	if err != nil {
+		printPartialResult(result)
+		switch {
+		case errors.Is(err, deploy.ErrSourceTypeChange):
+			err = fmt.Errorf("%w; re-run plan with --allow-type-change if this is intended", err)
+		case errors.Is(err, deploy.ErrCodeTooLarge):
+			err = fmt.Errorf("%w; re-run plan with a larger --max-payload-size", err)
+		}
+		return fmt.Errorf("apply failed: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "\nSummary: %s\n", result.Summary())
+	return nil
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+// errNotGitRepo is returned by gitChangedFiles when dir isn't inside a git
+// work tree, or git isn't installed.
+var errNotGitRepo = errors.New("not a git repository")
+
+// gitChangedFiles returns the absolute paths of the files under dir that
+// differ from the merge base of baseRef and HEAD, including uncommitted and
+// untracked files.
+func gitChangedFiles(ctx context.Context, dir, baseRef string) ([]string, error) {
+	if _, err := runGit(ctx, dir, "rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, errNotGitRepo
+	}
+	base, err := runGit(ctx, dir, "merge-base", baseRef, "HEAD")
+	if err != nil {
+		// CI checkouts are often shallow, where the default HEAD~1 (or the
+		// merge base with a branch) isn't available.
+		if shallow, _ := runGit(ctx, dir, "rev-parse", "--is-shallow-repository"); strings.TrimSpace(shallow) == "true" {
+			return nil, fmt.Errorf("resolving --base-ref %q: the repository is a shallow clone without that history; fetch more of it (e.g. \"git fetch --unshallow\", or fetch-depth: 0 in GitHub Actions) or pass a --base-ref it contains", baseRef)
+		}
+		return nil, fmt.Errorf("resolving --base-ref %q: %w", baseRef, err)
+	}
+	diff, err := runGit(ctx, dir, "diff", "--name-only", "--relative", strings.TrimSpace(base))
+	if err != nil {
+		return nil, fmt.Errorf("listing changed files: %w", err)
+	}
+	untracked, err := runGit(ctx, dir, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, fmt.Errorf("listing untracked files: %w", err)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(diff+untracked, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			paths = append(paths, filepath.Join(absDir, filepath.FromSlash(line)))
+		}
+	}
+	return paths, nil
+}
+
+// runGit runs git in dir and returns its stdout. A failing command's error
+// includes its stderr.
+func runGit(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s", msg)
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// selectChanged narrows a project deploy to the resources defined in a
+// changed manifest and the transformations whose code file changed, plus
+// what their connections reference (see project.Select). A change to the
+// project config or an env file (an --env-file, else the project root's
+// .env or .env.<env>) can affect every resource, so it keeps the whole
+// input. Other changed files are ignored.
+func selectChanged(input *deploy.DeployInput, proj *project.Project, projectPath string, changed []string) (*deploy.DeployInput, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	changedSet := make(map[string]bool, len(changed))
+	for _, path := range changed {
+		changedSet[filepath.Clean(path)] = true
+	}
+
+	everything := []string{absPath(cwd, projectPath)}
+	for _, envFile := range flagEnvFiles {
+		everything = append(everything, absPath(cwd, envFile))
+	}
+	// Without --env-file, interpolation reads .env and .env.<env> from the
+	// project root (see manifest.LoadEnvFiles).
+	if len(flagEnvFiles) == 0 {
+		root := absPath(cwd, proj.RootDir)
+		everything = append(everything, filepath.Join(root, ".env"))
+		if flagEnv != "" {
+			everything = append(everything, filepath.Join(root, ".env."+flagEnv))
+		}
+	}
+	for _, path := range everything {
+		if changedSet[path] {
+			logger.Infof("%s changed; deploying all resources", displayPath(cwd, path))
+			return input, nil
+		}
+	}
+
+	return project.Select(input, func(kind, name string) bool {
+		if file := proj.Registry.FileFor(kind, name); file != "" && changedSet[absPath(cwd, file)] {
+			return true
+		}
+		if kind != project.KindTransformation {
+			return false
+		}
+		for _, tr := range input.Transformations {
+			if tr.Name != name {
+				continue
+			}
+			for path := range changedSet {
+				if codeFileMatches(tr.CodeFile, tr.CodeFiles, cwd, path) {
+					return true
+				}
+			}
+		}
+		return false
+	}), nil
+}
+
+// displayPath returns path relative to dir when it is inside dir.
+func displayPath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// gitRepo creates a repository in a temporary directory with one commit
+// per entry of commits, each writing the given files.
+func gitRepo(t *testing.T, commits ...map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git(t, dir, "init", "-q")
+	for i, files := range commits {
+		writeFiles(t, dir, files)
+		git(t, dir, "add", "-A")
+		git(t, dir, "commit", "-q", "-m", fmt.Sprintf("commit %d", i+1))
+	}
+	return dir
+}
+
+// git runs git in dir with a fixed identity and fails the test on error.
+func git(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// relPaths returns paths relative to dir, sorted.
+func relPaths(t *testing.T, dir string, paths []string) []string {
+	t.Helper()
+	var rel []string
+	for _, p := range paths {
+		r, err := filepath.Rel(dir, p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	sort.Strings(rel)
+	return rel
+}
+
+func TestGitChangedFiles(t *testing.T) {
+	dir := gitRepo(t,
+		map[string]string{"a/hookdeck.jsonc": "{}", "b/hookdeck.jsonc": "{}"},
+		map[string]string{"a/hookdeck.jsonc": `{"sources": []}`},
+	)
+	// Uncommitted and untracked changes count too.
+	writeFiles(t, dir, map[string]string{"b/hookdeck.jsonc": `{"destinations": []}`, "c/new.js": "x"})
+
+	changed, err := gitChangedFiles(context.Background(), dir, "HEAD~1")
+	if err != nil {
+		t.Fatalf("gitChangedFiles failed: %v", err)
+	}
+	got := relPaths(t, dir, changed)
+	want := []string{"a/hookdeck.jsonc", "b/hookdeck.jsonc", "c/new.js"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestGitChangedFiles_Subdirectory(t *testing.T) {
+	dir := gitRepo(t,
+		map[string]string{"project/a.jsonc": "{}", "other.txt": "x"},
+		map[string]string{"project/a.jsonc": "{ }", "other.txt": "y"},
+	)
+
+	changed, err := gitChangedFiles(context.Background(), filepath.Join(dir, "project"), "HEAD~1")
+	if err != nil {
+		t.Fatalf("gitChangedFiles failed: %v", err)
+	}
+	if got := relPaths(t, dir, changed); len(got) != 1 || got[0] != "project/a.jsonc" {
+		t.Errorf("expected only the file under the project, got %v", got)
+	}
+}
+
+func TestGitChangedFiles_NotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	_, err := gitChangedFiles(context.Background(), t.TempDir(), "HEAD~1")
+	if !errors.Is(err, errNotGitRepo) {
+		t.Errorf("expected errNotGitRepo, got %v", err)
+	}
+}
+
+func TestGitChangedFiles_ShallowClone(t *testing.T) {
+	origin := gitRepo(t, map[string]string{"a.jsonc": "1"}, map[string]string{"a.jsonc": "2"})
+	clone := filepath.Join(t.TempDir(), "clone")
+	git(t, origin, "clone", "-q", "--depth", "1", "file://"+origin, clone)
+
+	_, err := gitChangedFiles(context.Background(), clone, "HEAD~1")
+	if err == nil || !strings.Contains(err.Error(), "shallow clone") {
+		t.Errorf("expected a shallow clone error, got %v", err)
+	}
+}
+
+func TestGitChangedFiles_UnknownRef(t *testing.T) {
+	dir := gitRepo(t, map[string]string{"a.jsonc": "1"})
+
+	_, err := gitChangedFiles(context.Background(), dir, "no-such-ref")
+	if err == nil || !strings.Contains(err.Error(), `resolving --base-ref "no-such-ref"`) || strings.Contains(err.Error(), "shallow") {
+		t.Errorf("expected an unresolvable ref error, got %v", err)
+	}
+}
+
+func TestSelectChanged_EnvFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"hookdeck.project.jsonc": `{}`,
+		"a/hookdeck.jsonc":       `{"sources": [{"name": "a"}]}`,
+		"b/hookdeck.jsonc":       `{"sources": [{"name": "b"}]}`,
+	})
+	projectPath := filepath.Join(dir, "hookdeck.project.jsonc")
+	proj, err := loadProject(projectPath)
+	if err != nil {
+		t.Fatalf("loadProject failed: %v", err)
+	}
+	defer func(env string, files []string) { flagEnv, flagEnvFiles = env, files }(flagEnv, flagEnvFiles)
+	flagEnv, flagEnvFiles = "production", nil
+
+	selected := func(changed ...string) []string {
+		t.Helper()
+		var paths []string
+		for _, name := range changed {
+			paths = append(paths, filepath.Join(dir, filepath.FromSlash(name)))
+		}
+		input := buildDeployInputFromRegistry(proj.Registry, flagEnv)
+		out, err := selectChanged(input, proj, projectPath, paths)
+		if err != nil {
+			t.Fatalf("selectChanged failed: %v", err)
+		}
+		var names []string
+		for _, src := range out.Sources {
+			names = append(names, src.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got := selected("b/hookdeck.jsonc"); strings.Join(got, ",") != "b" {
+		t.Errorf("changed manifest: selected %v, want [b]", got)
+	}
+	for _, name := range []string{".env", ".env.production"} {
+		if got := selected(name); strings.Join(got, ",") != "a,b" {
+			t.Errorf("changed %s: selected %v, want every resource", name, got)
+		}
+	}
+	if got := selected(".env.staging"); len(got) != 0 {
+		t.Errorf("changed .env.staging: selected %v, want none", got)
+	}
+
+	// With --env-file, only the given files count.
+	flagEnvFiles = []string{filepath.Join(dir, "ci.env")}
+	if got := selected(".env.production"); len(got) != 0 {
+		t.Errorf("changed .env.production under --env-file: selected %v, want none", got)
+	}
+	if got := selected("ci.env"); strings.Join(got, ",") != "a,b" {
+		t.Errorf("changed --env-file: selected %v, want every resource", got)
+	}
+}
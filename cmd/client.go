@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"os"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+// apiBaseURL picks the Hookdeck API base URL with precedence
+// --api-base-url > HOOKDECK_API_BASE_URL > configured (from the project config
+// or manifest). An empty result means the client's built-in default.
+func apiBaseURL(configured string) string {
+	if flagAPIBaseURL != "" {
+		return flagAPIBaseURL
+	}
+	if v := os.Getenv("HOOKDECK_API_BASE_URL"); v != "" {
+		return v
+	}
+	return configured
+}
+
+// conflictRetries is how often --retry-on-conflict repeats an upsert that
+// got 409 Conflict.
+const conflictRetries = 3
+
+// newAPIClient creates a Hookdeck client for creds, honoring any base URL
+// override (see apiBaseURL), --retry-on-conflict, and the retry, rate limit,
+// and timeout settings (see clientSettings). cfg is the project config's
+// client block, or nil.
+func newAPIClient(creds *credentials.Credentials, configuredBaseURL string, cfg *project.ClientConfig) *hookdeck.Client {
+	opts := []hookdeck.ClientOption{hookdeck.WithVersion(version), hookdeck.WithLogger(logger)}
+	if flagRetryOnConflict {
+		opts = append(opts, hookdeck.WithConflictRetries(conflictRetries))
+	}
+	opts = append(opts, clientSettings(cfg)...)
+	if baseURL := apiBaseURL(configuredBaseURL); baseURL != "" {
+		opts = append(opts, hookdeck.WithBaseURL(baseURL))
+		logger.Debugf("API base URL: %s", baseURL)
+	}
+	return hookdeck.NewClient(creds.APIKey, creds.ProjectID, opts...)
+}
+
+// persistentFlagChanged reports whether a root persistent flag was set on
+// the command line. It is assigned in root.go's init, since reading rootCmd
+// here would be an initialization cycle.
+var persistentFlagChanged func(name string) bool
+
+// clientSettings returns the retry, rate limit, and timeout options with
+// precedence flag > project config client block > client default.
+func clientSettings(cfg *project.ClientConfig) []hookdeck.ClientOption {
+	if cfg == nil {
+		cfg = &project.ClientConfig{}
+	}
+
+	attempts := cfg.RetryAttempts
+	if persistentFlagChanged("retry-attempts") {
+		attempts = flagRetryAttempts
+	}
+	baseDelay := time.Duration(cfg.RetryBaseDelayMS) * time.Millisecond
+	if persistentFlagChanged("retry-base-delay") {
+		baseDelay = flagRetryBaseDelay
+	}
+	rps := cfg.RateLimitRPS
+	if persistentFlagChanged("rate-limit") {
+		rps = flagRateLimit
+	}
+	timeout := time.Duration(cfg.TimeoutMS) * time.Millisecond
+	if persistentFlagChanged("request-timeout") {
+		timeout = flagRequestTimeout
+	}
+
+	return []hookdeck.ClientOption{
+		hookdeck.WithRetries(attempts, baseDelay),
+		hookdeck.WithRateLimit(rps),
+		hookdeck.WithTimeout(timeout),
+	}
+}
+
+// projectClientConfig returns cfg's client block, or nil for a single
+// manifest (nil cfg).
+func projectClientConfig(cfg *project.ProjectConfig) *project.ClientConfig {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.Client
+}
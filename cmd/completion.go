@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+// completeEnv suggests the env keys of the project config that --project /
+// --dir resolve to. For deploy, which takes a comma-separated list, it
+// completes the last entry and doesn't repeat the ones already listed.
+// Without a loadable project config it suggests nothing.
+func completeEnv(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	projectPath, err := resolveProjectPath()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	cfg, err := project.LoadProjectConfig(projectPath)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	prefix := ""
+	listed := map[string]bool{}
+	if cmd == deployCmd {
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			prefix = toComplete[:i+1]
+			for _, env := range splitEnvs(prefix) {
+				listed[env] = true
+			}
+		}
+	}
+
+	var envs []string
+	for env := range cfg.Env {
+		if !listed[env] {
+			envs = append(envs, prefix+env)
+		}
+	}
+	sort.Strings(envs)
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if cmd == deployCmd {
+		directive |= cobra.ShellCompDirectiveNoSpace
+	}
+	return envs, directive
+}
+
+// completeProfile suggests the profile names in the credential config files
+// listed by credentialConfigPaths. Unreadable files are skipped.
+func completeProfile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// Completion skips PersistentPreRunE, so apply --config here.
+	credentials.SetConfigPath(flagConfig)
+	paths, _ := credentialConfigPaths()
+
+	seen := map[string]bool{}
+	var names []string
+	for _, path := range paths {
+		profiles, err := credentials.ListProfiles(path)
+		if err != nil {
+			continue
+		}
+		for _, p := range profiles {
+			if !seen[p.Name] {
+				seen[p.Name] = true
+				names = append(names, p.Name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
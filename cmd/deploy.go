@@ -2,20 +2,52 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/drift"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/wrangler"
 )
 
-var flagSyncWrangler bool
+var (
+	flagSyncWrangler bool
+	flagWranglerEnv  string
+	flagStrictRefs   bool
+	flagNoValidate   bool
+	flagCheckSchema  bool
+	flagOnly         string
+	flagOnlyChanged  bool
+	flagBaseRef      string
+
+	flagSkipUnchanged      bool
+	flagPreserveRemoteAuth bool
+	flagDeleteOrphanRules  bool
+	flagRetryOnConflict    bool
+	flagCanonicalRuleOrder bool
+	flagReplace            bool
+	flagAllowTypeChange    bool
+	flagMaxPayloadSize     int
+	flagDumpRequest        bool
+	flagNoProgress         bool
+	flagDeployOutput       string
+	flagSummaryFile        string
+	flagVerbose            bool
+	flagOffline            bool
+	flagWatch              bool
+	flagFailFast           bool
+	flagConfirm            bool
+)
 
 var deployCmd = &cobra.Command{
 	Use:   "deploy",
@@ -28,85 +60,238 @@ order: source, transformation, destination, connection.`,
 
 func init() {
 	deployCmd.Flags().BoolVar(&flagSyncWrangler, "sync-wrangler", true, "sync source URL back to wrangler.jsonc after deploy")
+	deployCmd.Flags().StringVar(&flagWranglerEnv, "wrangler-env", "", "wrangler environment to sync the source URL into (default: the --env value)")
+	deployCmd.Flags().BoolVar(&flagSkipUnchanged, "skip-unchanged", false, "fetch each resource first and skip the upsert when it already matches the manifest")
+	deployCmd.Flags().BoolVar(&flagPreserveRemoteAuth, "preserve-remote-auth", false, "fetch each destination first and only send auth when it differs from the live config")
+	deployCmd.Flags().BoolVar(&flagDeleteOrphanRules, "delete-orphan-rules", false, "fetch each connection that declares no rules first and remove the rules it still has")
+	deployCmd.Flags().BoolVar(&flagRetryOnConflict, "retry-on-conflict", false, "retry an upsert rejected with 409 Conflict (e.g. by a concurrent deploy) up to 3 times with jittered backoff")
+	deployCmd.Flags().BoolVar(&flagCanonicalRuleOrder, "canonical-rule-order", false, "send each connection's rules in canonical order (transform, filter, retry, delay, deduplicate) instead of as declared, warning when explicit rules move")
+	deployCmd.Flags().BoolVar(&flagReplace, "replace", false, "delete and recreate connections whose live source or destination differs from the manifest, which an upsert can't change")
+	deployCmd.Flags().BoolVar(&flagAllowTypeChange, "allow-type-change", false, "deploy even when a source's type differs from its live type, which can recreate the source with a new ingest URL")
+	deployCmd.Flags().IntVar(&flagMaxPayloadSize, "max-payload-size", deploy.DefaultMaxCodeSize, "fail before upserting anything if a transformation's code is larger than this many bytes (0 disables the check)")
+	deployCmd.Flags().BoolVar(&flagDumpRequest, "dump-request", false, "print each upsert request body to stderr before sending it, with interpolated secrets masked")
+	deployCmd.Flags().StringVar(&flagOnly, "only", "", "in project mode, only deploy resources from manifests matching this glob (e.g. 'services/payments/**')")
+	deployCmd.Flags().BoolVar(&flagOnlyChanged, "only-changed", false, "in project mode, only deploy resources from manifests and code files changed since --base-ref (per git)")
+	deployCmd.Flags().StringVar(&flagBaseRef, "base-ref", "HEAD~1", "git ref --only-changed compares against, via its merge base with HEAD")
+	deployCmd.Flags().BoolVar(&flagNoValidate, "no-validate", false, "skip pre-deploy validation (source/destination types, retry rules)")
+	deployCmd.Flags().BoolVar(&flagCheckSchema, "validate-schema", false, "validate each manifest file against the embedded JSON Schema before deploying")
+	deployCmd.Flags().BoolVar(&flagOffline, "offline", false, "with --dry-run, skip fetching remote state and only list what would be upserted")
+	deployCmd.Flags().BoolVar(&flagNoProgress, "no-progress", false, "print a line per resource instead of a progress line, even on a terminal")
+	deployCmd.Flags().StringVarP(&flagDeployOutput, "output", "o", "text", "output format: text, table (aligned columns, colored on a terminal unless NO_COLOR is set), or env (also print an export line per source URL on stdout, for eval)")
+	deployCmd.Flags().StringVar(&flagSummaryFile, "summary-file", "", "also write the deploy results, summary counts, and timing as JSON to this file, even when the deploy fails")
+	deployCmd.Flags().BoolVarP(&flagVerbose, "verbose", "v", false, "show the manifest file each resource was declared in")
+	deployCmd.Flags().BoolVar(&flagStrictRefs, "strict-refs", false, "fail when a connection references a resource not defined in the manifest")
+	deployCmd.Flags().BoolVar(&flagFailFast, "fail-fast", false, "with several --env values, stop at the first environment that fails instead of continuing")
+	deployCmd.Flags().BoolVar(&flagConfirm, "confirm", false, "confirm deploying to an environment listed in the project's protected_environments")
+	deployCmd.Flags().BoolVar(&flagWatch, "watch", false, "after deploying, watch manifests and code files and redeploy affected resources on change")
 	rootCmd.AddCommand(deployCmd)
 }
 
-func runDeploy(cmd *cobra.Command, args []string) error {
+func runDeploy(cmd *cobra.Command, args []string) (err error) {
+	flagEnv = normalizeEnv(flagEnv)
+	switch flagDeployOutput {
+	case "text", "table":
+	case "env":
+		if len(splitEnvs(flagEnv)) > 1 {
+			return fmt.Errorf("--output env cannot be combined with multiple --env values")
+		}
+		if flagWatch {
+			return fmt.Errorf("--output env cannot be combined with --watch")
+		}
+	default:
+		return fmt.Errorf("invalid --output %q: expected text, table, or env", flagDeployOutput)
+	}
+	if flagSummaryFile != "" {
+		if len(splitEnvs(flagEnv)) > 1 {
+			return fmt.Errorf("--summary-file cannot be combined with multiple --env values")
+		}
+		if flagWatch {
+			return fmt.Errorf("--summary-file cannot be combined with --watch")
+		}
+		summaryRecorder = newResultRecorder(nil)
+		defer func() {
+			err = finishSummaryFile(summaryRecorder, err)
+			summaryRecorder = nil
+		}()
+	}
+	if flagOffline && !flagDryRun {
+		return fmt.Errorf("--offline requires --dry-run")
+	}
+	if flagMaxPayloadSize < 0 {
+		return fmt.Errorf("--max-payload-size must not be negative")
+	}
+	if flagCheckSchema {
+		if err := checkSchemas(); err != nil {
+			return err
+		}
+	}
+	if flagWatch {
+		if len(splitEnvs(flagEnv)) > 1 {
+			return fmt.Errorf("--watch cannot be combined with multiple --env values")
+		}
+		if flagDryRun {
+			return fmt.Errorf("--watch cannot be combined with --dry-run")
+		}
+		if flagOnly != "" || flagOnlyChanged {
+			return fmt.Errorf("--watch cannot be combined with --only or --only-changed")
+		}
+		return runWatchDeploy(cmd.Context())
+	}
+	if flagOnly != "" && flagOnlyChanged {
+		return fmt.Errorf("--only cannot be combined with --only-changed")
+	}
+	if cmd.Flags().Changed("base-ref") && !flagOnlyChanged {
+		return fmt.Errorf("--base-ref requires --only-changed")
+	}
+	if envs := splitEnvs(flagEnv); len(envs) > 1 {
+		return runMultiEnvDeploy(cmd.Context(), envs)
+	}
+	if flagFailFast {
+		return fmt.Errorf("--fail-fast requires more than one --env")
+	}
+	return deployEnv(cmd.Context())
+}
+
+// deployEnv runs one deploy for the current --env.
+func deployEnv(ctx context.Context) error {
 	// Check if we should use project mode:
 	// 1. --project flag was explicitly set, OR
 	// 2. no --file flag and a hookdeck.project.jsonc/json exists in CWD
 	if flagProject != "" || (flagFile == "" && projectFileExists()) {
-		return runProjectDeploy()
+		return runProjectDeploy(ctx)
+	}
+	if flagOnly != "" {
+		return fmt.Errorf("--only requires project mode")
 	}
-	return runSingleFileDeploy()
+	if flagOnlyChanged {
+		return fmt.Errorf("--only-changed requires project mode")
+	}
+	return runSingleFileDeploy(ctx)
 }
 
 // runSingleFileDeploy handles the single manifest file deploy flow.
-func runSingleFileDeploy() error {
-	ctx := context.Background()
-
+func runSingleFileDeploy(ctx context.Context) error {
 	// 1. Find and load manifest
 	manifestPath, err := resolveManifestPath()
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "Loading manifest: %s\n", manifestPath)
+	logger.Infof("Loading manifest: %s", manifestPath)
 
-	m, err := manifest.LoadFile(manifestPath)
+	m, err := loadManifest(manifestPath)
 	if err != nil {
-		return fmt.Errorf("loading manifest: %w", err)
+		return err
 	}
 
+	manifestDir := filepath.Dir(manifestPath)
+
 	// 2. Resolve environment overrides per resource
 	input := buildDeployInputFromManifest(m, flagEnv)
 
 	// 3. Interpolate secrets (${ENV_VAR}) — operate on the manifest with resolved resources
 	resolvedManifest := deployInputToManifest(input)
-	if err := manifest.InterpolateEnvVars(resolvedManifest); err != nil {
+	resolvedManifest.Vars = m.Vars
+	if err := interpolateManifest(resolvedManifest, manifestDir); err != nil {
 		return fmt.Errorf("interpolating env vars: %w", err)
 	}
 	// Re-extract input after interpolation
+	rawInput := input
 	input = manifestToDeployInput(resolvedManifest)
+	input.Disabled = rawInput.Disabled
+	input.External = rawInput.External
+	files := newResourceFiles(rawInput, input, manifestDir, singleFile(manifestPath))
 
-	// 4. Resolve credentials
-	profileName := flagProfile
+	if !flagNoValidate {
+		if err := validateInput(input); err != nil {
+			return err
+		}
+	}
 
-	var client deploy.Client
-	if !flagDryRun {
-		creds, err := credentials.Resolve(profileName)
-		if err != nil {
-			return fmt.Errorf("resolving credentials: %w", err)
+	// A single manifest may legitimately reference resources deployed from
+	// elsewhere, so unresolved references only warn unless --strict-refs is set.
+	if errs := deploy.CheckReferences(input); len(errs) > 0 {
+		msgs := make([]string, len(errs))
+		for i, e := range errs {
+			msgs[i] = e.Error()
 		}
+		if flagStrictRefs {
+			return fmt.Errorf("reference errors:\n  %s", strings.Join(msgs, "\n  "))
+		}
+		for _, msg := range msgs {
+			logger.Warnf("%s", msg)
+		}
+	}
+
+	// 4. Resolve credentials and create HTTP client for Hookdeck API
+	apiClient, err := resolveDeployClient(flagProfile, m.APIBaseURL, nil)
+	if err != nil {
+		return err
+	}
 
-		// 5. Create HTTP client for Hookdeck API
-		client = hookdeck.NewClient(creds.APIKey, creds.ProjectID)
+	// 5. With credentials, dry-run compares against remote state instead of
+	// running the deploy orchestration.
+	if flagDryRun && apiClient != nil {
+		return runDryRunPreview(ctx, apiClient, input, manifestDir, files)
+	}
+
+	var client deploy.Client
+	var checker deploy.UnchangedChecker
+	var authFetcher deploy.RemoteAuthFetcher
+	var rulesFetcher deploy.RemoteRulesFetcher
+	var replacer deploy.ConnectionReplacer
+	var sourceTypes deploy.RemoteSourceTypeFetcher
+	if apiClient != nil {
+		client = apiClient
+		c := drift.NewChecker(apiClient)
+		checker, authFetcher, rulesFetcher, sourceTypes = c, c, c, c
+		replacer = drift.NewReplacer(apiClient)
 	}
 
 	// 6. Run deploy orchestration
-	manifestDir := filepath.Dir(manifestPath)
+	reporter, finishReporter := newDeployReporter(input, files)
+	reporter = recordResults(reporter)
 	opts := deploy.Options{
-		DryRun:   flagDryRun,
-		CodeRoot: manifestDir,
+		DryRun:             flagDryRun,
+		CodeRoot:           manifestDir,
+		Reporter:           reporter,
+		SkipUnchanged:      flagSkipUnchanged,
+		Checker:            checker,
+		PreserveRemoteAuth: flagPreserveRemoteAuth,
+		AuthFetcher:        authFetcher,
+		DeleteOrphanRules:  flagDeleteOrphanRules,
+		RulesFetcher:       rulesFetcher,
+		DumpRequests:       dumpRequestWriter(),
+		Redact:             redact,
+		CanonicalRuleOrder: flagCanonicalRuleOrder,
+		Warnf:              logger.Warnf,
+		ReplaceConnections: flagReplace,
+		Replacer:           replacer,
+		SourceTypes:        sourceTypes,
+		AllowTypeChange:    flagAllowTypeChange,
+		MaxCodeSize:        flagMaxPayloadSize,
 	}
 
 	if flagDryRun {
-		fmt.Fprintln(os.Stderr, "Dry-run mode: no changes will be applied")
+		logger.Infof("Dry-run mode: no changes will be applied")
 	}
 
+	// Results are reported as each resource completes.
 	result, err := deploy.Deploy(ctx, client, input, opts)
+	finishReporter()
 	if err != nil {
-		return fmt.Errorf("deploy failed: %w", err)
+		printPartialResult(result)
+		return fmt.Errorf("deploy failed: %w", withFlagHint(err))
 	}
+	recordFinalResult(result)
+	fmt.Fprintf(os.Stderr, "\nSummary: %s\n", result.Summary())
+	printSourceExports(result)
 
-	// 7. Print results
-	printDeployResult(result)
-
-	// 8. Wrangler sync (if --sync-wrangler and at least one source was deployed)
+	// 7. Wrangler sync (if --sync-wrangler and at least one source was deployed)
 	if flagSyncWrangler && !flagDryRun && len(result.Sources) > 0 && result.Sources[0].ID != "" {
 		if err := syncWrangler(manifestDir, result.Sources[0].ID); err != nil {
 			// Wrangler sync is best-effort; warn but don't fail
-			fmt.Fprintf(os.Stderr, "Warning: wrangler sync failed: %v\n", err)
+			logger.Warnf("wrangler sync failed: %v", err)
 		}
 	}
 
@@ -114,76 +299,313 @@ func runSingleFileDeploy() error {
 }
 
 // runProjectDeploy handles the project-wide deploy flow.
-func runProjectDeploy() error {
-	ctx := context.Background()
-
+func runProjectDeploy(ctx context.Context) error {
 	// 1. Resolve project path
 	projectPath, err := resolveProjectPath()
 	if err != nil {
 		return err
 	}
 
-	fmt.Fprintf(os.Stderr, "Loading project: %s\n", projectPath)
+	logger.Infof("Loading project: %s", projectPath)
 
 	// 2. Load project (config + discover manifests + registry)
-	proj, err := project.LoadProject(projectPath)
+	proj, err := loadProject(projectPath)
 	if err != nil {
-		return fmt.Errorf("loading project: %w", err)
+		return err
+	}
+
+	if err := checkProjectEnv(proj.Config, flagEnv); err != nil {
+		return err
+	}
+	if !flagDryRun {
+		if err := confirmProtectedEnv(flagEnv, proj.Config.IsProtected(flagEnv)); err != nil {
+			return err
+		}
 	}
 
 	// 3. Resolve profile from project config env or --profile flag
 	profileName := flagProfile
-	if profileName == "" && proj.Config.Env != nil && flagEnv != "" {
-		if envCfg, ok := proj.Config.Env[flagEnv]; ok && envCfg.Profile != "" {
-			profileName = envCfg.Profile
-		}
+	if profileName == "" {
+		profileName = profileForEnv(proj.Config, flagEnv)
 	}
 
 	// 4. Build DeployInput from registry with env overrides
 	input := buildDeployInputFromRegistry(proj.Registry, flagEnv)
 
+	// The full project was loaded (so cross-file references validate), but
+	// --only narrows what is upserted to the matching manifests plus anything
+	// their connections need.
+	if flagOnly != "" {
+		input, err = project.SelectByFile(input, proj.Registry, proj.RootDir, flagOnly)
+		if err != nil {
+			return err
+		}
+	}
+	if flagOnlyChanged {
+		changed, err := gitChangedFiles(ctx, proj.RootDir, flagBaseRef)
+		switch {
+		case errors.Is(err, errNotGitRepo):
+			logger.Warnf("--only-changed: %s is not in a git repository; deploying all resources", proj.RootDir)
+		case err != nil:
+			return err
+		default:
+			if input, err = selectChanged(input, proj, projectPath, changed); err != nil {
+				return err
+			}
+			if len(input.Sources)+len(input.Transformations)+len(input.Destinations)+len(input.Connections)+len(input.Disabled) == 0 {
+				logger.Infof("No resources changed since %s", flagBaseRef)
+				return nil
+			}
+		}
+	}
+
 	// 5. Interpolate env vars
 	resolvedManifest := deployInputToManifest(input)
-	if err := manifest.InterpolateEnvVars(resolvedManifest); err != nil {
+	resolvedManifest.Vars = proj.Registry.Vars
+	if err := interpolateManifest(resolvedManifest, proj.RootDir); err != nil {
 		return fmt.Errorf("interpolating env vars: %w", err)
 	}
+	rawInput := input
 	input = manifestToDeployInput(resolvedManifest)
+	input.Disabled = rawInput.Disabled
+	input.External = rawInput.External
+	files := newResourceFiles(rawInput, input, proj.RootDir, proj.Registry.FileFor)
+
+	if !flagNoValidate {
+		if err := validateInput(input); err != nil {
+			return err
+		}
+	}
+
+	// Order resources by their actual references so cross-file dependencies
+	// are always upserted first.
+	input, err = project.SortDeployInput(input)
+	if err != nil {
+		return fmt.Errorf("ordering resources: %w", err)
+	}
 
 	// 6. Resolve credentials and create client
+	apiClient, err := resolveDeployClient(profileName, proj.Config.APIBaseURL, proj.Config.Client)
+	if err != nil {
+		return err
+	}
+	// code_file paths are already absolute here (see CodeRoot below).
+	if flagDryRun && apiClient != nil {
+		return runDryRunPreview(ctx, apiClient, input, "", files)
+	}
+
 	var client deploy.Client
-	if !flagDryRun {
-		creds, err := credentials.Resolve(profileName)
-		if err != nil {
-			return fmt.Errorf("resolving credentials: %w", err)
-		}
-		client = hookdeck.NewClient(creds.APIKey, creds.ProjectID)
+	var checker deploy.UnchangedChecker
+	var authFetcher deploy.RemoteAuthFetcher
+	var rulesFetcher deploy.RemoteRulesFetcher
+	var replacer deploy.ConnectionReplacer
+	var sourceTypes deploy.RemoteSourceTypeFetcher
+	if apiClient != nil {
+		client = apiClient
+		c := drift.NewChecker(apiClient)
+		checker, authFetcher, rulesFetcher, sourceTypes = c, c, c, c
+		replacer = drift.NewReplacer(apiClient)
 	}
 
 	// 7. Deploy
 	// CodeRoot is empty because buildDeployInputFromRegistry already resolves
 	// each transformation's code_file to an absolute path relative to its
 	// manifest directory.
+	reporter, finishReporter := newDeployReporter(input, files)
+	reporter = recordResults(reporter)
 	opts := deploy.Options{
-		DryRun: flagDryRun,
+		DryRun:             flagDryRun,
+		Reporter:           reporter,
+		SkipUnchanged:      flagSkipUnchanged,
+		Checker:            checker,
+		PreserveRemoteAuth: flagPreserveRemoteAuth,
+		AuthFetcher:        authFetcher,
+		DeleteOrphanRules:  flagDeleteOrphanRules,
+		RulesFetcher:       rulesFetcher,
+		DumpRequests:       dumpRequestWriter(),
+		Redact:             redact,
+		CanonicalRuleOrder: flagCanonicalRuleOrder,
+		Warnf:              logger.Warnf,
+		ReplaceConnections: flagReplace,
+		Replacer:           replacer,
+		SourceTypes:        sourceTypes,
+		AllowTypeChange:    flagAllowTypeChange,
+		MaxCodeSize:        flagMaxPayloadSize,
 	}
 
 	if flagDryRun {
-		fmt.Fprintln(os.Stderr, "Dry-run mode: no changes will be applied")
+		logger.Infof("Dry-run mode: no changes will be applied")
 	}
 
+	// Results are reported as each resource completes.
 	result, err := deploy.Deploy(ctx, client, input, opts)
+	finishReporter()
 	if err != nil {
-		return fmt.Errorf("deploy failed: %w", err)
+		printPartialResult(result)
+		return fmt.Errorf("deploy failed: %w", withFlagHint(err))
 	}
+	recordFinalResult(result)
+	fmt.Fprintf(os.Stderr, "\nSummary: %s\n", result.Summary())
+	printSourceExports(result)
 
-	// 8. Print results
-	printDeployResult(result)
+	return nil
+}
+
+// printSourceExports prints, under --output env, an
+// "export SOURCE_<NAME>_URL=..." line on stdout for each deployed source, so
+// CI can eval the ingest URLs. Sources without an ID (dry-run or disabled)
+// are left out.
+func printSourceExports(result *deploy.Result) {
+	if flagDeployOutput != "env" {
+		return
+	}
+	seen := map[string]string{}
+	for _, r := range result.Sources {
+		if r.ID == "" {
+			continue
+		}
+		name := sourceEnvVar(r.Name)
+		if other, ok := seen[name]; ok {
+			logger.Warnf("sources %q and %q both export %s; the last one wins", other, r.Name, name)
+		}
+		seen[name] = r.Name
+		fmt.Printf("export %s=%s\n", name, hookdeck.SourceIngestURL(r.ID))
+	}
+}
 
+// sourceEnvVar returns the SOURCE_<NAME>_URL variable for a source: its name
+// uppercased, with every character that isn't valid in a shell identifier
+// replaced by an underscore.
+func sourceEnvVar(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+	return "SOURCE_" + sanitized + "_URL"
+}
+
+// printPartialResult reports what an interrupted deploy applied before it
+// stopped. Deploy only returns a result with an error when ctx was done.
+func printPartialResult(result *deploy.Result) {
+	if result != nil {
+		fmt.Fprintf(os.Stderr, "\nStopped early. Applied before stopping: %s\n", result.Summary())
+	}
+}
+
+// resolveDeployClient resolves credentials and returns the API client for a
+// deploy. A dry-run returns a nil client when --offline is set or no
+// credentials are available, falling back to the blind "would upsert" preview.
+func resolveDeployClient(profileName, configuredBaseURL string, clientConfig *project.ClientConfig) (*hookdeck.Client, error) {
+	if flagDryRun && flagOffline {
+		return nil, nil
+	}
+	creds, err := credentials.Resolve(profileName)
+	if err != nil {
+		if flagDryRun {
+			logger.Infof("Note: %v; previewing without remote state", err)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("resolving credentials: %w", err)
+	}
+	return newAPIClient(creds, configuredBaseURL, clientConfig), nil
+}
+
+// runDryRunPreview prints the online dry-run comparison and its summary.
+func runDryRunPreview(ctx context.Context, apiClient *hookdeck.Client, input *deploy.DeployInput, codeRoot string, files resourceFiles) error {
+	logger.Infof("Dry-run mode: comparing against remote state, no changes will be applied")
+	result, _, err := previewDeploy(ctx, hookdeck.NewCachingClient(apiClient), input, codeRoot, files)
+	if err != nil {
+		return fmt.Errorf("dry-run failed: %w", withFlagHint(err))
+	}
+	recordFinalResult(result)
+	fmt.Fprintf(os.Stderr, "\nSummary: %s\n", result.Summary())
+	return nil
+}
+
+// profileForEnv returns the credential profile mapped to envName in the
+// project config, or "" when none is configured.
+func profileForEnv(cfg *project.ProjectConfig, envName string) string {
+	if cfg == nil || cfg.Env == nil || envName == "" {
+		return ""
+	}
+	if envCfg, ok := cfg.Env[envName]; ok && envCfg != nil {
+		return envCfg.Profile
+	}
+	return ""
+}
+
+// checkProjectEnv rejects an --env that isn't declared in the project
+// config's env block, so a typo can't silently deploy base values. It is a
+// no-op when envName is empty or --allow-undefined-env is set.
+func checkProjectEnv(cfg *project.ProjectConfig, envName string) error {
+	if envName == "" || flagAllowUndefinedEnv {
+		return nil
+	}
+	if _, ok := cfg.Env[envName]; ok {
+		return nil
+	}
+	valid := make([]string, 0, len(cfg.Env))
+	for name := range cfg.Env {
+		valid = append(valid, name)
+	}
+	sort.Strings(valid)
+	if len(valid) == 0 {
+		return fmt.Errorf("unknown environment %q: the project config declares no environments (use --allow-undefined-env to deploy base values)", envName)
+	}
+	return fmt.Errorf("unknown environment %q: valid environments are %s (use --allow-undefined-env to deploy base values)", envName, strings.Join(valid, ", "))
+}
+
+// dumpRequestWriter returns where deploy --dump-request prints request
+// bodies, or nil when the flag isn't set.
+func dumpRequestWriter() io.Writer {
+	if !flagDumpRequest {
+		return nil
+	}
+	return os.Stderr
+}
+
+// withFlagHint appends the deploy flag that overrides the guard err came
+// from, if any.
+func withFlagHint(err error) error {
+	switch {
+	case errors.Is(err, deploy.ErrSourceTypeChange):
+		return fmt.Errorf("%w; pass --allow-type-change if this is intended", err)
+	case errors.Is(err, deploy.ErrCodeTooLarge):
+		return fmt.Errorf("%w; shrink it or raise --max-payload-size", err)
+	}
+	return err
+}
+
+// confirmProtectedEnv returns an error unless deploying to envName is
+// allowed: the environment isn't protected, --confirm was given, or the user
+// answers yes on an interactive terminal.
+func confirmProtectedEnv(envName string, protected bool) error {
+	if envName == "" || flagConfirm || !protected {
+		return nil
+	}
+	p := newPrompter(os.Stdin, os.Stderr)
+	if !p.interactive {
+		return fmt.Errorf("environment %q is protected: pass --confirm to deploy to it", envName)
+	}
+	ok, err := p.confirm(fmt.Sprintf("Deploy to protected environment %q?", envName))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("deploy to protected environment %q aborted", envName)
+	}
 	return nil
 }
 
 // buildDeployInputFromManifest constructs a DeployInput from a loaded manifest,
-// applying per-resource environment overrides.
+// applying per-resource environment overrides. Resources resolved to
+// "enabled": false end up in input.Disabled, and "external": true
+// placeholders in input.External.
 func buildDeployInputFromManifest(m *manifest.Manifest, envName string) *deploy.DeployInput {
 	input := &deploy.DeployInput{}
 
@@ -204,11 +626,15 @@ func buildDeployInputFromManifest(m *manifest.Manifest, envName string) *deploy.
 		input.Connections = append(input.Connections, resolved)
 	}
 
+	input.ExcludeExternal()
+	input.ExcludeDisabled()
 	return input
 }
 
 // buildDeployInputFromRegistry constructs a DeployInput from a project registry,
-// applying per-resource environment overrides.
+// applying per-resource environment overrides. Resources resolved to
+// "enabled": false end up in input.Disabled, and "external": true
+// placeholders in input.External.
 func buildDeployInputFromRegistry(reg *project.Registry, envName string) *deploy.DeployInput {
 	input := &deploy.DeployInput{}
 
@@ -222,13 +648,23 @@ func buildDeployInputFromRegistry(reg *project.Registry, envName string) *deploy
 	}
 	for i := range reg.TransformationList {
 		resolved := manifest.ResolveTransformationEnv(&reg.TransformationList[i], envName)
-		// Resolve code_file relative to the manifest directory so that
-		// project-mode deploys find the file regardless of CWD.
-		if resolved.CodeFile != "" && !filepath.IsAbs(resolved.CodeFile) {
-			if ref, ok := reg.Transformations[resolved.Name]; ok {
-				manifestDir := filepath.Dir(ref.FilePath)
+		// Resolve code_file / code_files relative to the manifest directory so
+		// that project-mode deploys find the files regardless of CWD.
+		if ref, ok := reg.Transformations[resolved.Name]; ok {
+			manifestDir := filepath.Dir(ref.FilePath)
+			if resolved.CodeFile != "" && !filepath.IsAbs(resolved.CodeFile) {
 				resolved.CodeFile = filepath.Join(manifestDir, resolved.CodeFile)
 			}
+			if len(resolved.CodeFiles) > 0 {
+				codeFiles := make([]string, len(resolved.CodeFiles))
+				for j, f := range resolved.CodeFiles {
+					if !filepath.IsAbs(f) {
+						f = filepath.Join(manifestDir, f)
+					}
+					codeFiles[j] = f
+				}
+				resolved.CodeFiles = codeFiles
+			}
 		}
 		input.Transformations = append(input.Transformations, resolved)
 	}
@@ -237,6 +673,8 @@ func buildDeployInputFromRegistry(reg *project.Registry, envName string) *deploy
 		input.Connections = append(input.Connections, resolved)
 	}
 
+	input.ExcludeExternal()
+	input.ExcludeDisabled()
 	return input
 }
 
@@ -276,18 +714,59 @@ func manifestToDeployInput(m *manifest.Manifest) *deploy.DeployInput {
 	return input
 }
 
+// loadManifest loads the manifest at path and checks its format version. A
+// version problem is a warning, or an error under --strict.
+func loadManifest(path string) (*manifest.Manifest, error) {
+	m, err := manifest.LoadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading manifest: %w", err)
+	}
+	if err := manifest.CheckVersion(m); err != nil {
+		if flagStrict {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		logger.Warnf("%s: %v", path, err)
+	}
+	return m, nil
+}
+
+// loadProject loads the project at path like loadManifest loads a manifest,
+// logging the warnings of its manifests.
+func loadProject(path string) (*project.Project, error) {
+	proj, err := project.LoadProjectWithOptions(path, project.LoadOptions{
+		Parallelism:   flagParallelManifests,
+		StrictVersion: flagStrict,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading project: %w", err)
+	}
+	for _, w := range proj.Warnings {
+		logger.Warnf("%s", w)
+	}
+	return proj, nil
+}
+
 // resolveProjectPath determines which project config file to use.
 func resolveProjectPath() (string, error) {
 	if flagProject != "" {
-		if _, err := os.Stat(flagProject); err != nil {
+		info, err := os.Stat(flagProject)
+		if err != nil {
 			return "", fmt.Errorf("project file not found: %s", flagProject)
 		}
+		if info.IsDir() {
+			return "", fmt.Errorf("--project must point at a hookdeck.project.jsonc file, not a directory (use --dir to search %s)", flagProject)
+		}
+		// Commands that only need the project's directory would otherwise
+		// accept a manifest here without complaint.
+		if _, err := project.LoadProjectConfig(flagProject); err != nil {
+			return "", fmt.Errorf("--project: %w; use --file for a single manifest", err)
+		}
 		return flagProject, nil
 	}
 
-	cwd, err := os.Getwd()
+	cwd, err := discoveryDir()
 	if err != nil {
-		return "", fmt.Errorf("getting working directory: %w", err)
+		return "", err
 	}
 
 	for _, name := range []string{"hookdeck.project.jsonc", "hookdeck.project.json"} {
@@ -301,9 +780,9 @@ func resolveProjectPath() (string, error) {
 }
 
 // projectFileExists checks if a hookdeck.project.jsonc or hookdeck.project.json file
-// exists in the current working directory.
+// exists in the discovery directory.
 func projectFileExists() bool {
-	cwd, err := os.Getwd()
+	cwd, err := discoveryDir()
 	if err != nil {
 		return false
 	}
@@ -316,8 +795,25 @@ func projectFileExists() bool {
 	return false
 }
 
+// discoveryDir returns the directory searched for hookdeck.project.jsonc and
+// hookdeck.jsonc: --dir when given, otherwise the working directory.
+func discoveryDir() (string, error) {
+	if flagDir != "" {
+		info, err := os.Stat(flagDir)
+		if err != nil || !info.IsDir() {
+			return "", fmt.Errorf("--dir %s is not a directory", flagDir)
+		}
+		return flagDir, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("getting working directory: %w", err)
+	}
+	return cwd, nil
+}
+
 // resolveManifestPath determines which manifest file to use.
-// If --file was provided, use it directly. Otherwise, auto-discover in cwd.
+// If --file was provided, use it directly. Otherwise, auto-discover in the discovery directory.
 func resolveManifestPath() (string, error) {
 	if flagFile != "" {
 		if _, err := os.Stat(flagFile); err != nil {
@@ -326,9 +822,9 @@ func resolveManifestPath() (string, error) {
 		return flagFile, nil
 	}
 
-	cwd, err := os.Getwd()
+	cwd, err := discoveryDir()
 	if err != nil {
-		return "", fmt.Errorf("getting working directory: %w", err)
+		return "", err
 	}
 
 	for _, name := range []string{"hookdeck.jsonc", "hookdeck.json"} {
@@ -352,45 +848,72 @@ func syncWrangler(manifestDir, sourceID string) error {
 		}
 	}
 
-	// The source URL is the Hookdeck ingest URL for the source.
-	envName := flagEnv
+	envName := wrangler.TargetEnv(flagWranglerEnv, flagEnv)
 	if envName == "" {
-		envName = "staging" // default environment for wrangler sync
+		logger.Warnf("skipping wrangler sync: pass --env or --wrangler-env to choose the wrangler environment")
+		return nil
 	}
 
-	sourceURL := fmt.Sprintf("https://hk-%s.hookdeck.com", sourceID)
-
-	modified, err := wrangler.SyncSourceURL(wranglerPath, envName, sourceURL)
+	modified, err := wrangler.SyncSourceURL(wranglerPath, envName, hookdeck.SourceIngestURL(sourceID))
 	if err != nil {
 		return err
 	}
 	if modified {
-		fmt.Fprintf(os.Stderr, "Synced source URL to %s (env: %s)\n", wranglerPath, envName)
+		logger.Infof("Synced source URL to %s (env: %s)", wranglerPath, envName)
+	} else {
+		logger.Infof("Source URL in %s is up to date (env: %s)", wranglerPath, envName)
 	}
 	return nil
 }
 
-// printDeployResult prints the deploy results to stderr.
-func printDeployResult(result *deploy.Result) {
-	for _, r := range result.Sources {
-		printResourceResult("Source", r)
-	}
-	for _, r := range result.Transformations {
-		printResourceResult("Transformation", r)
-	}
-	for _, r := range result.Destinations {
-		printResourceResult("Destination", r)
+// streamReporter is the default deploy.Reporter for the CLI. It prints each
+// resource's result line as soon as that resource completes, so long-running
+// deploys show progress instead of a single dump at the end.
+type streamReporter struct {
+	// files, when set (--verbose), adds each resource's manifest file to its line.
+	files resourceFiles
+}
+
+// newStreamReporter returns a streamReporter that shows files only under
+// --verbose.
+func newStreamReporter(files resourceFiles) streamReporter {
+	if !flagVerbose {
+		return streamReporter{}
 	}
-	for _, r := range result.Connections {
-		printResourceResult("Connection", r)
+	return streamReporter{files: files}
+}
+
+func (streamReporter) OnResourceStart(kind, name string) {}
+
+func (s streamReporter) OnResourceDone(kind string, r *deploy.ResourceResult) {
+	printResourceResult(kindLabel(kind), r, s.files.lookup(kind, r.Name))
+}
+
+// kindLabel turns a reporter kind ("source") into its display label ("Source").
+func kindLabel(kind string) string {
+	if kind == "" {
+		return kind
 	}
+	return strings.ToUpper(kind[:1]) + kind[1:]
 }
 
-// printResourceResult prints a single resource result line.
-func printResourceResult(kind string, r *deploy.ResourceResult) {
+// printResourceResult prints a single resource result line, followed by the
+// declaring manifest file when file is non-empty.
+func printResourceResult(kind string, r *deploy.ResourceResult, file string) {
+	fmt.Fprintln(os.Stderr, resourceResultLine(kind, r, file))
+}
+
+// resourceResultLine formats the line printResourceResult prints.
+func resourceResultLine(kind string, r *deploy.ResourceResult, file string) string {
+	line := fmt.Sprintf("  %-16s %-30s %s", kind, r.Name, r.Action)
+	if r.Reason != "" {
+		line += ": " + r.Reason
+	}
 	if r.ID != "" {
-		fmt.Fprintf(os.Stderr, "  %-16s %-30s %s (id: %s)\n", kind, r.Name, r.Action, r.ID)
-	} else {
-		fmt.Fprintf(os.Stderr, "  %-16s %-30s %s\n", kind, r.Name, r.Action)
+		line += fmt.Sprintf(" (id: %s)", r.ID)
+	}
+	if file != "" {
+		line += fmt.Sprintf("  [%s]", file)
 	}
+	return line
 }
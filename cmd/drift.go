@@ -2,14 +2,17 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/drift"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
 )
 
 var driftCmd = &cobra.Command{
@@ -17,88 +20,79 @@ var driftCmd = &cobra.Command{
 	Short: "Detect drift between manifest and live Hookdeck resources",
 	Long: `Drift compares the resources declared in a manifest file against their
 current state on Hookdeck. Reports resources that are missing, drifted
-(field values differ), or in sync.`,
+(field values differ), or in sync. External placeholders and resources
+resolved to "enabled": false for --env, with the connections that reference
+them, are skipped. When a project config is found, its client block sets
+the API retry, rate limit, and timeout defaults.
+
+With --output json, the diffs are written to stdout as a JSON array for CI
+to parse. With --quiet, only a one-line summary is printed.
+
+Drift exits non-zero when any resource is missing or drifted, unless
+--exit-zero is set. Use --fail-on missing to only fail on missing resources
+and report field drift as a warning, or --fail-on drifted for the reverse.`,
 	RunE: runDrift,
 }
 
+var (
+	flagDriftOutput      string
+	flagDriftExitZero    bool
+	flagDriftQuiet       bool
+	flagDriftFailOn      string
+	flagDriftFailOnDrift bool
+)
+
 func init() {
+	driftCmd.Flags().StringVarP(&flagDriftOutput, "output", "o", "text", "output format: text, json, or table (aligned columns, colored on a terminal unless NO_COLOR is set)")
+	driftCmd.Flags().BoolVar(&flagDriftExitZero, "exit-zero", false, "exit 0 even when drift is detected")
+	driftCmd.Flags().BoolVarP(&flagDriftQuiet, "quiet", "q", false, "print only a one-line summary")
+	driftCmd.Flags().StringVar(&flagDriftFailOn, "fail-on", drift.FailOnAny, "which diffs fail the check: missing, drifted, or any")
+	driftCmd.Flags().BoolVar(&flagDriftFailOnDrift, "fail-on-drift", false, "alias for --fail-on any")
+	driftCmd.MarkFlagsMutuallyExclusive("fail-on-drift", "exit-zero")
+	driftCmd.MarkFlagsMutuallyExclusive("fail-on-drift", "fail-on")
 	rootCmd.AddCommand(driftCmd)
 }
 
 func runDrift(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
-
-	// 1. Load and resolve manifest
-	manifestPath, err := resolveManifestPath()
-	if err != nil {
-		return err
-	}
+	ctx := cmd.Context()
 
-	fmt.Fprintf(os.Stderr, "Loading manifest: %s\n", manifestPath)
-
-	m, err := manifest.LoadFile(manifestPath)
-	if err != nil {
-		return fmt.Errorf("loading manifest: %w", err)
+	if flagDriftOutput != "text" && flagDriftOutput != "json" && flagDriftOutput != "table" {
+		return fmt.Errorf("invalid --output %q: expected text, json, or table", flagDriftOutput)
 	}
-
-	// 2. Resolve environment overrides per resource
-	var sources []*manifest.SourceConfig
-	for i := range m.Sources {
-		sources = append(sources, manifest.ResolveSourceEnv(&m.Sources[i], flagEnv))
+	if flagDriftFailOnDrift {
+		flagDriftFailOn = drift.FailOnAny
 	}
-
-	var destinations []*manifest.DestinationConfig
-	for i := range m.Destinations {
-		destinations = append(destinations, manifest.ResolveDestinationEnv(&m.Destinations[i], flagEnv))
+	if _, err := drift.Failing(nil, flagDriftFailOn); err != nil {
+		return fmt.Errorf("invalid --fail-on: %w", err)
 	}
 
-	var transformations []*manifest.TransformationConfig
-	for i := range m.Transformations {
-		transformations = append(transformations, manifest.ResolveTransformationEnv(&m.Transformations[i], flagEnv))
+	// 1. Load and resolve manifest
+	manifestPath, err := resolveManifestPath()
+	if err != nil {
+		return err
 	}
 
-	var connections []*manifest.ConnectionConfig
-	for i := range m.Connections {
-		conn := m.Connections[i]
-		connections = append(connections, &conn)
+	if !flagDriftQuiet {
+		logger.Infof("Loading manifest: %s", manifestPath)
 	}
 
-	// 3. Interpolate env vars — rebuild a manifest for interpolation
-	resolvedManifest := &manifest.Manifest{}
-	for _, src := range sources {
-		resolvedManifest.Sources = append(resolvedManifest.Sources, *src)
-	}
-	for _, dst := range destinations {
-		resolvedManifest.Destinations = append(resolvedManifest.Destinations, *dst)
-	}
-	for _, tr := range transformations {
-		resolvedManifest.Transformations = append(resolvedManifest.Transformations, *tr)
-	}
-	for _, conn := range connections {
-		resolvedManifest.Connections = append(resolvedManifest.Connections, *conn)
+	m, err := loadManifest(manifestPath)
+	if err != nil {
+		return err
 	}
 
-	if err := manifest.InterpolateEnvVars(resolvedManifest); err != nil {
+	// 2. Resolve environment overrides and interpolate env vars, the way
+	// deploy builds its input. External placeholders are managed elsewhere,
+	// and resources resolved to "enabled": false (with the connections that
+	// reference them) aren't deployed, so neither is this manifest's drift.
+	input := buildDeployInputFromManifest(m, flagEnv)
+	resolvedManifest := deployInputToManifest(input)
+	resolvedManifest.Vars = m.Vars
+	if err := interpolateManifest(resolvedManifest, filepath.Dir(manifestPath)); err != nil {
 		return fmt.Errorf("interpolating env vars: %w", err)
 	}
-
-	// Re-extract pointers after interpolation
-	sources = nil
-	for i := range resolvedManifest.Sources {
-		sources = append(sources, &resolvedManifest.Sources[i])
-	}
-	destinations = nil
-	for i := range resolvedManifest.Destinations {
-		destinations = append(destinations, &resolvedManifest.Destinations[i])
-	}
-	transformations = nil
-	for i := range resolvedManifest.Transformations {
-		transformations = append(transformations, &resolvedManifest.Transformations[i])
-	}
-	connections = nil
-	for i := range resolvedManifest.Connections {
-		connections = append(connections, &resolvedManifest.Connections[i])
-	}
+	input = manifestToDeployInput(resolvedManifest)
+	sources, destinations, transformations, connections := input.Sources, input.Destinations, input.Transformations, input.Connections
 
 	// 4. Resolve credentials
 	profileName := flagProfile
@@ -107,25 +101,65 @@ func runDrift(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("resolving credentials: %w", err)
 	}
 
-	client := hookdeck.NewClient(creds.APIKey, creds.ProjectID)
+	// The project config, when there is one, supplies the client settings.
+	var cfg *project.ProjectConfig
+	if flagProject != "" || (flagFile == "" && projectFileExists()) {
+		projectPath, err := resolveProjectPath()
+		if err != nil {
+			return err
+		}
+		if cfg, err = project.LoadProjectConfig(projectPath); err != nil {
+			return fmt.Errorf("loading project config: %w", err)
+		}
+	}
+
+	// Lookups are cached for the run so a name referenced more than once is
+	// only fetched once.
+	client := hookdeck.NewCachingClient(newAPIClient(creds, m.APIBaseURL, projectClientConfig(cfg)))
 
 	// 5. Fetch remote state and detect drift for each resource
-	fmt.Fprintln(os.Stderr, "Fetching remote state...")
+	if !flagDriftQuiet {
+		logger.Infof("Fetching remote state...")
+	}
 	remote, err := fetchRemoteState(ctx, client, sources, destinations, transformations, connections)
 	if err != nil {
 		return fmt.Errorf("fetching remote state: %w", err)
 	}
 
 	// 6. Detect drift
-	diffs := drift.Detect(sources, destinations, transformations, connections, remote)
+	diffs := drift.Detect(sources, destinations, transformations, connections, remote, filepath.Dir(manifestPath))
+	diffs = redactDiffs(diffs)
 
 	// 7. Print results
+	if flagDriftOutput == "json" {
+		if diffs == nil {
+			diffs = []drift.Diff{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(diffs); err != nil {
+			return fmt.Errorf("encoding drift report: %w", err)
+		}
+		return driftResult(diffs)
+	}
+
 	if len(diffs) == 0 {
-		fmt.Fprintln(os.Stderr, "\nAll resources in sync.")
+		if !flagDriftQuiet {
+			fmt.Fprintln(os.Stderr)
+		}
+		fmt.Fprintln(os.Stderr, "All resources in sync.")
 		return nil
 	}
+	if flagDriftQuiet {
+		return driftResult(diffs)
+	}
 
 	fmt.Fprintln(os.Stderr)
+	if flagDriftOutput == "table" {
+		printDriftTable(diffs)
+		fmt.Fprintln(os.Stderr)
+		return driftResult(diffs)
+	}
 	for _, d := range diffs {
 		switch d.Status {
 		case drift.Missing:
@@ -140,12 +174,54 @@ func runDrift(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Fprintln(os.Stderr)
 
-	return fmt.Errorf("drift detected: %d resource(s) out of sync", len(diffs))
+	return driftResult(diffs)
+}
+
+// printDriftTable prints diffs as a table with a row per drifted field,
+// missing resources in red and drifted ones in yellow.
+func printDriftTable(diffs []drift.Diff) {
+	tbl := newTable("KIND", "NAME", "STATUS", "FIELD", "LOCAL", "REMOTE")
+	for _, d := range diffs {
+		switch d.Status {
+		case drift.Missing:
+			tbl.addRow(plainCell(kindLabel(d.Kind)), plainCell(d.Name), tableCell{text: "MISSING", color: colorRed})
+		case drift.Drifted:
+			for _, f := range d.Fields {
+				tbl.addRow(
+					plainCell(kindLabel(d.Kind)),
+					plainCell(d.Name),
+					tableCell{text: "DRIFTED", color: colorYellow},
+					plainCell(f.Field),
+					plainCell(f.Local),
+					plainCell(f.Remote),
+				)
+			}
+		}
+	}
+	tbl.render(os.Stderr, colorEnabled(os.Stderr))
+}
+
+// driftResult returns the command error when diffs contains any diff
+// selected by --fail-on, or nil after printing a one-line summary when there
+// is no drift, none of it fails the check, or --exit-zero is set.
+func driftResult(diffs []drift.Diff) error {
+	if len(diffs) == 0 {
+		return nil
+	}
+	missing, drifted := drift.Count(diffs)
+	summary := fmt.Sprintf("%d resource(s) out of sync (%d missing, %d drifted)", len(diffs), missing, drifted)
+
+	failing, _ := drift.Failing(diffs, flagDriftFailOn)
+	if failing == 0 || flagDriftExitZero {
+		fmt.Fprintf(os.Stderr, "Drift detected: %s\n", summary)
+		return nil
+	}
+	return fmt.Errorf("drift detected: %s", summary)
 }
 
 func fetchRemoteState(
 	ctx context.Context,
-	client *hookdeck.Client,
+	client *hookdeck.CachingClient,
 	sources []*manifest.SourceConfig,
 	destinations []*manifest.DestinationConfig,
 	transformations []*manifest.TransformationConfig,
@@ -170,7 +246,7 @@ func fetchRemoteState(
 	}
 
 	for _, conn := range connections {
-		detail, err := client.GetConnectionByFullName(ctx, conn.Name)
+		detail, err := drift.FetchConnection(ctx, client, conn)
 		if err != nil {
 			return nil, fmt.Errorf("fetching connection %q: %w", conn.Name, err)
 		}
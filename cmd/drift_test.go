@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// emptyAPI starts a fake Hookdeck API on which nothing exists and points
+// the client at it. It returns the paths requested so far.
+func emptyAPI(t *testing.T) func() []string {
+	t.Helper()
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"models": [], "count": 0}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("HOOKDECK_API_KEY", "test-key")
+	t.Setenv("HOOKDECK_API_BASE_URL", srv.URL)
+	return func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+}
+
+func TestRunDrift_SkipsDisabledResources(t *testing.T) {
+	requested := emptyAPI(t)
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"hookdeck.jsonc": `{
+		"sources": [{"name": "shop"}],
+		"destinations": [
+			{"name": "api", "url": "https://api.example.com", "env": {"production": {"enabled": false}}}
+		],
+		"connections": [{"name": "orders", "source": "shop", "destination": "api"}]
+	}`})
+	defer func(file, env string) { flagFile, flagEnv = file, env }(flagFile, flagEnv)
+	flagFile, flagEnv = filepath.Join(dir, "hookdeck.jsonc"), "production"
+
+	driftCmd.SetContext(context.Background())
+	err := runDrift(driftCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "1 resource(s) out of sync (1 missing, 0 drifted)") {
+		t.Fatalf("expected only the source to be missing, got %v", err)
+	}
+	for _, path := range requested() {
+		if path == "/destinations" || path == "/connections" {
+			t.Errorf("expected no lookup of the disabled destination or its connection, got %s", path)
+		}
+	}
+}
+
+func TestRunDrift_UsesProjectClientConfig(t *testing.T) {
+	// The first request fails transiently; only the project's
+	// retry_attempts lets drift get past it.
+	var mu sync.Mutex
+	failed := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"models": [], "count": 0}`))
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("HOOKDECK_API_KEY", "test-key")
+	t.Setenv("HOOKDECK_API_BASE_URL", srv.URL)
+
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"hookdeck.project.jsonc": `{"client": {"retry_attempts": 1, "retry_base_delay_ms": 1}}`,
+		"hookdeck.jsonc":         `{"sources": [{"name": "shop"}]}`,
+	})
+	defer func(d string) { flagDir = d }(flagDir)
+	flagDir = dir
+
+	driftCmd.SetContext(context.Background())
+	err := runDrift(driftCmd, nil)
+	if err == nil || !strings.Contains(err.Error(), "1 resource(s) out of sync (1 missing, 0 drifted)") {
+		t.Fatalf("expected the retried lookup to report the source missing, got %v", err)
+	}
+}
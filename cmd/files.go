@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+// resourceFiles records which manifest file declared each resource, keyed by
+// kind ("source", ...) and then by resolved resource name. A nil
+// resourceFiles is valid and knows no files.
+type resourceFiles map[string]map[string]string
+
+// lookup returns the file that declared the named resource, or "".
+func (f resourceFiles) lookup(kind, name string) string {
+	return f[kind][name]
+}
+
+// newResourceFiles pairs every resource in resolved with the file fileOf
+// reports for the resource at the same position in raw. Interpolation keeps
+// resource order, so the two line up even when names use ${VAR}. Paths are
+// shown relative to baseDir when possible.
+func newResourceFiles(raw, resolved *deploy.DeployInput, baseDir string, fileOf func(kind, name string) string) resourceFiles {
+	files := resourceFiles{}
+	add := func(kind, rawName, name string) {
+		path := fileOf(kind, rawName)
+		if path == "" {
+			return
+		}
+		if rel, err := filepath.Rel(baseDir, path); err == nil {
+			path = rel
+		}
+		if files[kind] == nil {
+			files[kind] = map[string]string{}
+		}
+		files[kind][name] = path
+	}
+	for i, src := range resolved.Sources {
+		add(project.KindSource, raw.Sources[i].Name, src.Name)
+	}
+	for i, tr := range resolved.Transformations {
+		add(project.KindTransformation, raw.Transformations[i].Name, tr.Name)
+	}
+	for i, dst := range resolved.Destinations {
+		add(project.KindDestination, raw.Destinations[i].Name, dst.Name)
+	}
+	for i, conn := range resolved.Connections {
+		add(project.KindConnection, raw.Connections[i].Name, conn.Name)
+	}
+	return files
+}
+
+// singleFile returns a fileOf function that attributes every resource to path.
+func singleFile(path string) func(kind, name string) string {
+	return func(kind, name string) string { return path }
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+var flagGraphFormat string
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Print the resource relationships of a manifest or project as a graph",
+	Long: `Graph loads the project or manifest (same resolution as deploy), applies the
+--env overlay, and prints a Graphviz DOT or Mermaid graph: each connection is
+an edge from its source to its destination, and each transformation it runs is
+attached to that edge. It never contacts the Hookdeck API, so no credentials
+are needed.
+
+  hookdeck-deploy graph | dot -Tsvg > resources.svg`,
+	Args: cobra.NoArgs,
+	RunE: runGraph,
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&flagGraphFormat, "format", "dot", "graph format: dot or mermaid")
+	rootCmd.AddCommand(graphCmd)
+}
+
+// graphNode is one resource in the graph. external marks an endpoint a
+// connection references by literal ID rather than by a declared resource.
+type graphNode struct {
+	kind     string
+	name     string
+	external bool
+}
+
+// graphEdge links two nodes, by index. Edges from a connection's source to
+// its destination are solid; edges attaching a transformation are dashed.
+type graphEdge struct {
+	from, to int
+	label    string
+	dashed   bool
+}
+
+// resourceGraph is the format-independent graph the renderers draw.
+type resourceGraph struct {
+	nodes []graphNode
+	edges []graphEdge
+	index map[string]int
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	var render func(io.Writer, *resourceGraph)
+	switch flagGraphFormat {
+	case "dot":
+		render = renderDOT
+	case "mermaid":
+		render = renderMermaid
+	default:
+		return fmt.Errorf("invalid --format %q: expected dot or mermaid", flagGraphFormat)
+	}
+
+	resolved, err := loadResolvedInput()
+	if err != nil {
+		return err
+	}
+	render(os.Stdout, buildResourceGraph(resolved.Input))
+	return nil
+}
+
+// buildResourceGraph adds every deployed resource in input as a node, then
+// an edge per connection and a dashed edge from each of its transformations
+// to its destination. Disabled resources are left out.
+func buildResourceGraph(input *deploy.DeployInput) *resourceGraph {
+	g := &resourceGraph{index: map[string]int{}}
+	for _, src := range input.Sources {
+		g.node(project.KindSource, src.Name, false)
+	}
+	for _, tr := range input.Transformations {
+		g.node(project.KindTransformation, tr.Name, false)
+	}
+	for _, dst := range input.Destinations {
+		g.node(project.KindDestination, dst.Name, false)
+	}
+
+	for _, conn := range input.Connections {
+		from := g.endpoint(project.KindSource, conn.Source, conn.SourceID)
+		to := g.endpoint(project.KindDestination, conn.Destination, conn.DestinationID)
+		label := conn.Name
+		if label == "" {
+			label = manifest.DefaultConnectionName(conn)
+		}
+		g.edges = append(g.edges, graphEdge{from: from, to: to, label: label})
+		for _, name := range manifest.TransformationRefs(conn) {
+			tr := g.node(project.KindTransformation, name, false)
+			g.edges = append(g.edges, graphEdge{from: tr, to: to, label: label, dashed: true})
+		}
+	}
+	return g
+}
+
+// node returns the index of the kind/name node, adding it if needed.
+// External nodes are keyed apart, so an ID never matches a resource name.
+func (g *resourceGraph) node(kind, name string, external bool) int {
+	key := kind + "/" + name
+	if external {
+		key = "external:" + key
+	}
+	if i, ok := g.index[key]; ok {
+		return i
+	}
+	g.index[key] = len(g.nodes)
+	g.nodes = append(g.nodes, graphNode{kind: kind, name: name, external: external})
+	return len(g.nodes) - 1
+}
+
+// endpoint returns the node for a connection endpoint referenced by name, or
+// an external node when it is referenced by literal ID.
+func (g *resourceGraph) endpoint(kind, name, id string) int {
+	if name == "" && id != "" {
+		return g.node(kind, id, true)
+	}
+	return g.node(kind, name, false)
+}
+
+// dotStyles are the Graphviz node attributes for each resource kind.
+var dotStyles = map[string]string{
+	project.KindSource:         `shape=box, style="rounded,filled", fillcolor="#dbeafe"`,
+	project.KindTransformation: `shape=hexagon, style=filled, fillcolor="#fef3c7"`,
+	project.KindDestination:    `shape=box, style=filled, fillcolor="#dcfce7"`,
+}
+
+// renderDOT writes g as a Graphviz digraph.
+func renderDOT(w io.Writer, g *resourceGraph) {
+	fmt.Fprintln(w, "digraph hookdeck {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [fontname="Helvetica"];`)
+	fmt.Fprintln(w, `  edge [fontname="Helvetica", fontsize=10];`)
+	for i, n := range g.nodes {
+		style := dotStyles[n.kind]
+		if n.external {
+			style = `shape=box, style=dashed`
+		}
+		fmt.Fprintf(w, "  n%d [label=%s, %s];\n", i, dotQuote(nodeLabel(n)), style)
+	}
+	for _, e := range g.edges {
+		attrs := "label=" + dotQuote(e.label)
+		if e.dashed {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(w, "  n%d -> n%d [%s];\n", e.from, e.to, attrs)
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// renderMermaid writes g as a Mermaid flowchart, with a class per resource
+// kind.
+func renderMermaid(w io.Writer, g *resourceGraph) {
+	fmt.Fprintln(w, "flowchart LR")
+	for i, n := range g.nodes {
+		class := n.kind
+		if n.external {
+			class = "external"
+		}
+		fmt.Fprintf(w, "  n%d%s:::%s\n", i, mermaidShape(n, mermaidQuote(nodeLabel(n))), class)
+	}
+	for _, e := range g.edges {
+		arrow := "-->"
+		if e.dashed {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(w, "  n%d %s|%s| n%d\n", e.from, arrow, mermaidQuote(e.label), e.to)
+	}
+	fmt.Fprintln(w, "  classDef source fill:#dbeafe,stroke:#1d4ed8")
+	fmt.Fprintln(w, "  classDef transformation fill:#fef3c7,stroke:#b45309")
+	fmt.Fprintln(w, "  classDef destination fill:#dcfce7,stroke:#15803d")
+	fmt.Fprintln(w, "  classDef external stroke-dasharray:4 2")
+}
+
+// mermaidShape wraps label in the node shape for n's kind.
+func mermaidShape(n graphNode, label string) string {
+	switch {
+	case n.external:
+		return "[" + label + "]"
+	case n.kind == project.KindSource:
+		return "(" + label + ")"
+	case n.kind == project.KindTransformation:
+		return "{{" + label + "}}"
+	}
+	return "[" + label + "]"
+}
+
+// nodeLabel is the text shown for n: its kind and name, or the literal ID of
+// an external endpoint.
+func nodeLabel(n graphNode) string {
+	if n.external {
+		return n.kind + " id: " + n.name
+	}
+	return n.kind + ": " + n.name
+}
+
+// dotQuote quotes s as a DOT string.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// mermaidQuote quotes s as Mermaid label text, escaping double quotes.
+func mermaidQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "#quot;") + `"`
+}
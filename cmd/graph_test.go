@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// graphInput has a named and an unnamed connection, a transformation, and
+// an endpoint referenced by an ID that equals a declared source's name.
+func graphInput() *deploy.DeployInput {
+	return &deploy.DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "shop"}, {Name: "src_1"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "enrich"}},
+		Destinations:    []*manifest.DestinationConfig{{Name: "api"}},
+		Connections: []*manifest.ConnectionConfig{
+			{Name: "orders", Source: "shop", Destination: "api", Transformations: []string{"enrich"}},
+			{SourceID: "src_1", Destination: "api"},
+		},
+	}
+}
+
+func TestBuildResourceGraph(t *testing.T) {
+	g := buildResourceGraph(graphInput())
+
+	wantNodes := []graphNode{
+		{kind: "source", name: "shop"},
+		{kind: "source", name: "src_1"},
+		{kind: "transformation", name: "enrich"},
+		{kind: "destination", name: "api"},
+		{kind: "source", name: "src_1", external: true},
+	}
+	if len(g.nodes) != len(wantNodes) {
+		t.Fatalf("expected %d nodes, got %+v", len(wantNodes), g.nodes)
+	}
+	for i, n := range wantNodes {
+		if g.nodes[i] != n {
+			t.Errorf("node %d: expected %+v, got %+v", i, n, g.nodes[i])
+		}
+	}
+
+	wantEdges := []graphEdge{
+		{from: 0, to: 3, label: "orders"},
+		{from: 2, to: 3, label: "orders", dashed: true},
+		{from: 4, to: 3, label: "src_1-to-api"},
+	}
+	if len(g.edges) != len(wantEdges) {
+		t.Fatalf("expected %d edges, got %+v", len(wantEdges), g.edges)
+	}
+	for i, e := range wantEdges {
+		if g.edges[i] != e {
+			t.Errorf("edge %d: expected %+v, got %+v", i, e, g.edges[i])
+		}
+	}
+}
+
+func TestRenderDOT(t *testing.T) {
+	var buf bytes.Buffer
+	renderDOT(&buf, buildResourceGraph(graphInput()))
+
+	want := `digraph hookdeck {
+  rankdir=LR;
+  node [fontname="Helvetica"];
+  edge [fontname="Helvetica", fontsize=10];
+  n0 [label="source: shop", shape=box, style="rounded,filled", fillcolor="#dbeafe"];
+  n1 [label="source: src_1", shape=box, style="rounded,filled", fillcolor="#dbeafe"];
+  n2 [label="transformation: enrich", shape=hexagon, style=filled, fillcolor="#fef3c7"];
+  n3 [label="destination: api", shape=box, style=filled, fillcolor="#dcfce7"];
+  n4 [label="source id: src_1", shape=box, style=dashed];
+  n0 -> n3 [label="orders"];
+  n2 -> n3 [label="orders", style=dashed];
+  n4 -> n3 [label="src_1-to-api"];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderMermaid(t *testing.T) {
+	var buf bytes.Buffer
+	renderMermaid(&buf, buildResourceGraph(graphInput()))
+
+	want := `flowchart LR
+  n0("source: shop"):::source
+  n1("source: src_1"):::source
+  n2{{"transformation: enrich"}}:::transformation
+  n3["destination: api"]:::destination
+  n4["source id: src_1"]:::external
+  n0 -->|"orders"| n3
+  n2 -.->|"orders"| n3
+  n4 -->|"src_1-to-api"| n3
+  classDef source fill:#dbeafe,stroke:#1d4ed8
+  classDef transformation fill:#fef3c7,stroke:#b45309
+  classDef destination fill:#dcfce7,stroke:#15803d
+  classDef external stroke-dasharray:4 2
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	if got := dotQuote(`a "b" \c`); got != `"a \"b\" \\c"` {
+		t.Errorf("dotQuote: got %s", got)
+	}
+	if got := mermaidQuote(`a "b"`); got != `"a #quot;b#quot;"` {
+		t.Errorf("mermaidQuote: got %s", got)
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+const (
+	deploySchemaRef  = "node_modules/@toppy/hookdeck-deploy-cli/schemas/hookdeck-deploy.schema.json"
+	projectSchemaRef = "node_modules/@toppy/hookdeck-deploy-cli/schemas/hookdeck-project.schema.json"
+)
+
+var (
+	flagInitSourceName      string
+	flagInitDestinationName string
+	flagInitDestinationURL  string
+	flagInitConnectionName  string
+	flagInitTransformation  string
+	flagInitProject         bool
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Scaffold a new hookdeck.jsonc manifest",
+	Long: `Init writes a minimal hookdeck.jsonc manifest with one source, one
+destination, and a connection between them. Values not given as flags are
+prompted for when running interactively.
+
+With --project, a hookdeck.project.jsonc is written alongside the manifest.
+With --transformation, a transformation stub and its handler file are added
+and attached to the connection. Existing files are never overwritten.`,
+	Args: cobra.NoArgs,
+	RunE: runInit,
+}
+
+func init() {
+	initCmd.Flags().StringVar(&flagInitSourceName, "source-name", "", "name of the source")
+	initCmd.Flags().StringVar(&flagInitDestinationName, "destination-name", "", "name of the destination (default: destination URL host)")
+	initCmd.Flags().StringVar(&flagInitDestinationURL, "destination-url", "", "URL events are delivered to")
+	initCmd.Flags().StringVar(&flagInitConnectionName, "connection-name", "", "name of the connection (default: <source>-to-<destination>)")
+	initCmd.Flags().StringVar(&flagInitTransformation, "transformation", "", "also scaffold a transformation with this name")
+	initCmd.Flags().BoolVar(&flagInitProject, "project", false, "also write a hookdeck.project.jsonc")
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	manifestPath := filepath.Join(cwd, "hookdeck.jsonc")
+	projectPath := filepath.Join(cwd, "hookdeck.project.jsonc")
+	var handlerPath, handlerRel string
+	if flagInitTransformation != "" {
+		handlerRel = filepath.ToSlash(filepath.Join("transformations", flagInitTransformation, "handler.js"))
+		handlerPath = filepath.Join(cwd, filepath.FromSlash(handlerRel))
+	}
+
+	// Refuse to overwrite anything before prompting or writing.
+	targets := []string{manifestPath}
+	if flagInitProject {
+		targets = append(targets, projectPath)
+	}
+	if handlerPath != "" {
+		targets = append(targets, handlerPath)
+	}
+	for _, path := range targets {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s already exists; refusing to overwrite", path)
+		}
+	}
+
+	p := newPrompter(os.Stdin, os.Stderr)
+
+	sourceName, err := p.value(flagInitSourceName, "Source name", "")
+	if err != nil {
+		return err
+	}
+	destinationURL, err := p.value(flagInitDestinationURL, "Destination URL", "")
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(destinationURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("invalid destination URL %q: expected e.g. https://example.com/webhooks", destinationURL)
+	}
+	destinationName, err := p.value(flagInitDestinationName, "Destination name", u.Hostname())
+	if err != nil {
+		return err
+	}
+	connectionName, err := p.value(flagInitConnectionName, "Connection name", sourceName+"-to-"+destinationName)
+	if err != nil {
+		return err
+	}
+
+	m := &manifest.Manifest{
+		Schema:       deploySchemaRef,
+		Sources:      []manifest.SourceConfig{{Name: sourceName}},
+		Destinations: []manifest.DestinationConfig{{Name: destinationName, URL: destinationURL}},
+		Connections: []manifest.ConnectionConfig{{
+			Name:        connectionName,
+			Source:      sourceName,
+			Destination: destinationName,
+		}},
+	}
+	if flagInitTransformation != "" {
+		m.Transformations = []manifest.TransformationConfig{{
+			Name:     flagInitTransformation,
+			CodeFile: handlerRel,
+		}}
+		m.Connections[0].Transformations = []string{flagInitTransformation}
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+	if err := writeNewFile(manifestPath, append(data, '\n')); err != nil {
+		return err
+	}
+	logger.Infof("Created %s", manifestPath)
+
+	if handlerPath != "" {
+		if err := os.MkdirAll(filepath.Dir(handlerPath), 0o755); err != nil {
+			return fmt.Errorf("creating transformation directory: %w", err)
+		}
+		if err := writeNewFile(handlerPath, []byte(handlerStub)); err != nil {
+			return err
+		}
+		logger.Infof("Created %s", handlerPath)
+	}
+
+	if flagInitProject {
+		if err := writeNewFile(projectPath, []byte(fmt.Sprintf(projectStub, projectSchemaRef))); err != nil {
+			return err
+		}
+		logger.Infof("Created %s", projectPath)
+	}
+
+	return nil
+}
+
+const handlerStub = `addHandler("transform", (request, context) => {
+  // Modify the request before delivery
+  return request;
+});
+`
+
+const projectStub = `{
+  "$schema": %q,
+  "version": "2",
+  "env": {
+    "staging": {},
+    "production": {}
+  }
+}
+`
+
+// writeNewFile writes data to path, failing if the file already exists.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists; refusing to overwrite", path)
+		}
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// prompter asks for missing values on an interactive terminal.
+type prompter struct {
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+}
+
+func newPrompter(in *os.File, out io.Writer) *prompter {
+	return &prompter{
+		in:          bufio.NewReader(in),
+		out:         out,
+		interactive: isTerminal(in),
+	}
+}
+
+// value returns flagValue if set; otherwise it prompts for a value, falling
+// back to def when the answer is empty. Without a terminal, def is used and
+// an empty def is an error.
+func (p *prompter) value(flagValue, label, def string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if !p.interactive {
+		if def == "" {
+			return "", fmt.Errorf("%s is required (pass it as a flag when not running interactively)", strings.ToLower(label))
+		}
+		return def, nil
+	}
+
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", label)
+		}
+		line, err := p.in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("reading input: %w", err)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		if err == io.EOF {
+			return "", fmt.Errorf("%s is required", strings.ToLower(label))
+		}
+	}
+}
+
+// confirm asks a yes/no question and reports whether the answer was yes.
+// Anything but "y" or "yes" is a no.
+func (p *prompter) confirm(question string) (bool, error) {
+	fmt.Fprintf(p.out, "%s [y/N]: ", question)
+	line, err := p.in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, fmt.Errorf("reading input: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// isTerminal reports whether f is attached to a character device (a TTY).
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
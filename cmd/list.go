@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+var flagListOutput string
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the resources a manifest or project resolves to",
+	Long: `List loads the project or manifest (same resolution as deploy), applies the
+--env overlay and variable interpolation, and prints every resource with its
+resolved key fields. It never contacts the Hookdeck API, so no credentials
+are needed.`,
+	Args: cobra.NoArgs,
+	RunE: runList,
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&flagListOutput, "output", "o", "text", "output format: text or json")
+	rootCmd.AddCommand(listCmd)
+}
+
+// listItem is the resolved summary of one resource.
+type listItem struct {
+	Kind        string `json:"kind"`
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	URL         string `json:"url,omitempty"`
+	AuthType    string `json:"auth_type,omitempty"`
+	CodeFile    string `json:"code_file,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Destination string `json:"destination,omitempty"`
+	Rules       *int   `json:"rules,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	if flagListOutput != "text" && flagListOutput != "json" {
+		return fmt.Errorf("invalid --output %q: expected text or json", flagListOutput)
+	}
+
+	resolved, err := loadResolvedInput()
+	if err != nil {
+		return err
+	}
+	items := listItems(resolved.Input)
+	for i := range items {
+		item := &items[i]
+		item.Type, item.URL, item.AuthType, item.CodeFile = redact(item.Type), redact(item.URL), redact(item.AuthType), redact(item.CodeFile)
+	}
+
+	if flagListOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(items); err != nil {
+			return fmt.Errorf("encoding resource list: %w", err)
+		}
+		return nil
+	}
+
+	if len(items) == 0 {
+		fmt.Fprintln(os.Stdout, "No resources defined.")
+		return nil
+	}
+	for _, item := range items {
+		fmt.Fprintf(os.Stdout, "  %-16s %-30s %s\n", kindLabel(item.Kind), item.Name, item.details())
+	}
+	return nil
+}
+
+// resolvedInput is a loaded project or manifest with --env overlays and
+// variable interpolation applied.
+type resolvedInput struct {
+	Input *deploy.DeployInput
+	// Files records the manifest each resource was declared in.
+	Files resourceFiles
+	// Profile is the credential profile from --profile or the project's env
+	// mapping; APIBaseURL is the configured base URL override.
+	Profile    string
+	APIBaseURL string
+	// Config is the project config, or nil for a single manifest.
+	Config *project.ProjectConfig
+	// Dir resolves relative transformation code_file paths.
+	Dir string
+}
+
+// loadResolvedInput loads the project or single manifest the way deploy
+// does, applying --env overlays and variable interpolation.
+func loadResolvedInput() (*resolvedInput, error) {
+	out := &resolvedInput{Profile: flagProfile}
+	var input *deploy.DeployInput
+	var dir string
+	var fileOf func(kind, name string) string
+	var vars map[string]string
+
+	if flagProject != "" || (flagFile == "" && projectFileExists()) {
+		projectPath, err := resolveProjectPath()
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof("Loading project: %s", projectPath)
+		proj, err := loadProject(projectPath)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkProjectEnv(proj.Config, flagEnv); err != nil {
+			return nil, err
+		}
+		input = buildDeployInputFromRegistry(proj.Registry, flagEnv)
+		dir = proj.RootDir
+		fileOf = proj.Registry.FileFor
+		vars = proj.Registry.Vars
+		if out.Profile == "" {
+			out.Profile = profileForEnv(proj.Config, flagEnv)
+		}
+		out.APIBaseURL = proj.Config.APIBaseURL
+		out.Config = proj.Config
+	} else {
+		manifestPath, err := resolveManifestPath()
+		if err != nil {
+			return nil, err
+		}
+		logger.Infof("Loading manifest: %s", manifestPath)
+		m, err := loadManifest(manifestPath)
+		if err != nil {
+			return nil, err
+		}
+		input = buildDeployInputFromManifest(m, flagEnv)
+		dir = filepath.Dir(manifestPath)
+		fileOf = singleFile(manifestPath)
+		vars = m.Vars
+		out.APIBaseURL = m.APIBaseURL
+	}
+
+	resolvedManifest := deployInputToManifest(input)
+	resolvedManifest.Vars = vars
+	if err := interpolateManifest(resolvedManifest, dir); err != nil {
+		return nil, fmt.Errorf("interpolating env vars: %w", err)
+	}
+	out.Input = manifestToDeployInput(resolvedManifest)
+	out.Input.Disabled = input.Disabled
+	out.Input.External = input.External
+	out.Files = newResourceFiles(input, out.Input, dir, fileOf)
+	out.Dir = dir
+	return out, nil
+}
+
+// listItems summarizes input in deploy tier order.
+func listItems(input *deploy.DeployInput) []listItem {
+	items := []listItem{}
+	for _, src := range input.Sources {
+		items = append(items, listItem{Kind: "source", Name: src.Name, Type: src.Type})
+	}
+	for _, tr := range input.Transformations {
+		codeFile := tr.CodeFile
+		if codeFile == "" {
+			codeFile = strings.Join(tr.CodeFiles, ", ")
+		}
+		items = append(items, listItem{Kind: "transformation", Name: tr.Name, CodeFile: codeFile})
+	}
+	for _, dst := range input.Destinations {
+		items = append(items, listItem{Kind: "destination", Name: dst.Name, Type: dst.Type, URL: dst.URL, AuthType: dst.AuthType})
+	}
+	for _, conn := range input.Connections {
+		// Count rules as deploy builds them: explicit rules plus the
+		// transformations, filter, and delay shorthands.
+		rules := len(conn.Rules) + len(conn.Transformations)
+		if conn.Filter != nil {
+			rules++
+		}
+		if conn.Delay > 0 {
+			rules++
+		}
+		source := conn.Source
+		if source == "" {
+			source = conn.SourceID
+		}
+		destination := conn.Destination
+		if destination == "" {
+			destination = conn.DestinationID
+		}
+		items = append(items, listItem{
+			Kind:        "connection",
+			Name:        conn.Name,
+			Source:      source,
+			Destination: destination,
+			Rules:       &rules,
+			Disabled:    conn.Disabled,
+		})
+	}
+	return items
+}
+
+// details renders the non-empty key fields of item for text output.
+func (item listItem) details() string {
+	var parts []string
+	add := func(key, value string) {
+		if value != "" {
+			parts = append(parts, key+": "+value)
+		}
+	}
+	add("type", item.Type)
+	add("url", item.URL)
+	add("auth_type", item.AuthType)
+	add("code_file", item.CodeFile)
+	if item.Kind == "connection" {
+		add("route", item.Source+" -> "+item.Destination)
+		add("rules", fmt.Sprint(*item.Rules))
+	}
+	if item.Disabled {
+		parts = append(parts, "disabled")
+	}
+	return strings.Join(parts, "  ")
+}
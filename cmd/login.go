@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"golang.org/x/term"
+)
+
+var (
+	flagLoginAPIKey    string
+	flagLoginProjectID string
+)
+
+var loginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Save an API key to a credential profile",
+	Long: `Login prompts for a Hookdeck API key and optional project ID, verifies the
+key against the API, and saves it to ~/.config/hookdeck/config.toml (or the
+file named by --config or HOOKDECK_CONFIG) under the profile given by
+--profile (default: "default").`,
+	Args: cobra.NoArgs,
+	RunE: runLogin,
+}
+
+var logoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove a credential profile",
+	Long: `Logout removes the profile given by --profile (default: "default") from
+~/.config/hookdeck/config.toml, or from the file named by --config or
+HOOKDECK_CONFIG.`,
+	Args: cobra.NoArgs,
+	RunE: runLogout,
+}
+
+func init() {
+	loginCmd.Flags().StringVar(&flagLoginAPIKey, "api-key", "", "API key to save (prompted for if omitted)")
+	loginCmd.Flags().StringVar(&flagLoginProjectID, "project-id", "", "project ID to save with the profile")
+	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(logoutCmd)
+}
+
+// loginProfileName returns the profile login/logout operate on.
+func loginProfileName() string {
+	if flagProfile != "" {
+		return flagProfile
+	}
+	return "default"
+}
+
+func runLogin(cmd *cobra.Command, args []string) error {
+	profileName := loginProfileName()
+
+	apiKey := flagLoginAPIKey
+	projectID := flagLoginProjectID
+	if apiKey == "" {
+		if !isTerminal(os.Stdin) {
+			return fmt.Errorf("--api-key is required when not running interactively")
+		}
+		fmt.Fprint(os.Stderr, "API key: ")
+		key, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return fmt.Errorf("reading API key: %w", err)
+		}
+		apiKey = strings.TrimSpace(string(key))
+		if apiKey == "" {
+			return fmt.Errorf("API key is required")
+		}
+
+		if projectID == "" {
+			p := newPrompter(os.Stdin, os.Stderr)
+			projectID, err = p.value("", "Project ID (optional)", "-")
+			if err != nil {
+				return err
+			}
+			if projectID == "-" {
+				projectID = ""
+			}
+		}
+	}
+
+	logger.Infof("Verifying API key...")
+	client := newAPIClient(&credentials.Credentials{APIKey: apiKey, ProjectID: projectID}, "", nil)
+	if err := client.Verify(cmd.Context()); err != nil {
+		return fmt.Errorf("verifying API key: %w", err)
+	}
+
+	path, err := credentials.WriteConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := credentials.SaveProfile(path, profileName, &credentials.Credentials{APIKey: apiKey, ProjectID: projectID}); err != nil {
+		return fmt.Errorf("saving profile: %w", err)
+	}
+
+	logger.Infof("Saved profile '%s' to %s", profileName, path)
+	return nil
+}
+
+func runLogout(cmd *cobra.Command, args []string) error {
+	profileName := loginProfileName()
+
+	path, err := credentials.WriteConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := credentials.RemoveProfile(path, profileName); err != nil {
+		return err
+	}
+
+	logger.Infof("Removed profile '%s' from %s", profileName, path)
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// splitEnvs splits a comma-separated --env value into its environments,
+// dropping blanks and duplicates.
+func splitEnvs(value string) []string {
+	var envs []string
+	seen := map[string]bool{}
+	for _, env := range strings.Split(value, ",") {
+		env = strings.TrimSpace(env)
+		if env != "" && !seen[env] {
+			seen[env] = true
+			envs = append(envs, env)
+		}
+	}
+	return envs
+}
+
+// normalizeEnv returns value with a single environment trimmed of blanks
+// and separators, as splitEnvs reads it: " staging" and "staging," become
+// "staging", and "," becomes "". A value naming several environments is
+// returned unchanged.
+func normalizeEnv(value string) string {
+	envs := splitEnvs(value)
+	if len(envs) > 1 {
+		return value
+	}
+	return strings.Join(envs, "")
+}
+
+// runMultiEnvDeploy deploys each environment in turn, as if deploy had been
+// run once per --env value. In project mode each environment uses its own
+// mapped profile, so each gets its own API client. A failing environment is
+// reported and the rest still run unless --fail-fast is set.
+func runMultiEnvDeploy(ctx context.Context, envs []string) error {
+	if flagProfile != "" {
+		return fmt.Errorf("--profile cannot be combined with multiple --env values; each environment uses its mapped profile")
+	}
+	defer func(env string) { flagEnv = env }(flagEnv)
+
+	status := make(map[string]string, len(envs))
+	var failed []string
+	for i, env := range envs {
+		if i > 0 {
+			fmt.Fprintln(os.Stderr)
+		}
+		logger.Infof("=== Environment: %s ===", env)
+		flagEnv = env
+		if err := deployEnv(ctx); err != nil {
+			logger.Errorf("%s: %v", env, err)
+			status[env] = "failed"
+			failed = append(failed, env)
+			if flagFailFast || ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		status[env] = "ok"
+	}
+
+	fmt.Fprintf(os.Stderr, "\nEnvironments:\n")
+	for _, env := range envs {
+		result, ok := status[env]
+		if !ok {
+			result = "not deployed"
+		}
+		fmt.Fprintf(os.Stderr, "  %-30s %s\n", env, result)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("deploy failed for %d of %d environment(s): %s", len(failed), len(envs), strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitEnvs(t *testing.T) {
+	got := splitEnvs(" staging, production,,staging ")
+	if want := []string{"staging", "production"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitEnvs = %v, want %v", got, want)
+	}
+}
+
+func TestNormalizeEnv(t *testing.T) {
+	tests := map[string]string{
+		"":                   "",
+		"staging":            "staging",
+		" staging":           "staging",
+		"staging,":           "staging",
+		"staging, staging":   "staging",
+		",":                  "",
+		"staging,production": "staging,production",
+	}
+	for value, want := range tests {
+		if got := normalizeEnv(value); got != want {
+			t.Errorf("normalizeEnv(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
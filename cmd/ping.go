@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check that the resolved credentials can reach the Hookdeck API",
+	Long: `Ping resolves credentials the same way deploy does (including the profile
+mapped to --env in the project config) and makes one cheap, read-only
+authenticated request. It prints where the credentials came from, the project
+ID, and the round-trip time, and exits non-zero when the API key is rejected
+or the API can't be reached, so CI can run it before a deploy.`,
+	Args: cobra.NoArgs,
+	RunE: runPing,
+}
+
+func init() {
+	rootCmd.AddCommand(pingCmd)
+}
+
+func runPing(cmd *cobra.Command, args []string) error {
+	// The project config, when there is one, supplies the env's profile, the
+	// API base URL, and client settings. No manifest is needed.
+	var cfg *project.ProjectConfig
+	if flagProject != "" || (flagFile == "" && projectFileExists()) {
+		projectPath, err := resolveProjectPath()
+		if err != nil {
+			return err
+		}
+		if cfg, err = project.LoadProjectConfig(projectPath); err != nil {
+			return fmt.Errorf("loading project config: %w", err)
+		}
+		if err := checkProjectEnv(cfg, flagEnv); err != nil {
+			return err
+		}
+	}
+
+	profileName := flagProfile
+	if profileName == "" {
+		profileName = profileForEnv(cfg, flagEnv)
+	}
+	creds, source, err := credentials.ResolveWithSource(profileName)
+	if err != nil {
+		return fmt.Errorf("resolving credentials: %w", err)
+	}
+
+	var configuredBaseURL string
+	if cfg != nil {
+		configuredBaseURL = cfg.APIBaseURL
+	}
+	client := newAPIClient(creds, configuredBaseURL, projectClientConfig(cfg))
+
+	start := time.Now()
+	if err := client.Verify(cmd.Context()); err != nil {
+		var apiErr *hookdeck.APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			return fmt.Errorf("authentication failed for the API key from %s: %w", source, err)
+		}
+		return fmt.Errorf("cannot reach the Hookdeck API at %s: %w", client.BaseURL(), err)
+	}
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	projectID := creds.ProjectID
+	if projectID == "" {
+		projectID = "(none, API key is project-scoped)"
+	}
+
+	fmt.Printf("OK:         %s responded in %s\n", client.BaseURL(), elapsed)
+	fmt.Printf("Source:     %s\n", source)
+	fmt.Printf("Project ID: %s\n", projectID)
+	return nil
+}
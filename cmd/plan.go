@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/drift"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+var flagPlanOut string
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Compute the changes a deploy would make and optionally save them",
+	Long: `Plan loads the project or manifest (same resolution as deploy), compares every
+resource against remote state, and prints what a deploy would change, like
+"deploy --dry-run". With -o, the resolved input, transformation code, and
+expected changes are saved to a plan file that "hookdeck-deploy apply" can
+execute later without re-reading manifests or variables.
+
+The plan file contains interpolated values, including secrets, and is
+written with owner-only permissions.`,
+	Args: cobra.NoArgs,
+	RunE: runPlan,
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&flagPlanOut, "out", "o", "", "write the plan to this file")
+	planCmd.Flags().IntVar(&flagMaxPayloadSize, "max-payload-size", deploy.DefaultMaxCodeSize, "fail if a transformation's code is larger than this many bytes, here and in apply (0 disables the check)")
+	planCmd.Flags().BoolVar(&flagAllowTypeChange, "allow-type-change", false, "let apply change a source's type, which can recreate the source with a new ingest URL")
+	rootCmd.AddCommand(planCmd)
+}
+
+func runPlan(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	if flagMaxPayloadSize < 0 {
+		return fmt.Errorf("--max-payload-size must not be negative")
+	}
+	resolved, err := loadResolvedInput()
+	if err != nil {
+		return err
+	}
+	input := resolved.Input
+
+	for _, err := range deploy.CheckReferences(input) {
+		logger.Warnf("%s", err)
+	}
+	if err := validateInput(input); err != nil {
+		return err
+	}
+
+	creds, err := credentials.Resolve(resolved.Profile)
+	if err != nil {
+		return fmt.Errorf("resolving credentials: %w", err)
+	}
+	client := hookdeck.NewCachingClient(newAPIClient(creds, resolved.APIBaseURL, projectClientConfig(resolved.Config)))
+
+	result, diffs, err := previewDeploy(ctx, client, input, resolved.Dir, resolved.Files)
+	if err != nil {
+		return fmt.Errorf("plan failed: %w", withFlagHint(err))
+	}
+	fmt.Fprintf(os.Stderr, "\nSummary: %s\n", result.Summary())
+
+	if flagPlanOut == "" {
+		return nil
+	}
+
+	plan, err := buildPlan(ctx, client, resolved, result, diffs)
+	if err != nil {
+		return err
+	}
+	if err := deploy.WritePlan(flagPlanOut, plan); err != nil {
+		return err
+	}
+	logger.Infof("Plan saved to %s. Run \"hookdeck-deploy apply %s\" to execute it.", flagPlanOut, flagPlanOut)
+	return nil
+}
+
+// buildPlan records the previewed result as a plan, fingerprinting each
+// resource's remote state so apply can detect changes made since.
+func buildPlan(ctx context.Context, client *hookdeck.CachingClient, resolved *resolvedInput, result *deploy.Result, diffs []drift.Diff) (*deploy.Plan, error) {
+	input := resolved.Input
+	if resolved.Config != nil {
+		// Record the project deploy order, including depends_on, for apply.
+		sorted, err := project.SortDeployInput(input)
+		if err != nil {
+			return nil, fmt.Errorf("ordering resources: %w", err)
+		}
+		input = sorted
+	}
+
+	plan := &deploy.Plan{
+		Version:    deploy.PlanVersion,
+		CreatedAt:  time.Now().UTC(),
+		Env:        flagEnv,
+		Profile:    resolved.Profile,
+		APIBaseURL: resolved.APIBaseURL,
+		Protected:  resolved.Config != nil && resolved.Config.IsProtected(flagEnv),
+		Input:      input,
+
+		AllowTypeChange: flagAllowTypeChange,
+		MaxCodeSize:     flagMaxPayloadSize,
+	}
+
+	if cfg := projectClientConfig(resolved.Config); cfg != nil {
+		raw, err := json.Marshal(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("encoding client settings: %w", err)
+		}
+		plan.Client = raw
+	}
+
+	for _, tr := range input.Transformations {
+		code, err := deploy.ResolveCode(tr, resolved.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("resolving transformation code for %q: %w", tr.Name, err)
+		}
+		if plan.Code == nil {
+			plan.Code = map[string]string{}
+		}
+		plan.Code[tr.Name] = code
+	}
+
+	fields := map[string][]deploy.PlannedField{}
+	for _, d := range diffs {
+		for _, f := range d.Fields {
+			fields[d.Kind+"/"+d.Name] = append(fields[d.Kind+"/"+d.Name], deploy.PlannedField{Field: f.Field, Local: f.Local, Remote: f.Remote})
+		}
+	}
+
+	checker := drift.NewChecker(client)
+	add := func(kind string, results []*deploy.ResourceResult) error {
+		for _, r := range results {
+			change := deploy.PlannedChange{Kind: kind, Name: r.Name, Action: r.Action, Fields: fields[kind+"/"+r.Name]}
+			if r.Action != deploy.ActionSkippedDisabled {
+				id, fingerprint, err := fingerprintResource(ctx, checker, input, kind, r.Name)
+				if err != nil {
+					return fmt.Errorf("fingerprinting %s %q: %w", kind, r.Name, err)
+				}
+				change.RemoteID, change.Fingerprint = id, fingerprint
+			}
+			plan.Changes = append(plan.Changes, change)
+		}
+		return nil
+	}
+	if err := add(project.KindSource, result.Sources); err != nil {
+		return nil, err
+	}
+	if err := add(project.KindTransformation, result.Transformations); err != nil {
+		return nil, err
+	}
+	if err := add(project.KindDestination, result.Destinations); err != nil {
+		return nil, err
+	}
+	if err := add(project.KindConnection, result.Connections); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// fingerprintResource fingerprints the named resource. A connection is found
+// through its config in input, by full name like every other lookup, rather
+// than by its name alone.
+func fingerprintResource(ctx context.Context, checker *drift.Checker, input *deploy.DeployInput, kind, name string) (string, string, error) {
+	if kind == project.KindConnection && input != nil {
+		for _, conn := range input.Connections {
+			if conn.Name == name {
+				return checker.ConnectionFingerprint(ctx, conn)
+			}
+		}
+	}
+	return checker.Fingerprint(ctx, kind, name)
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/drift"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+// Dry-run preview actions, reported in place of "would upsert" when remote
+// state could be fetched.
+const (
+	actionNew         = "new"
+	actionWouldChange = "would change"
+	actionNoChanges   = "no changes"
+	actionWouldUpsert = "would upsert"
+)
+
+// previewDeploy is the online dry-run: it fetches the live state of every
+// resource in input and prints whether each one is new, unchanged, or which
+// fields would change. Resources the checker can't compare in full (auth
+// secrets, connection rules) are reported as "would upsert" rather than
+// "no changes". Disabled resources are reported as skipped without a lookup.
+// codeRoot resolves relative transformation code_file paths. Code larger
+// than --max-payload-size fails the preview as it would the deploy. The
+// detected diffs are returned with secrets redacted. Under --output table the
+// results are printed as a table once the preview returns.
+func previewDeploy(ctx context.Context, client *hookdeck.CachingClient, input *deploy.DeployInput, codeRoot string, files resourceFiles) (*deploy.Result, []drift.Diff, error) {
+	if err := deploy.CheckCodeSize(input, deploy.Options{CodeRoot: codeRoot, MaxCodeSize: flagMaxPayloadSize}); err != nil {
+		return nil, nil, err
+	}
+	result := &deploy.Result{StartedAt: time.Now()}
+
+	remote, err := fetchRemoteState(ctx, client, input.Sources, input.Destinations, input.Transformations, input.Connections)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetching remote state: %w", err)
+	}
+	diffs := map[string]drift.Diff{}
+	detected := redactDiffs(drift.Detect(input.Sources, input.Destinations, input.Transformations, input.Connections, remote, codeRoot))
+	for _, d := range detected {
+		diffs[d.Kind+"/"+d.Name] = d
+	}
+	checker := drift.NewChecker(client)
+	var reporter deploy.Reporter = newStreamReporter(files)
+	// Under --output table the field changes can't go under each row, so
+	// they are listed after the table.
+	asTable := flagDeployOutput == "table"
+	var changed []drift.Diff
+	if asTable {
+		if !flagVerbose {
+			files = nil
+		}
+		t := newTableReporter(files)
+		reporter = t
+		defer func() {
+			t.finish()
+			for _, d := range changed {
+				fmt.Fprintf(os.Stderr, "\n  %s %s:\n", kindLabel(d.Kind), d.Name)
+				printFieldDiffs(d.Fields)
+			}
+		}()
+	}
+
+	// preview reports one resource. unchanged is only consulted when drift
+	// found no field differences; lookups hit the client's cache.
+	preview := func(kind, name string, unchanged func() (string, bool, error)) (*deploy.ResourceResult, error) {
+		r := &deploy.ResourceResult{Name: name}
+		d, drifted := diffs[kind+"/"+name]
+		switch {
+		case drifted && d.Status == drift.Missing:
+			r.Action = actionNew
+		case drifted:
+			r.Action = actionWouldChange
+		default:
+			id, ok, err := unchanged()
+			if err != nil {
+				return nil, fmt.Errorf("comparing %s %q: %w", kind, name, err)
+			}
+			r.ID = id
+			r.Action = actionWouldUpsert
+			if ok {
+				r.Action = actionNoChanges
+			}
+		}
+		reporter.OnResourceDone(kind, r)
+		switch {
+		case !asTable:
+			printFieldDiffs(d.Fields)
+		case len(d.Fields) > 0:
+			changed = append(changed, d)
+		}
+		return r, nil
+	}
+
+	for _, r := range input.DisabledResults(project.KindSource) {
+		reporter.OnResourceDone(project.KindSource, r)
+		result.Sources = append(result.Sources, r)
+	}
+	for _, src := range input.Sources {
+		r, err := preview(project.KindSource, src.Name, func() (string, bool, error) {
+			return checker.SourceUnchanged(ctx, src)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Sources = append(result.Sources, r)
+	}
+	for _, r := range input.DisabledResults(project.KindTransformation) {
+		reporter.OnResourceDone(project.KindTransformation, r)
+		result.Transformations = append(result.Transformations, r)
+	}
+	for _, tr := range input.Transformations {
+		r, err := preview(project.KindTransformation, tr.Name, func() (string, bool, error) {
+			return checker.TransformationUnchanged(ctx, tr, codeRoot)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Transformations = append(result.Transformations, r)
+	}
+	for _, r := range input.DisabledResults(project.KindDestination) {
+		reporter.OnResourceDone(project.KindDestination, r)
+		result.Destinations = append(result.Destinations, r)
+	}
+	for _, dst := range input.Destinations {
+		r, err := preview(project.KindDestination, dst.Name, func() (string, bool, error) {
+			return checker.DestinationUnchanged(ctx, dst)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Destinations = append(result.Destinations, r)
+	}
+	for _, r := range input.DisabledResults(project.KindConnection) {
+		reporter.OnResourceDone(project.KindConnection, r)
+		result.Connections = append(result.Connections, r)
+	}
+	for _, conn := range input.Connections {
+		r, err := preview(project.KindConnection, conn.Name, func() (string, bool, error) {
+			return checker.ConnectionUnchanged(ctx, conn)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Connections = append(result.Connections, r)
+	}
+
+	result.FinishedAt = time.Now()
+	return result, detected, nil
+}
+
+// printFieldDiffs prints the local and remote value of each changed field,
+// indented under the resource's result line.
+func printFieldDiffs(fields []drift.FieldDiff) {
+	for _, f := range fields {
+		fmt.Fprintf(os.Stderr, "    %-20s local: %s\n", f.Field, f.Local)
+		fmt.Fprintf(os.Stderr, "    %-20s remote: %s\n", "", f.Remote)
+	}
+}
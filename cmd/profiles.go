@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+)
+
+var profilesCmd = &cobra.Command{
+	Use:   "profiles",
+	Short: "List the credential profiles in the local and global config files",
+	Long: `Profiles lists every profile in the project-local .hookdeck/config.toml and
+the global ~/.config/hookdeck/config.toml, with its masked API key and project
+ID. The default profile of each file is marked with "*". Credentials are
+resolved from the local file when it exists, so that file is marked as in use.
+When --config or HOOKDECK_CONFIG names a config file, only that file is listed.`,
+	Args: cobra.NoArgs,
+	RunE: runProfiles,
+}
+
+func init() {
+	rootCmd.AddCommand(profilesCmd)
+}
+
+func runProfiles(cmd *cobra.Command, args []string) error {
+	paths, err := credentialConfigPaths()
+	if err != nil {
+		return err
+	}
+	active := credentials.ActiveConfigPath()
+
+	found := false
+	for _, path := range paths {
+		profiles, err := credentials.ListProfiles(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(profiles) == 0 {
+			continue
+		}
+		if found {
+			fmt.Println()
+		}
+		found = true
+
+		header := path
+		if path == active {
+			header += " (in use)"
+		}
+		fmt.Println(header)
+		for _, p := range profiles {
+			marker := " "
+			if p.Default {
+				marker = "*"
+			}
+			key := p.MaskedAPIKey
+			if key == "" {
+				key = "(none)"
+			}
+			projectID := p.ProjectID
+			if projectID == "" {
+				projectID = "(none)"
+			}
+			fmt.Printf("  %s %-20s api key: %-24s project: %s\n", marker, p.Name, key, projectID)
+		}
+	}
+	if !found {
+		fmt.Println("No profiles found. Run 'hookdeck-deploy login' to create one.")
+	}
+	return nil
+}
+
+// credentialConfigPaths returns the config files profiles are read from: the
+// one named by --config or HOOKDECK_CONFIG, else the local and global files.
+func credentialConfigPaths() ([]string, error) {
+	if path, _ := credentials.ExplicitConfigPath(); path != "" {
+		return []string{path}, nil
+	}
+	globalPath, err := credentials.GlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	return []string{credentials.LocalConfigPath, globalPath}, nil
+}
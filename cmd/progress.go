@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+// maxProgressName caps the resource name on the progress line so it stays on
+// one terminal row.
+const maxProgressName = 60
+
+// progressReporter is the deploy.Reporter used on a terminal: below the
+// result line of each finished resource, it redraws a single
+// "[X/Y] kind name" status line. While it is active, logger output goes
+// through it so log lines don't land on the status line.
+type progressReporter struct {
+	out   io.Writer
+	total int
+
+	mu      sync.Mutex
+	done    int
+	current string
+	drawn   bool
+	// logOut is the logger's writer before the reporter replaced it.
+	logOut io.Writer
+}
+
+// newDeployReporter returns the reporter for deploying input and a function
+// to call once the deploy returns, before printing anything else. It shows a
+// progress line when stderr is a terminal, and falls back to streamReporter
+// under --no-progress, --verbose (which shows each resource's file),
+// --dump-request (whose output would break up the line), or --dry-run (whose
+// per-resource lines are the point). Under --output table it collects the
+// results and prints them as a table instead.
+func newDeployReporter(input *deploy.DeployInput, files resourceFiles) (deploy.Reporter, func()) {
+	if flagDeployOutput == "table" {
+		if !flagVerbose {
+			files = nil
+		}
+		t := newTableReporter(files)
+		return t, t.finish
+	}
+	if flagNoProgress || flagVerbose || flagDumpRequest || flagDryRun || !isTerminal(os.Stderr) {
+		return newStreamReporter(files), func() {}
+	}
+	p := newProgressReporter(os.Stderr, len(input.Sources)+len(input.Transformations)+len(input.Destinations)+len(input.Connections)+len(input.Disabled))
+	return p, p.finish
+}
+
+// newProgressReporter returns a progressReporter for total resources that
+// draws on out and routes logger output through itself until finish.
+func newProgressReporter(out io.Writer, total int) *progressReporter {
+	p := &progressReporter{out: out, total: total}
+	p.logOut = logger.SetOutput(p)
+	return p
+}
+
+func (p *progressReporter) OnResourceStart(kind, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = kind + " " + name
+	p.draw()
+}
+
+func (p *progressReporter) OnResourceDone(kind string, r *deploy.ResourceResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done++
+	p.current = kind + " " + r.Name
+	p.clear()
+	fmt.Fprintln(p.out, resourceResultLine(kindLabel(kind), r, ""))
+	p.draw()
+}
+
+// Write writes log output on its own row: it clears the status line, writes
+// b, and redraws the line below it.
+func (p *progressReporter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	redraw := p.drawn
+	p.clear()
+	n, err := p.out.Write(b)
+	if redraw {
+		p.draw()
+	}
+	return n, err
+}
+
+// draw rewrites the status line in place. p.mu must be held.
+func (p *progressReporter) draw() {
+	current := p.current
+	if len(current) > maxProgressName {
+		current = current[:maxProgressName-3] + "..."
+	}
+	fmt.Fprintf(p.out, "\r\033[K[%d/%d resources] %s", p.done, p.total, current)
+	p.drawn = true
+}
+
+// clear erases the status line if it is drawn. p.mu must be held.
+func (p *progressReporter) clear() {
+	if p.drawn {
+		fmt.Fprint(p.out, "\r\033[K")
+		p.drawn = false
+	}
+}
+
+// finish clears the status line so the summary or error starts on a clean
+// row, and gives the logger its writer back.
+func (p *progressReporter) finish() {
+	if p.logOut != nil {
+		logger.SetOutput(p.logOut)
+		p.logOut = nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+}
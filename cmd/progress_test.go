@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+func TestProgressReporter_PrintsResultLines(t *testing.T) {
+	var buf bytes.Buffer
+	p := newProgressReporter(&buf, 2)
+	p.OnResourceStart("source", "orders")
+	p.OnResourceDone("source", &deploy.ResourceResult{Name: "orders", ID: "src_1", Action: "upserted"})
+	p.OnResourceStart("destination", "api")
+	p.OnResourceDone("destination", &deploy.ResourceResult{Name: "api", ID: "des_1", Action: "skipped"})
+	p.finish()
+
+	want := "" +
+		"\r\033[K[0/2 resources] source orders" +
+		"\r\033[K" + resourceResultLine("Source", &deploy.ResourceResult{Name: "orders", ID: "src_1", Action: "upserted"}, "") + "\n" +
+		"\r\033[K[1/2 resources] source orders" +
+		"\r\033[K[1/2 resources] destination api" +
+		"\r\033[K" + resourceResultLine("Destination", &deploy.ResourceResult{Name: "api", ID: "des_1", Action: "skipped"}, "") + "\n" +
+		"\r\033[K[2/2 resources] destination api" +
+		"\r\033[K"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestProgressReporter_LogLinesClearStatusLine(t *testing.T) {
+	var logBuf bytes.Buffer
+	defer logger.SetOutput(logger.SetOutput(&logBuf))
+
+	var buf bytes.Buffer
+	p := newProgressReporter(&buf, 1)
+	p.OnResourceStart("source", "orders")
+	logger.Warnf("slow response")
+	p.finish()
+	logger.Infof("after")
+
+	want := "" +
+		"\r\033[K[0/1 resources] source orders" +
+		"\r\033[KWarning: slow response\n" +
+		"\r\033[K[0/1 resources] source orders" +
+		"\r\033[K"
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%q\nwant:\n%q", got, want)
+	}
+	if got := logBuf.String(); got != "after\n" {
+		t.Errorf("logger output after finish = %q, want it restored", got)
+	}
+}
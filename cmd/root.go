@@ -1,10 +1,18 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/logging"
 )
 
 var (
@@ -15,19 +23,97 @@ var (
 	flagDryRun  bool
 	flagProfile string
 	flagProject string
+	flagDir     string
+	flagTimeout time.Duration
+
+	flagAPIBaseURL        string
+	flagAPIKeyFile        string
+	flagConfig            string
+	flagAllowUndefinedEnv bool
+	flagParallelManifests int
+
+	flagEnvFiles        []string
+	flagVars            []string
+	flagShowSecrets     bool
+	flagAllowUnresolved bool
+	flagLogLevel        string
+	flagStrict          bool
+	flagStrictURLs      bool
+
+	flagRetryAttempts  int
+	flagRetryBaseDelay time.Duration
+	flagRateLimit      float64
+	flagRequestTimeout time.Duration
 )
 
+// logger writes progress, warnings, and errors to stderr at the --log-level
+// verbosity. Command results (resource lines, summaries, reports) are written
+// directly and are not affected by the level.
+var logger = logging.New(os.Stderr, logging.LevelInfo)
+
+// cancelTimeout releases the --timeout context once the command finishes.
+var cancelTimeout context.CancelFunc = func() {}
+
 var rootCmd = &cobra.Command{
 	Use:           "hookdeck-deploy",
 	Short:         "Deploy Hookdeck resources from manifest files",
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	Version:       version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		level, err := logging.ParseLevel(flagLogLevel)
+		if err != nil {
+			return fmt.Errorf("invalid --log-level: %w", err)
+		}
+		logger.SetLevel(level)
+
+		// --project would silently win over --file, so make the user pick.
+		if flagProject != "" && flagFile != "" {
+			return fmt.Errorf("--project and --file cannot be combined: use --project for a project-wide %s or --file for a single manifest", cmd.Name())
+		}
+
+		// Only deploy loops over several environments.
+		if cmd != deployCmd && strings.Contains(flagEnv, ",") {
+			return fmt.Errorf("--env takes a single environment for %s; only deploy accepts a comma-separated list", cmd.Name())
+		}
+
+		// Reject malformed --var entries before doing any work.
+		vars, err := parseCLIVars(flagVars)
+		if err != nil {
+			return err
+		}
+		cliVars = vars
+
+		credentials.SetAPIKeyFile(flagAPIKeyFile)
+		credentials.SetConfigPath(flagConfig)
+
+		// A zero timeout means no deadline.
+		if flagTimeout > 0 {
+			ctx, cancel := context.WithTimeout(cmd.Context(), flagTimeout)
+			cancelTimeout = cancel
+			cmd.SetContext(ctx)
+		}
+		return nil
+	},
 }
 
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintln(os.Stderr, "Error:", err)
+	// Ctrl-C cancels the command context so a deploy can stop between
+	// resources and report what it applied. Once it has, a second Ctrl-C
+	// kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	cancelTimeout()
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("operation timed out after %s", flagTimeout)
+		}
+		logger.Errorf("%v", err)
 		os.Exit(1)
 	}
 }
@@ -38,4 +124,28 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&flagDryRun, "dry-run", false, "preview changes without applying")
 	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "override credential profile")
 	rootCmd.PersistentFlags().StringVar(&flagProject, "project", "", "path to hookdeck.project.jsonc for project-wide deploy")
+	rootCmd.PersistentFlags().StringVarP(&flagDir, "dir", "C", "", "directory to discover hookdeck.project.jsonc / hookdeck.jsonc in (default: current directory)")
+	rootCmd.PersistentFlags().BoolVar(&flagAllowUndefinedEnv, "allow-undefined-env", false, "in project mode, allow an --env that isn't declared in the project config")
+	rootCmd.PersistentFlags().IntVar(&flagParallelManifests, "parallel-manifests", 1, "in project mode, parse up to this many manifest files concurrently (0 means one per CPU)")
+	rootCmd.PersistentFlags().StringArrayVar(&flagEnvFiles, "env-file", nil, "read interpolation variables from this file instead of .env/.env.<env> (repeatable)")
+	rootCmd.PersistentFlags().StringVar(&flagAPIKeyFile, "api-key-file", "", "read the API key from this file (default: $HOOKDECK_API_KEY_FILE); HOOKDECK_API_KEY still takes precedence")
+	rootCmd.PersistentFlags().StringVar(&flagConfig, "config", "", "read credential profiles from this config file (default: $HOOKDECK_CONFIG, then .hookdeck/config.toml, then ~/.config/hookdeck/config.toml)")
+	rootCmd.PersistentFlags().StringVar(&flagAPIBaseURL, "api-base-url", "", "override the Hookdeck API base URL (default: $HOOKDECK_API_BASE_URL, then api_base_url from config)")
+	rootCmd.PersistentFlags().BoolVar(&flagAllowUnresolved, "allow-unresolved", false, "leave ${VAR} references with no value in place for a later pipeline stage instead of failing")
+	rootCmd.PersistentFlags().BoolVar(&flagShowSecrets, "show-secrets", false, "print interpolated ${VAR} values instead of masking them as *** in output")
+	rootCmd.PersistentFlags().StringArrayVar(&flagVars, "var", nil, "set an interpolation variable as KEY=VALUE, overriding the environment and .env files (repeatable)")
+	rootCmd.PersistentFlags().DurationVar(&flagTimeout, "timeout", 0, "abort API operations after this duration (e.g. 30s, 2m; 0 means no timeout)")
+	rootCmd.PersistentFlags().IntVar(&flagRetryAttempts, "retry-attempts", 0, "retry API requests that fail with 429, a 5xx status, or a network error up to this many times (default: client.retry_attempts from the project config, else 0)")
+	rootCmd.PersistentFlags().DurationVar(&flagRetryBaseDelay, "retry-base-delay", 0, "backoff before the first retry, doubling after each (default: client.retry_base_delay_ms from the project config, else 500ms)")
+	rootCmd.PersistentFlags().Float64Var(&flagRateLimit, "rate-limit", 0, "send at most this many API requests per second (default: client.rate_limit_rps from the project config, else unlimited)")
+	rootCmd.PersistentFlags().DurationVar(&flagRequestTimeout, "request-timeout", 0, "time out each API request after this duration (default: client.timeout_ms from the project config, else none)")
+	rootCmd.PersistentFlags().BoolVar(&flagStrict, "strict", false, "fail instead of warning when a manifest declares an unsupported or deprecated format version")
+	rootCmd.PersistentFlags().BoolVar(&flagStrictURLs, "strict-urls", false, "fail instead of warning when a destination URL uses plain http to a host other than localhost")
+	rootCmd.PersistentFlags().StringVar(&flagLogLevel, "log-level", "info", "diagnostic output level: debug (adds API request tracing), info, warn, or error")
+
+	persistentFlagChanged = rootCmd.PersistentFlags().Changed
+
+	// Cobra provides the `completion` command; these add dynamic values.
+	rootCmd.RegisterFlagCompletionFunc("env", completeEnv)
+	rootCmd.RegisterFlagCompletionFunc("profile", completeProfile)
 }
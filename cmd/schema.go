@@ -2,8 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
 	"github.com/toppynl/hookdeck-deploy-cli/schemas"
 )
 
@@ -16,8 +19,26 @@ var schemaCmd = &cobra.Command{
 	RunE:  runSchema,
 }
 
+var schemaValidateCmd = &cobra.Command{
+	Use:   "validate <file>...",
+	Short: "Check files against the embedded JSON schema",
+	Long: `Validate checks each file against the embedded JSON Schema only, without
+loading the project, resolving variables, or needing credentials. Each
+violation is reported with its JSON pointer path and line.
+
+Files named hookdeck.project.jsonc or hookdeck.project.json are checked
+against the project schema, and all other files against the deploy manifest
+schema. Use --project to check every file against the project schema.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runSchemaValidate,
+}
+
+var schemaValidateProject bool
+
 func init() {
 	schemaCmd.Flags().BoolVar(&projectFlag, "project", false, "Output the project configuration schema instead of the deploy schema")
+	schemaValidateCmd.Flags().BoolVar(&schemaValidateProject, "project", false, "validate against the project configuration schema")
+	schemaCmd.AddCommand(schemaValidateCmd)
 	rootCmd.AddCommand(schemaCmd)
 }
 
@@ -29,3 +50,29 @@ func runSchema(cmd *cobra.Command, args []string) error {
 	}
 	return nil
 }
+
+func runSchemaValidate(cmd *cobra.Command, args []string) error {
+	invalid := 0
+	for _, path := range args {
+		validate := manifest.ValidateSchemaFile
+		if base := filepath.Base(path); schemaValidateProject || base == "hookdeck.project.jsonc" || base == "hookdeck.project.json" {
+			validate = manifest.ValidateProjectSchemaFile
+		}
+		errs, err := validate(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if len(errs) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: valid\n", path)
+			continue
+		}
+		invalid++
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, e)
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("schema validation failed for %d of %d file(s)", invalid, len(args))
+	}
+	return nil
+}
@@ -1,155 +1,278 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
 )
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
-	Short: "Show the status of Hookdeck resources defined in a manifest",
-	Long: `Status checks whether each resource declared in a manifest file exists on
-Hookdeck. For each resource it prints the name, ID, and URL (for sources).`,
+	Short: "Show the status of Hookdeck resources defined in a manifest or project",
+	Long: `Status checks whether each resource declared in a manifest file or project
+exists on Hookdeck. For each resource it prints the name, ID, URL (for
+sources), and the manifest file that declared it.
+
+With --offline it skips the API and credentials entirely and lists the
+resources the manifest or project declares for --env, marked "declared".`,
 	RunE: runStatus,
 }
 
+var (
+	flagStatusOutput        string
+	flagStatusModifiedSince time.Duration
+	flagStatusOffline       bool
+)
+
 func init() {
+	statusCmd.Flags().StringVarP(&flagStatusOutput, "output", "o", "text", "output format: text or table (aligned columns, colored on a terminal unless NO_COLOR is set)")
+	statusCmd.Flags().DurationVar(&flagStatusModifiedSince, "modified-since", 0, "only show resources Hookdeck updated within this window, e.g. 24h, to spot recent dashboard edits")
+	statusCmd.Flags().BoolVar(&flagStatusOffline, "offline", false, "list the declared resources without contacting Hookdeck or resolving credentials")
 	rootCmd.AddCommand(statusCmd)
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
-	ctx := context.Background()
+	ctx := cmd.Context()
 
-	// 1. Find and load manifest (same resolution as deploy)
-	manifestPath, err := resolveManifestPath()
+	if flagStatusOutput != "text" && flagStatusOutput != "table" {
+		return fmt.Errorf("invalid --output %q: expected text or table", flagStatusOutput)
+	}
+	if flagStatusOffline && flagStatusModifiedSince > 0 {
+		return fmt.Errorf("--modified-since needs remote timestamps and cannot be combined with --offline")
+	}
+
+	// 1. Load the project or manifest (same resolution as deploy), with env
+	// overrides and interpolation applied so ${VAR} names resolve.
+	resolved, err := loadResolvedInput()
 	if err != nil {
 		return err
 	}
+	input, files := resolved.Input, resolved.Files
 
-	fmt.Fprintf(os.Stderr, "Loading manifest: %s\n", manifestPath)
+	// 2. Resolve credentials, unless --offline only lists what is declared.
+	var client *hookdeck.Client
+	if !flagStatusOffline {
+		creds, err := credentials.Resolve(resolved.Profile)
+		if err != nil {
+			return fmt.Errorf("resolving credentials: %w", err)
+		}
+		client = newAPIClient(creds, resolved.APIBaseURL, projectClientConfig(resolved.Config))
+	}
 
-	// 2. Load manifest
-	m, err := manifest.LoadFile(manifestPath)
-	if err != nil {
-		return fmt.Errorf("loading manifest: %w", err)
+	// 3. Check each resource. The four sections are fetched concurrently and
+	// printed in a fixed order once all of them are done.
+	var sections []*statusSection
+	addSection := func(header, kind string, names []string, check func(i int, name string) resourceStatus) {
+		if len(names) == 0 {
+			return
+		}
+		section := &statusSection{header: header, kind: kind, names: names, statuses: make([]resourceStatus, len(names))}
+		sections = append(sections, section)
+		section.run = func() {
+			for i, name := range names {
+				section.statuses[i] = check(i, name)
+			}
+		}
 	}
 
-	// 3. Resolve environment overrides per resource and rebuild manifest for interpolation
-	resolvedManifest := &manifest.Manifest{}
-	for i := range m.Sources {
-		resolved := manifest.ResolveSourceEnv(&m.Sources[i], flagEnv)
-		resolvedManifest.Sources = append(resolvedManifest.Sources, *resolved)
+	var sourceNames, transformationNames, destinationNames, connectionNames []string
+	for _, src := range input.Sources {
+		sourceNames = append(sourceNames, src.Name)
 	}
-	for i := range m.Destinations {
-		resolved := manifest.ResolveDestinationEnv(&m.Destinations[i], flagEnv)
-		resolvedManifest.Destinations = append(resolvedManifest.Destinations, *resolved)
+	for _, tr := range input.Transformations {
+		transformationNames = append(transformationNames, tr.Name)
 	}
-	for i := range m.Transformations {
-		resolved := manifest.ResolveTransformationEnv(&m.Transformations[i], flagEnv)
-		resolvedManifest.Transformations = append(resolvedManifest.Transformations, *resolved)
+	for _, dst := range input.Destinations {
+		destinationNames = append(destinationNames, dst.Name)
 	}
-	resolvedManifest.Connections = m.Connections
-
-	// 4. Interpolate env vars (needed to resolve names that use ${VAR})
-	if err := manifest.InterpolateEnvVars(resolvedManifest); err != nil {
-		return fmt.Errorf("interpolating env vars: %w", err)
+	for _, conn := range input.Connections {
+		connectionNames = append(connectionNames, conn.Name)
 	}
 
-	// 5. Resolve credentials
-	profileName := flagProfile
+	// Sources need the full lookup for their URL; the rest only need an ID
+	// and timestamps.
+	sourceCheck := func(_ int, name string) resourceStatus {
+		return newResourceStatus(client.FindSourceByName(ctx, name))
+	}
+	existsCheck := func(kind string) func(i int, name string) resourceStatus {
+		return func(_ int, name string) resourceStatus {
+			return newResourceStatus(client.FindByName(ctx, kind, name))
+		}
+	}
+	if flagStatusOffline {
+		declared := func(int, string) resourceStatus { return resourceStatus{text: statusDeclared} }
+		sourceCheck = declared
+		existsCheck = func(string) func(int, string) resourceStatus { return declared }
+	}
+	addSection("Sources", project.KindSource, sourceNames, sourceCheck)
+	addSection("Transformations", project.KindTransformation, transformationNames, existsCheck(project.KindTransformation))
+	addSection("Destinations", project.KindDestination, destinationNames, existsCheck(project.KindDestination))
+	// Connections are looked up by position, since two of them may share a
+	// name while linking different endpoints.
+	connectionCheck := existsCheck(project.KindConnection)
+	addSection("Connections", project.KindConnection, connectionNames, func(i int, _ string) resourceStatus {
+		return connectionStatus(input.Connections[i], func(name string) resourceStatus {
+			return connectionCheck(i, name)
+		})
+	})
 
-	creds, err := credentials.Resolve(profileName)
-	if err != nil {
-		return fmt.Errorf("resolving credentials: %w", err)
+	var wg sync.WaitGroup
+	for _, section := range sections {
+		wg.Add(1)
+		go func(run func()) {
+			defer wg.Done()
+			run()
+		}(section.run)
 	}
+	wg.Wait()
 
-	client := hookdeck.NewClient(creds.APIKey, creds.ProjectID)
+	defined := len(sections) > 0
+	if flagStatusModifiedSince > 0 {
+		sections = modifiedSince(sections, time.Now().Add(-flagStatusModifiedSince))
+	}
 
-	// 6. Check each resource
 	fmt.Fprintln(os.Stderr)
-
-	hasResources := false
-
-	if len(resolvedManifest.Sources) > 0 {
-		hasResources = true
-		printStatusHeader("Sources")
-		for _, src := range resolvedManifest.Sources {
-			info, err := client.FindSourceByName(ctx, src.Name)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  %-30s error: %v\n", src.Name, err)
-			} else if info == nil {
-				fmt.Fprintf(os.Stderr, "  %-30s not found\n", src.Name)
-			} else {
-				line := fmt.Sprintf("  %-30s id: %s", info.Name, info.ID)
-				if info.URL != "" {
-					line += fmt.Sprintf("  url: %s", info.URL)
-				}
-				fmt.Fprintln(os.Stderr, line)
+	if flagStatusOutput == "table" && len(sections) > 0 {
+		printStatusTable(sections, files)
+	} else {
+		for _, section := range sections {
+			printStatusHeader(section.header)
+			for i, name := range section.names {
+				fmt.Fprintln(os.Stderr, formatStatusLine(name, section.statuses[i].text, files.lookup(section.kind, name)))
 			}
 		}
 	}
 
-	if len(resolvedManifest.Transformations) > 0 {
-		hasResources = true
-		printStatusHeader("Transformations")
-		for _, tr := range resolvedManifest.Transformations {
-			info, err := client.FindTransformationByName(ctx, tr.Name)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  %-30s error: %v\n", tr.Name, err)
-			} else if info == nil {
-				fmt.Fprintf(os.Stderr, "  %-30s not found\n", tr.Name)
-			} else {
-				fmt.Fprintf(os.Stderr, "  %-30s id: %s\n", info.Name, info.ID)
-			}
-		}
+	switch {
+	case !defined:
+		fmt.Fprintln(os.Stderr, "No resources defined in manifest.")
+	case len(sections) == 0:
+		fmt.Fprintf(os.Stderr, "No resources modified in the last %s.\n", flagStatusModifiedSince)
 	}
 
-	if len(resolvedManifest.Destinations) > 0 {
-		hasResources = true
-		printStatusHeader("Destinations")
-		for _, dst := range resolvedManifest.Destinations {
-			info, err := client.FindDestinationByName(ctx, dst.Name)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  %-30s error: %v\n", dst.Name, err)
-			} else if info == nil {
-				fmt.Fprintf(os.Stderr, "  %-30s not found\n", dst.Name)
-			} else {
-				fmt.Fprintf(os.Stderr, "  %-30s id: %s\n", info.Name, info.ID)
-			}
+	fmt.Fprintln(os.Stderr)
+
+	return nil
+}
+
+// connectionStatus returns the status of conn's live connection, looked up
+// like drift does: by full name, then by the declared name (see
+// manifest.ConnectionLookupNames). check looks up a single name.
+func connectionStatus(conn *manifest.ConnectionConfig, check func(name string) resourceStatus) resourceStatus {
+	var status resourceStatus
+	for _, lookup := range manifest.ConnectionLookupNames(conn) {
+		if status = check(lookup); status.text != statusNotFound {
+			break
 		}
 	}
+	return status
+}
+
+// statusSection collects one resource kind's statuses so sections can be
+// fetched concurrently but printed in order.
+type statusSection struct {
+	header   string
+	kind     string
+	names    []string
+	statuses []resourceStatus
+	run      func()
+}
+
+// Status texts of a resource missing on Hookdeck, and of one listed by
+// --offline without being looked up.
+const (
+	statusNotFound = "not found"
+	statusDeclared = "declared"
+)
+
+// resourceStatus is one resource's status line text and, when it was
+// found, the time Hookdeck last updated it.
+type resourceStatus struct {
+	text    string
+	updated time.Time
+	failed  bool
+}
 
-	if len(resolvedManifest.Connections) > 0 {
-		hasResources = true
-		printStatusHeader("Connections")
-		for _, conn := range resolvedManifest.Connections {
-			info, err := client.FindConnectionByFullName(ctx, conn.Name)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "  %-30s error: %v\n", conn.Name, err)
-			} else if info == nil {
-				fmt.Fprintf(os.Stderr, "  %-30s not found\n", conn.Name)
-			} else {
-				fmt.Fprintf(os.Stderr, "  %-30s id: %s\n", info.Name, info.ID)
+// newResourceStatus renders the result of a lookup: its ID, URL, and
+// update time, "not found", or the error.
+func newResourceStatus(info *hookdeck.ResourceInfo, err error) resourceStatus {
+	if err != nil {
+		return resourceStatus{text: fmt.Sprintf("error: %v", err), failed: true}
+	}
+	if info == nil {
+		return resourceStatus{text: statusNotFound}
+	}
+	text := "id: " + info.ID
+	if info.URL != "" {
+		text += "  url: " + info.URL
+	}
+	if !info.UpdatedAt.IsZero() {
+		text += "  updated: " + info.UpdatedAt.Format(time.RFC3339)
+	}
+	return resourceStatus{text: text, updated: info.UpdatedAt}
+}
+
+// modifiedSince keeps the resources Hookdeck updated after cutoff, plus any
+// that couldn't be checked, dropping sections left empty.
+func modifiedSince(sections []*statusSection, cutoff time.Time) []*statusSection {
+	var kept []*statusSection
+	for _, section := range sections {
+		filtered := &statusSection{header: section.header, kind: section.kind}
+		for i, status := range section.statuses {
+			if status.failed || status.updated.After(cutoff) {
+				filtered.names = append(filtered.names, section.names[i])
+				filtered.statuses = append(filtered.statuses, status)
 			}
 		}
+		if len(filtered.names) > 0 {
+			kept = append(kept, filtered)
+		}
 	}
+	return kept
+}
 
-	if !hasResources {
-		fmt.Fprintln(os.Stderr, "No resources defined in manifest.")
+// printStatusTable prints every section as one table, with the status
+// colored red when the resource is missing or couldn't be checked.
+func printStatusTable(sections []*statusSection, files resourceFiles) {
+	tbl := newTable("KIND", "NAME", "STATUS", "FILE")
+	for _, section := range sections {
+		for i, name := range section.names {
+			status := section.statuses[i]
+			color := colorGreen
+			switch {
+			case status.text == statusDeclared:
+				color = ""
+			case status.text == statusNotFound || status.failed:
+				color = colorRed
+			}
+			tbl.addRow(
+				plainCell(kindLabel(section.kind)),
+				plainCell(name),
+				tableCell{text: status.text, color: color},
+				plainCell(files.lookup(section.kind, name)),
+			)
+		}
 	}
-
-	fmt.Fprintln(os.Stderr)
-
-	return nil
+	tbl.render(os.Stderr, colorEnabled(os.Stderr))
 }
 
 // printStatusHeader prints a section header for resource status output.
 func printStatusHeader(kind string) {
 	fmt.Fprintf(os.Stderr, "%s:\n", kind)
 }
+
+// formatStatusLine renders one resource's status, followed by the manifest
+// file that declared it when known.
+func formatStatusLine(name, status, file string) string {
+	if file != "" {
+		status += "  file: " + file
+	}
+	return fmt.Sprintf("  %-30s %s", name, status)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func TestConnectionStatus_FallsBackToDeclaredName(t *testing.T) {
+	conn := &manifest.ConnectionConfig{Name: "orders", Source: "shop", Destination: "api"}
+	var lookups []string
+	status := connectionStatus(conn, func(name string) resourceStatus {
+		lookups = append(lookups, name)
+		if name == "orders" {
+			return resourceStatus{text: "id: con_1"}
+		}
+		return resourceStatus{text: statusNotFound}
+	})
+	if status.text != "id: con_1" {
+		t.Errorf("status = %q, want the connection found by name", status.text)
+	}
+	if len(lookups) != 2 || lookups[0] != "shop->api" {
+		t.Errorf("lookups = %v, want the full name first", lookups)
+	}
+}
+
+func TestConnectionStatus_SharedName(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"hookdeck.jsonc": `{
+		"sources": [{"name": "shop"}, {"name": "billing"}],
+		"destinations": [{"name": "api", "url": "https://api.example.com"}],
+		"connections": [
+			{"name": "orders", "source": "shop", "destination": "api"},
+			{"name": "orders", "source": "billing", "destination": "api"}
+		]
+	}`})
+	defer func(file string) { flagFile = file }(flagFile)
+	flagFile = filepath.Join(dir, "hookdeck.jsonc")
+
+	resolved, err := loadResolvedInput()
+	if err != nil {
+		t.Fatalf("loadResolvedInput failed: %v", err)
+	}
+	live := map[string]string{"shop->api": "id: con_shop", "billing->api": "id: con_billing"}
+	check := func(name string) resourceStatus {
+		if text, ok := live[name]; ok {
+			return resourceStatus{text: text}
+		}
+		return resourceStatus{text: statusNotFound}
+	}
+
+	var got []string
+	for _, conn := range resolved.Input.Connections {
+		got = append(got, connectionStatus(conn, check).text)
+	}
+	if len(got) != 2 || got[0] != "id: con_shop" || got[1] != "id: con_billing" {
+		t.Errorf("statuses = %v, want each connection's own live connection", got)
+	}
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+// summaryFile is the JSON written by --summary-file: the deploy result
+// (which encodes its own summary and timing), and the error that stopped the
+// deploy, if any.
+type summaryFile struct {
+	Env    string         `json:"env,omitempty"`
+	DryRun bool           `json:"dry_run,omitempty"`
+	Result *deploy.Result `json:"result"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// summaryRecorder collects what --summary-file records for the running
+// deploy command, or is nil when the flag isn't set. runDeploy writes it once
+// the command returns, so a command that fails before or during the deploy
+// still leaves a summary file.
+var summaryRecorder *resultRecorder
+
+// resultRecorder wraps a deploy.Reporter and collects every finished
+// resource into a Result, plus the resource in flight. Deploy returns no
+// result when an upsert fails, so this is what --summary-file writes for a
+// failed deploy.
+type resultRecorder struct {
+	next deploy.Reporter
+
+	mu     sync.Mutex
+	result *deploy.Result
+	// final is the result of a completed deploy or preview, written in
+	// place of result.
+	final *deploy.Result
+	// inFlight is the resource started but not yet done, if any.
+	inFlightKind string
+	inFlight     string
+}
+
+func newResultRecorder(next deploy.Reporter) *resultRecorder {
+	return &resultRecorder{next: next, result: &deploy.Result{StartedAt: time.Now()}}
+}
+
+func (r *resultRecorder) OnResourceStart(kind, name string) {
+	r.mu.Lock()
+	r.inFlightKind, r.inFlight = kind, name
+	r.mu.Unlock()
+	if r.next != nil {
+		r.next.OnResourceStart(kind, name)
+	}
+}
+
+func (r *resultRecorder) OnResourceDone(kind string, res *deploy.ResourceResult) {
+	r.mu.Lock()
+	r.inFlightKind, r.inFlight = "", ""
+	r.add(kind, res)
+	r.mu.Unlock()
+	if r.next != nil {
+		r.next.OnResourceDone(kind, res)
+	}
+}
+
+// add appends res to the collected result. r.mu must be held.
+func (r *resultRecorder) add(kind string, res *deploy.ResourceResult) {
+	switch kind {
+	case "source":
+		r.result.Sources = append(r.result.Sources, res)
+	case "transformation":
+		r.result.Transformations = append(r.result.Transformations, res)
+	case "destination":
+		r.result.Destinations = append(r.result.Destinations, res)
+	case "connection":
+		r.result.Connections = append(r.result.Connections, res)
+	}
+}
+
+// recordResults wraps reporter in summaryRecorder when --summary-file is
+// set, and otherwise returns it unchanged.
+func recordResults(reporter deploy.Reporter) deploy.Reporter {
+	if summaryRecorder == nil {
+		return reporter
+	}
+	summaryRecorder.next = reporter
+	return summaryRecorder
+}
+
+// recordFinalResult keeps the result of a completed deploy or preview for
+// --summary-file.
+func recordFinalResult(result *deploy.Result) {
+	if summaryRecorder == nil {
+		return
+	}
+	summaryRecorder.mu.Lock()
+	summaryRecorder.final = result
+	summaryRecorder.mu.Unlock()
+}
+
+// writeSummaryFile writes recorder's final result, or what it collected when
+// there is none, to --summary-file along with cmdErr. A resource that was
+// started but never finished is recorded as failed.
+func writeSummaryFile(recorder *resultRecorder, cmdErr error) error {
+	recorder.mu.Lock()
+	result := recorder.final
+	if result == nil {
+		result = recorder.result
+		if recorder.inFlight != "" || recorder.inFlightKind != "" {
+			recorder.add(recorder.inFlightKind, &deploy.ResourceResult{Name: recorder.inFlight, Action: actionFailed})
+			recorder.inFlightKind, recorder.inFlight = "", ""
+		}
+		result.FinishedAt = time.Now()
+	}
+	recorder.mu.Unlock()
+
+	out := summaryFile{Env: flagEnv, DryRun: flagDryRun, Result: result}
+	if cmdErr != nil {
+		out.Error = cmdErr.Error()
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding summary file: %w", err)
+	}
+	if err := os.WriteFile(flagSummaryFile, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing summary file: %w", err)
+	}
+	return nil
+}
+
+// finishSummaryFile writes the summary file once the deploy command is done
+// and returns the error the command should fail with: cmdErr when it failed
+// (a write failure is then only logged), otherwise any write failure.
+func finishSummaryFile(recorder *resultRecorder, cmdErr error) error {
+	err := writeSummaryFile(recorder, cmdErr)
+	if cmdErr == nil {
+		return err
+	}
+	if err != nil {
+		logger.Warnf("%v", err)
+	}
+	return cmdErr
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+// readSummaryFile decodes the file written to --summary-file.
+func readSummaryFile(t *testing.T) summaryFile {
+	t.Helper()
+	data, err := os.ReadFile(flagSummaryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out summaryFile
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("decoding %s: %v", data, err)
+	}
+	return out
+}
+
+// withSummaryFile points --summary-file at a temporary file for one test.
+func withSummaryFile(t *testing.T) {
+	t.Helper()
+	flagSummaryFile = filepath.Join(t.TempDir(), "summary.json")
+	t.Cleanup(func() { flagSummaryFile = "" })
+}
+
+type countingReporter struct{ starts, dones int }
+
+func (c *countingReporter) OnResourceStart(string, string)                { c.starts++ }
+func (c *countingReporter) OnResourceDone(string, *deploy.ResourceResult) { c.dones++ }
+
+func TestRecordResults(t *testing.T) {
+	next := &countingReporter{}
+	if got := recordResults(next); got != deploy.Reporter(next) {
+		t.Errorf("expected the reporter unchanged without --summary-file, got %T", got)
+	}
+
+	summaryRecorder = newResultRecorder(nil)
+	t.Cleanup(func() { summaryRecorder = nil })
+	reporter := recordResults(next)
+	if reporter != deploy.Reporter(summaryRecorder) {
+		t.Fatalf("expected the summary recorder, got %T", reporter)
+	}
+	reporter.OnResourceStart("source", "src")
+	reporter.OnResourceDone("source", &deploy.ResourceResult{Name: "src", Action: "upserted"})
+	if next.starts != 1 || next.dones != 1 {
+		t.Errorf("expected events forwarded, got %d starts and %d dones", next.starts, next.dones)
+	}
+	if len(summaryRecorder.result.Sources) != 1 {
+		t.Errorf("expected the source recorded, got %+v", summaryRecorder.result)
+	}
+}
+
+func TestWriteSummaryFile_RecordsFailingResource(t *testing.T) {
+	withSummaryFile(t)
+	recorder := newResultRecorder(nil)
+	recorder.OnResourceStart("source", "src")
+	recorder.OnResourceDone("source", &deploy.ResourceResult{Name: "src", ID: "src_1", Action: "upserted"})
+	recorder.OnResourceStart("destination", "dst")
+
+	err := finishSummaryFile(recorder, errors.New("deploy failed: boom"))
+	if err == nil || err.Error() != "deploy failed: boom" {
+		t.Fatalf("expected the command error back, got %v", err)
+	}
+
+	out := readSummaryFile(t)
+	if out.Error != "deploy failed: boom" {
+		t.Errorf("unexpected error: %q", out.Error)
+	}
+	if len(out.Result.Sources) != 1 || out.Result.Sources[0].ID != "src_1" {
+		t.Errorf("expected the applied source, got %+v", out.Result.Sources)
+	}
+	if len(out.Result.Destinations) != 1 || out.Result.Destinations[0].Name != "dst" || out.Result.Destinations[0].Action != actionFailed {
+		t.Errorf("expected the failing destination recorded as failed, got %+v", out.Result.Destinations)
+	}
+}
+
+func TestWriteSummaryFile_BeforeDeploy(t *testing.T) {
+	withSummaryFile(t)
+	if err := writeSummaryFile(newResultRecorder(nil), errors.New("resolving credentials: no API key")); err != nil {
+		t.Fatal(err)
+	}
+	out := readSummaryFile(t)
+	if out.Error != "resolving credentials: no API key" || out.Result == nil {
+		t.Errorf("expected an empty result with the error, got %+v", out)
+	}
+}
+
+func TestWriteSummaryFile_PrefersFinalResult(t *testing.T) {
+	withSummaryFile(t)
+	summaryRecorder = newResultRecorder(nil)
+	t.Cleanup(func() { summaryRecorder = nil })
+	summaryRecorder.OnResourceStart("source", "src")
+	recordFinalResult(&deploy.Result{Sources: []*deploy.ResourceResult{{Name: "src", Action: "skipped"}}})
+
+	if err := finishSummaryFile(summaryRecorder, nil); err != nil {
+		t.Fatal(err)
+	}
+	out := readSummaryFile(t)
+	if out.Error != "" || len(out.Result.Sources) != 1 || out.Result.Sources[0].Action != "skipped" {
+		t.Errorf("expected the final result, got %+v", out.Result)
+	}
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+// ANSI colors used by table cells.
+const (
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+	colorReset  = "\033[0m"
+)
+
+// actionFailed is shown in table output for a resource that was started but
+// never finished when the deploy stopped.
+const actionFailed = "failed"
+
+// tableCell is one cell of a table, optionally colored.
+type tableCell struct {
+	text  string
+	color string
+}
+
+// table renders rows with each column as wide as its widest cell, so long
+// names don't push later columns out of line like fixed-width formatting
+// does. Columns that are empty in every row are left out.
+type table struct {
+	header []string
+	rows   [][]tableCell
+}
+
+// newTable returns a table with the given column headers.
+func newTable(header ...string) *table {
+	return &table{header: header}
+}
+
+// addRow appends a row. A row shorter than the header leaves the remaining
+// columns empty.
+func (t *table) addRow(cells ...tableCell) {
+	t.rows = append(t.rows, cells)
+}
+
+// plainCell returns an uncolored cell.
+func plainCell(text string) tableCell {
+	return tableCell{text: text}
+}
+
+// render writes the header and rows to w, indented like the text output.
+// Cell colors are only applied when color is true.
+func (t *table) render(w io.Writer, color bool) {
+	widths := make([]int, len(t.header))
+	for i, h := range t.header {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	used := make([]bool, len(t.header))
+	for _, row := range t.rows {
+		for i, c := range row {
+			if i >= len(widths) || c.text == "" {
+				continue
+			}
+			used[i] = true
+			if n := utf8.RuneCountInString(c.text); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+	var cols []int
+	for i := range t.header {
+		if used[i] {
+			cols = append(cols, i)
+		}
+	}
+
+	writeLine := func(cells []tableCell) {
+		var b strings.Builder
+		b.WriteString("  ")
+		for n, i := range cols {
+			var c tableCell
+			if i < len(cells) {
+				c = cells[i]
+			}
+			if color && c.color != "" {
+				b.WriteString(c.color + c.text + colorReset)
+			} else {
+				b.WriteString(c.text)
+			}
+			if n < len(cols)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c.text)+2))
+			}
+		}
+		b.WriteString("\n")
+		io.WriteString(w, b.String())
+	}
+
+	header := make([]tableCell, len(t.header))
+	for i, h := range t.header {
+		header[i] = plainCell(h)
+	}
+	writeLine(header)
+	for _, row := range t.rows {
+		writeLine(row)
+	}
+}
+
+// colorEnabled reports whether output written to f should be colored: only
+// on a terminal, and never when NO_COLOR is set (https://no-color.org).
+func colorEnabled(f *os.File) bool {
+	return os.Getenv("NO_COLOR") == "" && isTerminal(f)
+}
+
+// actionColor returns the color for a deploy or preview action: green for
+// applied changes, yellow for skipped or unchanged resources, red for
+// failures, and none for the rest.
+func actionColor(action string) string {
+	switch {
+	case action == "upserted", action == deploy.ActionReplaced:
+		return colorGreen
+	case action == actionFailed:
+		return colorRed
+	case strings.HasPrefix(action, "skipped"), action == actionNoChanges:
+		return colorYellow
+	}
+	return ""
+}
+
+// tableReporter is the deploy.Reporter for --output table. It collects every
+// result and prints them as one table once the deploy returns, since column
+// widths depend on all of the rows.
+type tableReporter struct {
+	files resourceFiles
+	out   io.Writer
+	color bool
+
+	mu      sync.Mutex
+	order   []string
+	kinds   map[string]string
+	names   map[string]string
+	results map[string]*deploy.ResourceResult
+	// started maps a kind to the key of its resource awaiting a result, so
+	// the result lands on that row even if the API returned another name.
+	started map[string]string
+}
+
+func newTableReporter(files resourceFiles) *tableReporter {
+	return &tableReporter{
+		files:   files,
+		out:     os.Stderr,
+		color:   colorEnabled(os.Stderr),
+		kinds:   map[string]string{},
+		names:   map[string]string{},
+		results: map[string]*deploy.ResourceResult{},
+		started: map[string]string{},
+	}
+}
+
+func (t *tableReporter) OnResourceStart(kind, name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started[kind] = t.track(kind, name)
+}
+
+func (t *tableReporter) OnResourceDone(kind string, r *deploy.ResourceResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key, ok := t.started[kind]
+	if ok {
+		delete(t.started, kind)
+	} else {
+		key = t.track(kind, r.Name)
+	}
+	t.results[key] = r
+}
+
+// track records the resource in start order and returns its key, which is
+// unique per kind and name. t.mu must be held.
+func (t *tableReporter) track(kind, name string) string {
+	key := kind + "/" + name
+	if _, ok := t.kinds[key]; !ok {
+		t.kinds[key] = kind
+		t.names[key] = name
+		t.order = append(t.order, key)
+	}
+	return key
+}
+
+// finish prints the table. Resources that started but never finished are
+// shown as failed.
+func (t *tableReporter) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.order) == 0 {
+		return
+	}
+	tbl := newTable("KIND", "NAME", "ACTION", "ID", "FILE", "REASON")
+	for _, key := range t.order {
+		kind := t.kinds[key]
+		r, ok := t.results[key]
+		if !ok {
+			r = &deploy.ResourceResult{Name: t.names[key], Action: actionFailed}
+		}
+		tbl.addRow(
+			plainCell(kindLabel(kind)),
+			plainCell(r.Name),
+			tableCell{text: r.Action, color: actionColor(r.Action)},
+			plainCell(r.ID),
+			plainCell(t.files.lookup(kind, r.Name)),
+			plainCell(r.Reason),
+		)
+	}
+	tbl.render(t.out, t.color)
+}
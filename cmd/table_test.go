@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+// renderTable returns what t.finish prints, without colors.
+func renderTable(t *tableReporter) string {
+	var buf bytes.Buffer
+	t.out, t.color = &buf, false
+	t.finish()
+	return buf.String()
+}
+
+func TestTableReporter_KeysRowsByKindAndName(t *testing.T) {
+	tr := newTableReporter(nil)
+	tr.OnResourceStart("source", "orders")
+	tr.OnResourceDone("source", &deploy.ResourceResult{Name: "orders", ID: "src_1", Action: "upserted"})
+	tr.OnResourceStart("destination", "orders")
+	tr.OnResourceDone("destination", &deploy.ResourceResult{Name: "orders", ID: "des_1", Action: "skipped"})
+
+	want := "" +
+		"  KIND         NAME    ACTION    ID\n" +
+		"  Source       orders  upserted  src_1\n" +
+		"  Destination  orders  skipped   des_1\n"
+	if got := renderTable(tr); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTableReporter_ResultUnderStartedName(t *testing.T) {
+	// An unnamed connection starts as "" and comes back with the API's name;
+	// it must fill the started row rather than add a second one.
+	tr := newTableReporter(nil)
+	tr.OnResourceStart("connection", "")
+	tr.OnResourceDone("connection", &deploy.ResourceResult{Name: "src->dst", ID: "con_1", Action: "upserted"})
+
+	got := renderTable(tr)
+	if strings.Count(got, "\n") != 2 || strings.Contains(got, actionFailed) {
+		t.Errorf("expected a single upserted row, got:\n%s", got)
+	}
+	if !strings.Contains(got, "src->dst") {
+		t.Errorf("expected the returned name in the row, got:\n%s", got)
+	}
+}
+
+func TestTableReporter_UnfinishedIsFailed(t *testing.T) {
+	tr := newTableReporter(nil)
+	tr.OnResourceDone("source", &deploy.ResourceResult{Name: "old", Action: deploy.ActionSkippedDisabled})
+	tr.OnResourceStart("source", "new")
+
+	want := "" +
+		"  KIND    NAME  ACTION\n" +
+		"  Source  old   skipped (disabled)\n" +
+		"  Source  new   failed\n"
+	if got := renderTable(tr); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTableReporter_EmptyPrintsNothing(t *testing.T) {
+	if got := renderTable(newTableReporter(nil)); got != "" {
+		t.Errorf("expected no output, got %q", got)
+	}
+}
+
+func TestTable_ColorsOnlyWhenEnabled(t *testing.T) {
+	tbl := newTable("NAME", "ACTION")
+	tbl.addRow(plainCell("a"), tableCell{text: "failed", color: colorRed})
+
+	var plain, colored bytes.Buffer
+	tbl.render(&plain, false)
+	tbl.render(&colored, true)
+	if strings.Contains(plain.String(), "\033[") {
+		t.Errorf("expected no escape codes, got %q", plain.String())
+	}
+	if !strings.Contains(colored.String(), colorRed+"failed"+colorReset) {
+		t.Errorf("expected a colored cell, got %q", colored.String())
+	}
+}
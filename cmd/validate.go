@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check a manifest or project for errors without deploying",
+	Long: `Validate loads the project or manifest (same resolution as deploy), applies
+the --env overlay and variable interpolation, and runs the same checks deploy
+performs before contacting the API: source and destination types must be
+known Hookdeck types, retry rules must have a valid strategy, count,
+interval, and response_status_codes, filters may only use Hookdeck's filter
+operators, destination URLs must have an http or https scheme and a host,
+and connection references are reported when they point at resources that
+aren't defined. A destination URL using plain http to a host other than
+localhost is a warning, or an error under --strict-urls.
+
+With --schema, every manifest file is first checked against the embedded
+JSON Schema (see "hookdeck-deploy schema"), reporting each violation with its
+JSON path and line.`,
+	Args: cobra.NoArgs,
+	RunE: runValidate,
+}
+
+var flagSchema bool
+
+func init() {
+	validateCmd.Flags().BoolVar(&flagSchema, "schema", false, "also validate each manifest file against the embedded JSON Schema")
+	rootCmd.AddCommand(validateCmd)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	if flagSchema {
+		if err := checkSchemas(); err != nil {
+			return err
+		}
+	}
+	resolved, err := loadResolvedInput()
+	if err != nil {
+		return err
+	}
+	input := resolved.Input
+
+	for _, err := range deploy.CheckReferences(input) {
+		logger.Warnf("%s", err)
+	}
+	if err := validateInput(input); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Validation passed.")
+	return nil
+}
+
+// validateInput runs the pre-deploy checks on a resolved input and combines
+// any problems into a single error. Plain http destination URLs are only
+// warned about, unless --strict-urls is set.
+func validateInput(input *deploy.DeployInput) error {
+	m := deployInputToManifest(input)
+	errs := append(manifest.ValidateTypes(m), manifest.ValidateRules(m)...)
+	errs = append(errs, manifest.ValidateAuth(m)...)
+	errs = append(errs, manifest.ValidateFilters(m)...)
+	errs = append(errs, manifest.ValidateURLs(m)...)
+	for _, err := range manifest.InsecureURLs(m) {
+		if flagStrictURLs {
+			errs = append(errs, err)
+		} else {
+			logger.Warnf("%s", err)
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return fmt.Errorf("validation failed:\n  %s", strings.Join(msgs, "\n  "))
+}
+
+// manifestPaths returns the manifest files deploy would load: every manifest
+// under the project root in project mode, or the single resolved manifest.
+func manifestPaths() ([]string, error) {
+	if flagProject != "" || (flagFile == "" && projectFileExists()) {
+		projectPath, err := resolveProjectPath()
+		if err != nil {
+			return nil, err
+		}
+		return project.DiscoverManifests(filepath.Dir(projectPath))
+	}
+	manifestPath, err := resolveManifestPath()
+	if err != nil {
+		return nil, err
+	}
+	return []string{manifestPath}, nil
+}
+
+// checkSchemas validates every manifest file against the embedded JSON Schema
+// and combines the violations into a single error.
+func checkSchemas() error {
+	paths, err := manifestPaths()
+	if err != nil {
+		return err
+	}
+	var msgs []string
+	for _, path := range paths {
+		errs, err := manifest.ValidateSchemaFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for _, e := range errs {
+			msgs = append(msgs, fmt.Sprintf("%s: %s", path, e))
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("schema validation failed:\n  %s", strings.Join(msgs, "\n  "))
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/drift"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// cliVars holds the --var KEY=VALUE pairs, parsed before any command runs.
+var cliVars map[string]string
+
+// secrets records every value substituted by interpolateManifest so output
+// can mask it (see redact).
+var secrets = manifest.NewRedactor()
+
+// interpolateManifest resolves ${VAR} references in m. ${provider:key}
+// references go to the registered secret resolvers. Other variables are
+// looked up in --var values first, then the process environment, then the
+// .env files found in dir (or the files given with --env-file), and finally
+// the manifest's own vars. Manifest vars are declared in plain text, so they
+// aren't redacted in output. Under --allow-unresolved, variables found
+// nowhere are left as ${VAR}.
+func interpolateManifest(m *manifest.Manifest, dir string) error {
+	fileVars, err := loadEnvFileVars(dir)
+	if err != nil {
+		return err
+	}
+	secretRefs := &manifest.SecretLookup{}
+	err = manifest.InterpolateVarsWithOptions(m, manifest.ChainLookup(
+		secrets.Track(manifest.ChainLookup(
+			secretRefs.Lookup,
+			manifest.MapLookup(cliVars),
+			os.LookupEnv,
+			manifest.MapLookup(fileVars),
+		)),
+		manifest.MapLookup(m.Vars),
+	), manifest.InterpolateOptions{AllowUnresolved: flagAllowUnresolved})
+	if secretErr := secretRefs.Err(); secretErr != nil {
+		return secretErr
+	}
+	return err
+}
+
+// redact masks interpolated values in s unless --show-secrets is set. Use it
+// for field values in human-facing output; resource names are left as-is.
+func redact(s string) string {
+	if flagShowSecrets {
+		return s
+	}
+	return secrets.Redact(s)
+}
+
+// redactDiffs returns a copy of diffs with redact applied to every field value.
+func redactDiffs(diffs []drift.Diff) []drift.Diff {
+	out := make([]drift.Diff, len(diffs))
+	for i, d := range diffs {
+		out[i] = d
+		if d.Fields == nil {
+			continue
+		}
+		out[i].Fields = make([]drift.FieldDiff, len(d.Fields))
+		for j, f := range d.Fields {
+			out[i].Fields[j] = drift.FieldDiff{Field: f.Field, Local: redact(f.Local), Remote: redact(f.Remote)}
+		}
+	}
+	return out
+}
+
+// parseCLIVars parses KEY=VALUE entries from --var. Later entries override
+// earlier ones for the same key.
+func parseCLIVars(entries []string) (map[string]string, error) {
+	vars := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected KEY=VALUE", entry)
+		}
+		vars[strings.TrimSpace(key)] = value
+	}
+	return vars, nil
+}
+
+// loadEnvFileVars reads interpolation variables from --env-file paths when
+// given (later files override earlier ones), otherwise from .env and
+// .env.<env> in dir, skipping files that don't exist.
+func loadEnvFileVars(dir string) (map[string]string, error) {
+	if len(flagEnvFiles) == 0 {
+		return manifest.LoadEnvFiles(dir, flagEnv)
+	}
+
+	vars := make(map[string]string)
+	for _, path := range flagEnvFiles {
+		fileVars, err := manifest.ParseEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	return vars, nil
+}
@@ -0,0 +1,417 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/drift"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/glob"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+// watchDebounce is how long deploy --watch waits after the last change
+// before redeploying, so an editor's burst of writes triggers one cycle.
+const watchDebounce = 300 * time.Millisecond
+
+// deployWatcher redeploys the resources affected by file changes.
+type deployWatcher struct {
+	client  *hookdeck.Client
+	watcher *fsnotify.Watcher
+	project bool
+
+	// resolved is the last input that loaded successfully; it decides which
+	// files are relevant until the next successful load.
+	resolved *resolvedInput
+	// trees are the directories watched recursively for "**" code file
+	// patterns; directories created inside them are watched too.
+	trees []string
+}
+
+// runWatchDeploy deploys once, then redeploys on every change to a manifest,
+// project config, env file, or transformation code file until interrupted.
+// Credentials are resolved once, from the first load.
+func runWatchDeploy(ctx context.Context) error {
+	// --timeout bounds each deploy cycle rather than the whole session.
+	ctx, stop := signal.NotifyContext(context.WithoutCancel(ctx), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	resolved, err := loadWatchInput()
+	if err != nil {
+		return err
+	}
+	if resolved.Config != nil {
+		if err := confirmProtectedEnv(flagEnv, resolved.Config.IsProtected(flagEnv)); err != nil {
+			return err
+		}
+	}
+	creds, err := credentials.Resolve(resolved.Profile)
+	if err != nil {
+		return fmt.Errorf("resolving credentials: %w", err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("starting file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	w := &deployWatcher{
+		client:  newAPIClient(creds, resolved.APIBaseURL, projectClientConfig(resolved.Config)),
+		watcher: watcher,
+		project: flagProject != "" || (flagFile == "" && projectFileExists()),
+	}
+	watchLogf("Initial deploy")
+	w.deploy(ctx, resolved, resolved.Input)
+	w.watch(resolved)
+	watchLogf("Watching for changes (Ctrl-C to stop)")
+
+	changed := map[string]bool{}
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr)
+			watchLogf("Stopped watching")
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op.Has(fsnotify.Create) {
+				w.watchNewDir(event.Name)
+			}
+			if event.Op == fsnotify.Chmod || !w.relevant(event.Name) {
+				continue
+			}
+			changed[filepath.Clean(event.Name)] = true
+			debounce = time.After(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			logger.Warnf("file watcher: %v", err)
+		case <-debounce:
+			debounce = nil
+			w.cycle(ctx, changed)
+			changed = map[string]bool{}
+		}
+	}
+}
+
+// cycle reloads the input and redeploys what the changed files affect.
+// Errors are logged rather than returned so watching continues.
+func (w *deployWatcher) cycle(ctx context.Context, changed map[string]bool) {
+	paths := make([]string, 0, len(changed))
+	for path := range changed {
+		paths = append(paths, w.display(path))
+	}
+	sort.Strings(paths)
+	fmt.Fprintln(os.Stderr)
+	watchLogf("Changed: %s", strings.Join(paths, ", "))
+
+	resolved, err := loadWatchInput()
+	if err != nil {
+		watchLogf("Error: %v", err)
+		return
+	}
+	w.watch(resolved)
+
+	input := affectedInput(resolved, changed)
+	if input == nil {
+		watchLogf("Redeploying all resources")
+		input = resolved.Input
+	}
+	if len(input.Sources)+len(input.Transformations)+len(input.Destinations)+len(input.Connections)+len(input.Disabled) == 0 {
+		watchLogf("No resources affected")
+		return
+	}
+	w.deploy(ctx, resolved, input)
+}
+
+// loadWatchInput loads and validates the input like deploy does, with an
+// absolute Dir so paths compare equal to the watcher's event paths.
+func loadWatchInput() (*resolvedInput, error) {
+	resolved, err := loadResolvedInput()
+	if err != nil {
+		return nil, err
+	}
+	if resolved.Dir, err = filepath.Abs(resolved.Dir); err != nil {
+		return nil, err
+	}
+	if !flagNoValidate {
+		if err := validateInput(resolved.Input); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// deploy runs one deploy of input and logs its summary.
+func (w *deployWatcher) deploy(ctx context.Context, resolved *resolvedInput, input *deploy.DeployInput) {
+	if flagTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, flagTimeout)
+		defer cancel()
+	}
+	if w.project {
+		sorted, err := project.SortDeployInput(input)
+		if err != nil {
+			watchLogf("Error: ordering resources: %v", err)
+			return
+		}
+		input = sorted
+	}
+
+	checker := drift.NewChecker(w.client)
+	opts := deploy.Options{
+		CodeRoot:           resolved.Dir,
+		Reporter:           newStreamReporter(resolved.Files),
+		SkipUnchanged:      flagSkipUnchanged,
+		Checker:            checker,
+		PreserveRemoteAuth: flagPreserveRemoteAuth,
+		AuthFetcher:        checker,
+		DeleteOrphanRules:  flagDeleteOrphanRules,
+		RulesFetcher:       checker,
+		DumpRequests:       dumpRequestWriter(),
+		Redact:             redact,
+		CanonicalRuleOrder: flagCanonicalRuleOrder,
+		Warnf:              logger.Warnf,
+		ReplaceConnections: flagReplace,
+		Replacer:           drift.NewReplacer(w.client),
+		SourceTypes:        checker,
+		AllowTypeChange:    flagAllowTypeChange,
+		MaxCodeSize:        flagMaxPayloadSize,
+	}
+	result, err := deploy.Deploy(ctx, w.client, input, opts)
+	if err != nil {
+		watchLogf("Deploy failed: %v", withFlagHint(err))
+		if result != nil {
+			watchLogf("Applied before stopping: %s", result.Summary())
+		}
+		return
+	}
+	watchLogf("Summary: %s", result.Summary())
+}
+
+// affectedInput returns the part of resolved.Input that changed touches:
+// resources declared in a changed manifest and transformations whose code
+// changed, plus what their connections reference (see project.Select). It
+// returns nil when a change can't be attributed to particular resources,
+// such as an edit to the project config or an env file, so everything is
+// redeployed.
+func affectedInput(resolved *resolvedInput, changed map[string]bool) *deploy.DeployInput {
+	attributed := map[string]bool{}
+	fileChanged := map[string]bool{}
+	for kind, byName := range resolved.Files {
+		for name, path := range byName {
+			if path = absPath(resolved.Dir, path); changed[path] {
+				fileChanged[kind+"/"+name] = true
+				attributed[path] = true
+			}
+		}
+	}
+	codeChanged := map[string]bool{}
+	for _, tr := range resolved.Input.Transformations {
+		for path := range changed {
+			if codeFileMatches(tr.CodeFile, tr.CodeFiles, resolved.Dir, path) {
+				codeChanged[tr.Name] = true
+				attributed[path] = true
+			}
+		}
+	}
+
+	for path := range changed {
+		if !attributed[path] {
+			return nil
+		}
+	}
+	return project.Select(resolved.Input, func(kind, name string) bool {
+		return fileChanged[kind+"/"+name] || (kind == project.KindTransformation && codeChanged[name])
+	})
+}
+
+// absPath resolves path against dir unless it is already absolute.
+func absPath(dir, path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// codeFileMatches reports whether path is one of a transformation's code
+// files. Patterns are relative to dir unless absolute, and may be globs.
+func codeFileMatches(codeFile string, codeFiles []string, dir, path string) bool {
+	patterns := codeFiles
+	if codeFile != "" {
+		patterns = []string{codeFile}
+	}
+	for _, pattern := range patterns {
+		pattern = absPath(dir, pattern)
+		if !glob.HasMeta(pattern) {
+			if pattern == path {
+				return true
+			}
+			continue
+		}
+		re, err := glob.Compile(filepath.ToSlash(pattern))
+		if err == nil && re.MatchString(filepath.ToSlash(path)) {
+			return true
+		}
+	}
+	return false
+}
+
+// watch adds the directories holding resolved's manifests, code files, and
+// env files to the watcher, and makes resolved the current input. Adding a
+// directory that is already watched is a no-op.
+func (w *deployWatcher) watch(resolved *resolvedInput) {
+	w.resolved = resolved
+
+	dirs, trees := watchDirs(resolved)
+	w.trees = trees
+	for _, dir := range dirs {
+		if err := w.watcher.Add(dir); err != nil {
+			logger.Warnf("watching %s: %v", w.display(dir), err)
+		}
+	}
+}
+
+// watchNewDir starts watching path, and the directories below it, when it
+// is a directory created inside one of w.trees.
+func (w *deployWatcher) watchNewDir(path string) {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return
+	}
+	for _, tree := range w.trees {
+		if rel, err := filepath.Rel(tree, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			for _, dir := range subdirs(path) {
+				if err := w.watcher.Add(dir); err != nil {
+					logger.Warnf("watching %s: %v", w.display(dir), err)
+				}
+			}
+			return
+		}
+	}
+}
+
+// watchDirs returns, sorted, the directories to watch for resolved: those
+// holding its manifests, code files, and env files. A code file pattern
+// containing "**" can match in any directory below its static prefix, so
+// that whole tree is included and returned in trees.
+func watchDirs(resolved *resolvedInput) (dirs, trees []string) {
+	set := map[string]bool{resolved.Dir: true}
+	for _, byName := range resolved.Files {
+		for _, path := range byName {
+			set[filepath.Dir(absPath(resolved.Dir, path))] = true
+		}
+	}
+	for _, tr := range resolved.Input.Transformations {
+		patterns := tr.CodeFiles
+		if tr.CodeFile != "" {
+			patterns = []string{tr.CodeFile}
+		}
+		for _, pattern := range patterns {
+			pattern = absPath(resolved.Dir, pattern)
+			root := staticDir(pattern)
+			set[root] = true
+			if strings.Contains(pattern, "**") {
+				trees = append(trees, root)
+				for _, dir := range subdirs(root) {
+					set[dir] = true
+				}
+			}
+			matches, _ := glob.Expand(pattern)
+			for _, match := range matches {
+				set[filepath.Dir(match)] = true
+			}
+		}
+	}
+	for _, path := range flagEnvFiles {
+		if abs, err := filepath.Abs(path); err == nil {
+			set[filepath.Dir(abs)] = true
+		}
+	}
+
+	for dir := range set {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	return dirs, trees
+}
+
+// subdirs returns root and every directory below it. Unreadable
+// directories are skipped.
+func subdirs(root string) []string {
+	var dirs []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if d != nil && d.IsDir() && path != root {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	return dirs
+}
+
+// staticDir returns the directory of pattern before its first wildcard.
+func staticDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for glob.HasMeta(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// relevant reports whether a change to path can affect the deploy: a
+// manifest, project config, or env file, or one of the current
+// transformations' code files. Editor swap and backup files are ignored.
+func (w *deployWatcher) relevant(path string) bool {
+	path = filepath.Clean(path)
+	switch base := filepath.Base(path); {
+	case base == "hookdeck.jsonc" || base == "hookdeck.json",
+		base == "hookdeck.project.jsonc" || base == "hookdeck.project.json",
+		base == ".env" || strings.HasPrefix(base, ".env."):
+		return true
+	}
+	for _, envFile := range flagEnvFiles {
+		if abs, err := filepath.Abs(envFile); err == nil && abs == path {
+			return true
+		}
+	}
+	for _, tr := range w.resolved.Input.Transformations {
+		if codeFileMatches(tr.CodeFile, tr.CodeFiles, w.resolved.Dir, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// display returns path relative to the current directory when possible.
+func (w *deployWatcher) display(path string) string {
+	if cwd, err := os.Getwd(); err == nil {
+		return displayPath(cwd, path)
+	}
+	return path
+}
+
+// watchLogf prints a timestamped deploy --watch log line to stderr.
+func watchLogf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func TestWatchDirs_RecursivePattern(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"hookdeck.jsonc":           "{}",
+		"src/a.js":                 "",
+		"src/lib/b.js":             "",
+		"src/lib/deep/empty/.keep": "",
+		"other/c.js":               "",
+	})
+	resolved := &resolvedInput{
+		Input: &deploy.DeployInput{
+			Transformations: []*manifest.TransformationConfig{{Name: "t", CodeFile: "src/**/*.js"}},
+		},
+		Dir: dir,
+	}
+
+	dirs, trees := watchDirs(resolved)
+	want := []string{
+		dir,
+		filepath.Join(dir, "src"),
+		filepath.Join(dir, "src", "lib"),
+		filepath.Join(dir, "src", "lib", "deep"),
+		filepath.Join(dir, "src", "lib", "deep", "empty"),
+	}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dirs = %v, want %v", dirs, want)
+	}
+	if want := []string{filepath.Join(dir, "src")}; !reflect.DeepEqual(trees, want) {
+		t.Errorf("trees = %v, want %v", trees, want)
+	}
+}
+
+func TestWatchDirs_SingleLevelPattern(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"src/a.js":     "",
+		"src/lib/b.js": "",
+	})
+	resolved := &resolvedInput{
+		Input: &deploy.DeployInput{
+			Transformations: []*manifest.TransformationConfig{{Name: "t", CodeFile: "src/*.js"}},
+		},
+		Dir: dir,
+	}
+
+	dirs, trees := watchDirs(resolved)
+	if want := []string{dir, filepath.Join(dir, "src")}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("dirs = %v, want %v", dirs, want)
+	}
+	if len(trees) != 0 {
+		t.Errorf("trees = %v, want none", trees)
+	}
+}
+
+func TestDeployWatcher_WatchesNewSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"src/a.js": ""})
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fw.Close()
+	w := &deployWatcher{watcher: fw}
+	w.watch(&resolvedInput{
+		Input: &deploy.DeployInput{
+			Transformations: []*manifest.TransformationConfig{{Name: "t", CodeFile: "src/**/*.js"}},
+		},
+		Dir: dir,
+	})
+
+	nested := filepath.Join(dir, "src", "lib", "deep")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	w.watchNewDir(filepath.Join(dir, "src", "lib"))
+	w.watchNewDir(filepath.Join(dir, "elsewhere"))
+
+	watched := fw.WatchList()
+	for _, want := range []string{filepath.Join(dir, "src", "lib"), nested} {
+		found := false
+		for _, got := range watched {
+			if got == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s not watched; watch list %v", want, watched)
+		}
+	}
+
+	// A file written in the new directory is reported and relevant.
+	path := filepath.Join(nested, "b.js")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-fw.Events:
+			if event.Name == path {
+				if !w.relevant(event.Name) {
+					t.Errorf("%s not relevant", path)
+				}
+				return
+			}
+		case err := <-fw.Errors:
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatalf("no event for %s", path)
+		}
+	}
+}
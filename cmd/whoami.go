@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/credentials"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/project"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Show which credentials would be used",
+	Long: `Whoami resolves credentials the same way deploy, drift, and status do and
+prints where they came from (environment variable or config file and profile)
+along with the project ID. The API key is always masked.`,
+	Args: cobra.NoArgs,
+	RunE: runWhoami,
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
+
+func runWhoami(cmd *cobra.Command, args []string) error {
+	profileName := flagProfile
+	if profileName == "" && flagEnv != "" && (flagProject != "" || (flagFile == "" && projectFileExists())) {
+		projectPath, err := resolveProjectPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := project.LoadProjectConfig(projectPath)
+		if err != nil {
+			return fmt.Errorf("loading project config: %w", err)
+		}
+		profileName = profileForEnv(cfg, flagEnv)
+	}
+
+	creds, source, err := credentials.ResolveWithSource(profileName)
+	if err != nil {
+		return fmt.Errorf("resolving credentials: %w", err)
+	}
+
+	projectID := creds.ProjectID
+	if projectID == "" {
+		projectID = "(none, API key is project-scoped)"
+	}
+
+	fmt.Printf("Source:     %s\n", source)
+	fmt.Printf("API key:    %s\n", credentials.MaskAPIKey(creds.APIKey))
+	fmt.Printf("Project ID: %s\n", projectID)
+	return nil
+}
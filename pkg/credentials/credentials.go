@@ -1,9 +1,12 @@
 package credentials
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -16,53 +19,298 @@ type Credentials struct {
 
 // Resolve finds credentials using this priority:
 //  1. HOOKDECK_API_KEY environment variable
-//  2. Named profile from ~/.config/hookdeck/config.toml
-//  3. Default profile from config.toml
+//  2. File set by SetAPIKeyFile, else the one named by the
+//     HOOKDECK_API_KEY_FILE environment variable
+//  3. Named profile from the config file returned by ActiveConfigPath
+//  4. Default profile from that config file
 func Resolve(profileName string) (*Credentials, error) {
+	creds, _, err := ResolveWithSource(profileName)
+	return creds, err
+}
+
+// ResolveWithSource resolves credentials like Resolve and additionally returns
+// a human-readable description of where they came from (the environment
+// variable, or the profile and config file that supplied the API key).
+func ResolveWithSource(profileName string) (*Credentials, string, error) {
 	if key := os.Getenv("HOOKDECK_API_KEY"); key != "" {
-		return &Credentials{APIKey: key}, nil
+		return &Credentials{APIKey: key}, "environment variable HOOKDECK_API_KEY", nil
+	}
+	if path, origin := apiKeyFilePath(); path != "" {
+		key, err := readAPIKeyFile(path)
+		if err != nil {
+			return nil, "", err
+		}
+		return &Credentials{APIKey: key}, fmt.Sprintf("file %s (%s)", path, origin), nil
 	}
 
-	configPath := getConfigPath()
+	if path, origin := ExplicitConfigPath(); path != "" {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil, "", fmt.Errorf("config file %s (%s) does not exist", path, origin)
+		} else if err != nil {
+			return nil, "", fmt.Errorf("config file %s (%s): %w", path, origin, err)
+		}
+	}
+
+	configPath := ActiveConfigPath()
 	if configPath == "" {
-		return nil, fmt.Errorf("no credentials found: set HOOKDECK_API_KEY or run 'hookdeck login'")
+		return nil, "", fmt.Errorf("no credentials found: set HOOKDECK_API_KEY or run 'hookdeck-deploy login'")
 	}
 
-	creds, err := loadFromTOML(configPath, profileName)
+	creds, resolvedProfile, err := loadFromTOML(configPath, profileName)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	if creds.APIKey == "" {
-		return nil, fmt.Errorf("no API key found in profile '%s' at %s", profileName, configPath)
+		return nil, "", fmt.Errorf("no API key found in profile '%s' at %s", resolvedProfile, configPath)
+	}
+	return creds, fmt.Sprintf("profile '%s' in %s", resolvedProfile, configPath), nil
+}
+
+// apiKeyFileOverride is the API key file set by SetAPIKeyFile.
+var apiKeyFileOverride string
+
+// SetAPIKeyFile makes credentials read the API key from path, taking
+// precedence over HOOKDECK_API_KEY_FILE but not HOOKDECK_API_KEY. An empty
+// path clears the override. It backs the --api-key-file flag.
+func SetAPIKeyFile(path string) {
+	apiKeyFileOverride = path
+}
+
+// apiKeyFilePath returns the API key file set by SetAPIKeyFile or, failing
+// that, HOOKDECK_API_KEY_FILE, along with where it was set.
+func apiKeyFilePath() (path, origin string) {
+	if apiKeyFileOverride != "" {
+		return apiKeyFileOverride, "--api-key-file"
 	}
-	return creds, nil
+	if path := os.Getenv("HOOKDECK_API_KEY_FILE"); path != "" {
+		return path, "HOOKDECK_API_KEY_FILE"
+	}
+	return "", ""
 }
 
-func getConfigPath() string {
-	if _, err := os.Stat(".hookdeck/config.toml"); err == nil {
-		return ".hookdeck/config.toml"
+// readAPIKeyFile reads an API key from path, trimming surrounding whitespace
+// such as the trailing newline of a mounted secret.
+func readAPIKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading API key file: %w", err)
 	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", path)
+	}
+	return key, nil
+}
 
-	home, err := os.UserHomeDir()
+// MaskAPIKey hides all but the last four characters of an API key so it can
+// be shown in output without leaking the secret.
+func MaskAPIKey(key string) string {
+	if len(key) <= 4 {
+		return strings.Repeat("*", len(key))
+	}
+	return strings.Repeat("*", len(key)-4) + key[len(key)-4:]
+}
+
+// LocalConfigPath is the project-local config file. When it exists it is
+// used instead of the global config file.
+const LocalConfigPath = ".hookdeck/config.toml"
+
+// ConfigEnvVar names the environment variable that points credential
+// resolution at a specific config file.
+const ConfigEnvVar = "HOOKDECK_CONFIG"
+
+// configPathOverride is the config file set by SetConfigPath.
+var configPathOverride string
+
+// SetConfigPath makes credentials resolve from path, taking precedence over
+// ConfigEnvVar and the local and global config files. An empty path clears
+// the override. It backs the --config flag.
+func SetConfigPath(path string) {
+	configPathOverride = path
+}
+
+// ExplicitConfigPath returns the config file set by SetConfigPath or, failing
+// that, ConfigEnvVar, along with a description of where it was set. Both are
+// "" when neither is set.
+func ExplicitConfigPath() (path, origin string) {
+	if configPathOverride != "" {
+		return configPathOverride, "--config"
+	}
+	if path := os.Getenv(ConfigEnvVar); path != "" {
+		return path, ConfigEnvVar
+	}
+	return "", ""
+}
+
+// ActiveConfigPath returns the config file credentials are resolved from:
+// the ExplicitConfigPath if one is set, whether or not it exists; else
+// LocalConfigPath if it exists, else the global config file if it exists,
+// else "".
+func ActiveConfigPath() string {
+	if path, _ := ExplicitConfigPath(); path != "" {
+		return path
+	}
+	if _, err := os.Stat(LocalConfigPath); err == nil {
+		return LocalConfigPath
+	}
+
+	globalPath, err := GlobalConfigPath()
 	if err != nil {
 		return ""
 	}
-	globalPath := filepath.Join(home, ".config", "hookdeck", "config.toml")
 	if _, err := os.Stat(globalPath); err == nil {
 		return globalPath
 	}
 	return ""
 }
 
-func loadFromTOML(path string, profileName string) (*Credentials, error) {
+// WriteConfigPath returns the config file login and logout change: the
+// ExplicitConfigPath if one is set, else the global config file.
+func WriteConfigPath() (string, error) {
+	if path, _ := ExplicitConfigPath(); path != "" {
+		return path, nil
+	}
+	return GlobalConfigPath()
+}
+
+// GlobalConfigPath returns the path of the global config file,
+// ~/.config/hookdeck/config.toml, whether or not it exists.
+func GlobalConfigPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("finding home directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "hookdeck", "config.toml"), nil
+}
+
+// ProfileInfo describes a profile in a config file without its API key.
+type ProfileInfo struct {
+	Name string
+	// Path is the config file the profile was read from.
+	Path string
+	// Default is set for the profile used when no profile is named.
+	Default bool
+	// MaskedAPIKey is the API key as returned by MaskAPIKey, or "" if the
+	// profile has none.
+	MaskedAPIKey string
+	ProjectID    string
+}
+
+// ListProfiles returns the profiles in the config file at path, sorted by
+// name. A missing file yields no profiles.
+func ListProfiles(path string) ([]ProfileInfo, error) {
+	raw, err := readRawConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	defaultName, ok := raw["profile"].(string)
+	if !ok {
+		defaultName = "default"
+	}
+
+	var profiles []ProfileInfo
+	for name, section := range raw {
+		profileMap, ok := section.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		info := ProfileInfo{Name: name, Path: path, Default: name == defaultName}
+		if key, ok := profileMap["api_key"].(string); ok && key != "" {
+			info.MaskedAPIKey = MaskAPIKey(key)
+		}
+		if pid, ok := profileMap["project_id"].(string); ok {
+			info.ProjectID = pid
+		}
+		profiles = append(profiles, info)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+	return profiles, nil
+}
+
+// SaveProfile writes creds as profileName into the config file at path,
+// creating the file and its directory if needed. Other profiles and keys are
+// preserved. If the file has no default profile yet, profileName becomes it.
+func SaveProfile(path, profileName string, creds *Credentials) error {
+	raw, err := readRawConfig(path)
+	if err != nil {
+		return err
+	}
+
+	section := map[string]interface{}{"api_key": creds.APIKey}
+	if creds.ProjectID != "" {
+		section["project_id"] = creds.ProjectID
+	}
+	raw[profileName] = section
+	if _, ok := raw["profile"]; !ok {
+		raw["profile"] = profileName
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("creating config directory: %w", err)
+	}
+	return writeRawConfig(path, raw)
+}
+
+// RemoveProfile deletes profileName from the config file at path. If it was
+// the default profile, the default is cleared as well.
+func RemoveProfile(path, profileName string) error {
+	raw, err := readRawConfig(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := raw[profileName].(map[string]interface{}); !ok {
+		return fmt.Errorf("profile '%s' not found in %s", profileName, path)
+	}
+
+	delete(raw, profileName)
+	if def, ok := raw["profile"].(string); ok && def == profileName {
+		delete(raw, "profile")
+	}
+	return writeRawConfig(path, raw)
+}
+
+// readRawConfig parses the config file at path into a generic map. A missing
+// file yields an empty map.
+func readRawConfig(path string) (map[string]interface{}, error) {
+	raw := make(map[string]interface{})
 	data, err := os.ReadFile(path)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return raw, nil
+		}
 		return nil, fmt.Errorf("reading config: %w", err)
 	}
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	return raw, nil
+}
+
+// writeRawConfig encodes raw as TOML to path with owner-only permissions,
+// since the file holds API keys.
+func writeRawConfig(path string, raw map[string]interface{}) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return fmt.Errorf("encoding config: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("writing config: %w", err)
+	}
+	return nil
+}
+
+// loadFromTOML reads the named profile (or the file's default profile when
+// profileName is empty) and returns its credentials along with the profile
+// name that was actually used.
+func loadFromTOML(path string, profileName string) (*Credentials, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("reading config: %w", err)
+	}
 
 	var raw map[string]interface{}
 	if err := toml.Unmarshal(data, &raw); err != nil {
-		return nil, fmt.Errorf("parsing config: %w", err)
+		return nil, "", fmt.Errorf("parsing config: %w", err)
 	}
 
 	if profileName == "" {
@@ -75,12 +323,12 @@ func loadFromTOML(path string, profileName string) (*Credentials, error) {
 
 	section, ok := raw[profileName]
 	if !ok {
-		return nil, fmt.Errorf("profile '%s' not found in %s", profileName, path)
+		return nil, "", fmt.Errorf("profile '%s' not found in %s", profileName, path)
 	}
 
 	profileMap, ok := section.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("profile '%s' is not a valid section", profileName)
+		return nil, "", fmt.Errorf("profile '%s' is not a valid section", profileName)
 	}
 
 	creds := &Credentials{}
@@ -90,5 +338,5 @@ func loadFromTOML(path string, profileName string) (*Credentials, error) {
 	if pid, ok := profileMap["project_id"].(string); ok {
 		creds.ProjectID = pid
 	}
-	return creds, nil
+	return creds, profileName, nil
 }
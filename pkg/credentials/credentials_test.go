@@ -3,6 +3,7 @@ package credentials
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -159,3 +160,398 @@ api_key = "local-key"
 		t.Errorf("expected 'local-key', got '%s'", creds.APIKey)
 	}
 }
+
+func TestResolveWithSource_EnvVar(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "env-key-123")
+
+	_, source, err := ResolveWithSource("")
+	if err != nil {
+		t.Fatalf("ResolveWithSource failed: %v", err)
+	}
+	if source != "environment variable HOOKDECK_API_KEY" {
+		t.Errorf("unexpected source: %s", source)
+	}
+}
+
+func TestResolveWithSource_ReportsProfileAndFile(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+
+	tmpDir := t.TempDir()
+	configDir := filepath.Join(tmpDir, ".config", "hookdeck")
+	os.MkdirAll(configDir, 0o755)
+	configPath := filepath.Join(configDir, "config.toml")
+	os.WriteFile(configPath, []byte(`
+profile = "production"
+
+[production]
+api_key = "prod-key-456"
+project_id = "proj-456"
+`), 0o644)
+
+	t.Setenv("HOME", tmpDir)
+	origDir, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(origDir) })
+	os.Chdir(t.TempDir())
+
+	creds, source, err := ResolveWithSource("")
+	if err != nil {
+		t.Fatalf("ResolveWithSource failed: %v", err)
+	}
+	if creds.ProjectID != "proj-456" {
+		t.Errorf("expected 'proj-456', got '%s'", creds.ProjectID)
+	}
+	expected := "profile 'production' in " + configPath
+	if source != expected {
+		t.Errorf("expected source %q, got %q", expected, source)
+	}
+}
+
+func TestMaskAPIKey(t *testing.T) {
+	tests := []struct {
+		key, want string
+	}{
+		{"hk_abcdef1234", "*********1234"},
+		{"1234", "****"},
+		{"ab", "**"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := MaskAPIKey(tt.key); got != tt.want {
+			t.Errorf("MaskAPIKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSaveProfile_CreatesFileAndResolves(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	origDir, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(origDir) })
+	os.Chdir(t.TempDir())
+
+	path, err := GlobalConfigPath()
+	if err != nil {
+		t.Fatalf("GlobalConfigPath failed: %v", err)
+	}
+	if err := SaveProfile(path, "staging", &Credentials{APIKey: "stg-key", ProjectID: "prj_1"}); err != nil {
+		t.Fatalf("SaveProfile failed: %v", err)
+	}
+
+	// The first saved profile becomes the default.
+	creds, err := Resolve("")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if creds.APIKey != "stg-key" || creds.ProjectID != "prj_1" {
+		t.Errorf("unexpected credentials: %+v", creds)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected config permissions 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestSaveProfile_PreservesOtherProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+profile = "default"
+
+[default]
+api_key = "default-key"
+`), 0o644)
+
+	if err := SaveProfile(path, "production", &Credentials{APIKey: "prod-key"}); err != nil {
+		t.Fatalf("SaveProfile failed: %v", err)
+	}
+
+	creds, _, err := loadFromTOML(path, "")
+	if err != nil {
+		t.Fatalf("loadFromTOML failed: %v", err)
+	}
+	if creds.APIKey != "default-key" {
+		t.Errorf("expected default profile to be kept, got '%s'", creds.APIKey)
+	}
+	creds, _, err = loadFromTOML(path, "production")
+	if err != nil {
+		t.Fatalf("loadFromTOML failed: %v", err)
+	}
+	if creds.APIKey != "prod-key" {
+		t.Errorf("expected 'prod-key', got '%s'", creds.APIKey)
+	}
+}
+
+func TestRemoveProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+profile = "staging"
+
+[staging]
+api_key = "stg-key"
+
+[production]
+api_key = "prod-key"
+`), 0o644)
+
+	if err := RemoveProfile(path, "staging"); err != nil {
+		t.Fatalf("RemoveProfile failed: %v", err)
+	}
+	if _, _, err := loadFromTOML(path, "staging"); err == nil {
+		t.Error("expected staging profile to be gone")
+	}
+	// The default pointed at the removed profile, so it falls back to "default".
+	if _, _, err := loadFromTOML(path, "production"); err != nil {
+		t.Errorf("expected production profile to remain: %v", err)
+	}
+
+	if err := RemoveProfile(path, "missing"); err == nil {
+		t.Error("expected error removing a missing profile")
+	}
+}
+
+func TestListProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	os.WriteFile(path, []byte(`
+profile = "staging"
+
+[staging]
+api_key = "stg-key-1234"
+project_id = "prj_stg"
+
+[production]
+api_key = "prod-key-5678"
+`), 0o644)
+
+	profiles, err := ListProfiles(path)
+	if err != nil {
+		t.Fatalf("ListProfiles failed: %v", err)
+	}
+	want := []ProfileInfo{
+		{Name: "production", Path: path, MaskedAPIKey: "*********5678"},
+		{Name: "staging", Path: path, Default: true, MaskedAPIKey: "********1234", ProjectID: "prj_stg"},
+	}
+	if len(profiles) != len(want) {
+		t.Fatalf("expected %d profiles, got %+v", len(want), profiles)
+	}
+	for i := range want {
+		if profiles[i] != want[i] {
+			t.Errorf("profile %d = %+v, want %+v", i, profiles[i], want[i])
+		}
+	}
+
+	profiles, err = ListProfiles(filepath.Join(t.TempDir(), "missing.toml"))
+	if err != nil || len(profiles) != 0 {
+		t.Errorf("expected no profiles for a missing file, got %v, %v", profiles, err)
+	}
+}
+
+func TestResolve_APIKeyFile(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	keyPath := filepath.Join(t.TempDir(), "api-key")
+	os.WriteFile(keyPath, []byte("  file-key-456\n"), 0o600)
+	t.Setenv("HOOKDECK_API_KEY_FILE", keyPath)
+
+	creds, source, err := ResolveWithSource("some-profile")
+	if err != nil {
+		t.Fatalf("ResolveWithSource failed: %v", err)
+	}
+	if creds.APIKey != "file-key-456" {
+		t.Errorf("expected trimmed key from file, got '%s'", creds.APIKey)
+	}
+	if !strings.Contains(source, keyPath) {
+		t.Errorf("expected source to name the key file, got '%s'", source)
+	}
+}
+
+func TestResolve_APIKeyFileFlagBeatsEnvVar(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, "env-key")
+	os.WriteFile(envPath, []byte("env-file-key"), 0o600)
+	flagPath := filepath.Join(dir, "flag-key")
+	os.WriteFile(flagPath, []byte("flag-file-key"), 0o600)
+
+	t.Setenv("HOOKDECK_API_KEY_FILE", envPath)
+	SetAPIKeyFile(flagPath)
+	t.Cleanup(func() { SetAPIKeyFile("") })
+
+	creds, source, err := ResolveWithSource("")
+	if err != nil {
+		t.Fatalf("ResolveWithSource failed: %v", err)
+	}
+	if creds.APIKey != "flag-file-key" {
+		t.Errorf("expected 'flag-file-key', got '%s'", creds.APIKey)
+	}
+	if want := "file " + flagPath + " (--api-key-file)"; source != want {
+		t.Errorf("expected source %q, got %q", want, source)
+	}
+	if got := os.Getenv("HOOKDECK_API_KEY_FILE"); got != envPath {
+		t.Errorf("expected HOOKDECK_API_KEY_FILE left unchanged, got %q", got)
+	}
+}
+
+func TestResolve_EnvVarBeatsAPIKeyFile(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "env-key-123")
+	t.Setenv("HOOKDECK_API_KEY_FILE", filepath.Join(t.TempDir(), "does-not-exist"))
+
+	creds, err := Resolve("")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if creds.APIKey != "env-key-123" {
+		t.Errorf("expected env key, got '%s'", creds.APIKey)
+	}
+}
+
+func TestResolve_APIKeyFileErrors(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	dir := t.TempDir()
+
+	t.Setenv("HOOKDECK_API_KEY_FILE", filepath.Join(dir, "missing"))
+	if _, err := Resolve(""); err == nil || !strings.Contains(err.Error(), "reading API key file") {
+		t.Errorf("expected read error for missing file, got %v", err)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	os.WriteFile(empty, []byte("\n"), 0o600)
+	t.Setenv("HOOKDECK_API_KEY_FILE", empty)
+	if _, err := Resolve(""); err == nil || !strings.Contains(err.Error(), "is empty") {
+		t.Errorf("expected empty-file error, got %v", err)
+	}
+}
+
+func TestResolve_ConfigEnvVarOverridesSearchPath(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	t.Setenv("HOOKDECK_API_KEY_FILE", "")
+
+	tmpHome := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpHome, ".config", "hookdeck"), 0o755)
+	os.WriteFile(filepath.Join(tmpHome, ".config", "hookdeck", "config.toml"), []byte(`
+[default]
+api_key = "global-key"
+`), 0o644)
+	t.Setenv("HOME", tmpHome)
+
+	tmpWork := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpWork, ".hookdeck"), 0o755)
+	os.WriteFile(filepath.Join(tmpWork, ".hookdeck", "config.toml"), []byte(`
+[default]
+api_key = "local-key"
+`), 0o644)
+	origDir, _ := os.Getwd()
+	t.Cleanup(func() { os.Chdir(origDir) })
+	os.Chdir(tmpWork)
+
+	customPath := filepath.Join(t.TempDir(), "ci.toml")
+	os.WriteFile(customPath, []byte(`
+[default]
+api_key = "custom-key"
+`), 0o644)
+	t.Setenv("HOOKDECK_CONFIG", customPath)
+
+	creds, source, err := ResolveWithSource("")
+	if err != nil {
+		t.Fatalf("ResolveWithSource failed: %v", err)
+	}
+	if creds.APIKey != "custom-key" {
+		t.Errorf("expected 'custom-key', got '%s'", creds.APIKey)
+	}
+	if !strings.Contains(source, customPath) {
+		t.Errorf("expected source to name the custom config, got '%s'", source)
+	}
+}
+
+func TestResolve_ConfigFlagBeatsEnvVar(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	t.Setenv("HOOKDECK_API_KEY_FILE", "")
+	dir := t.TempDir()
+
+	envPath := filepath.Join(dir, "env.toml")
+	os.WriteFile(envPath, []byte(`
+[default]
+api_key = "env-config-key"
+`), 0o644)
+	flagPath := filepath.Join(dir, "flag.toml")
+	os.WriteFile(flagPath, []byte(`
+[default]
+api_key = "flag-config-key"
+`), 0o644)
+
+	t.Setenv("HOOKDECK_CONFIG", envPath)
+	SetConfigPath(flagPath)
+	t.Cleanup(func() { SetConfigPath("") })
+
+	creds, err := Resolve("")
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	if creds.APIKey != "flag-config-key" {
+		t.Errorf("expected 'flag-config-key', got '%s'", creds.APIKey)
+	}
+	if got := ActiveConfigPath(); got != flagPath {
+		t.Errorf("expected active config %s, got %s", flagPath, got)
+	}
+}
+
+func TestResolve_ExplicitConfigMissing(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	t.Setenv("HOOKDECK_API_KEY_FILE", "")
+	missing := filepath.Join(t.TempDir(), "missing.toml")
+
+	t.Setenv("HOOKDECK_CONFIG", missing)
+	_, err := Resolve("")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") || !strings.Contains(err.Error(), "HOOKDECK_CONFIG") {
+		t.Errorf("expected missing-config error naming HOOKDECK_CONFIG, got %v", err)
+	}
+
+	t.Setenv("HOOKDECK_CONFIG", "")
+	SetConfigPath(missing)
+	t.Cleanup(func() { SetConfigPath("") })
+	_, err = Resolve("")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") || !strings.Contains(err.Error(), "--config") {
+		t.Errorf("expected missing-config error naming --config, got %v", err)
+	}
+}
+
+func TestResolve_ExplicitConfigUnreadable(t *testing.T) {
+	t.Setenv("HOOKDECK_API_KEY", "")
+	t.Setenv("HOOKDECK_API_KEY_FILE", "")
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0o644)
+
+	// A path below a regular file fails to stat without being missing.
+	t.Setenv("HOOKDECK_CONFIG", filepath.Join(file, "config.toml"))
+	_, err := Resolve("")
+	if err == nil || strings.Contains(err.Error(), "does not exist") || !strings.Contains(err.Error(), "HOOKDECK_CONFIG") {
+		t.Errorf("expected a stat error naming HOOKDECK_CONFIG, got %v", err)
+	}
+}
+
+func TestWriteConfigPath(t *testing.T) {
+	tmpHome := t.TempDir()
+	t.Setenv("HOME", tmpHome)
+	t.Setenv("HOOKDECK_CONFIG", "")
+
+	got, err := WriteConfigPath()
+	if err != nil {
+		t.Fatalf("WriteConfigPath failed: %v", err)
+	}
+	if want := filepath.Join(tmpHome, ".config", "hookdeck", "config.toml"); got != want {
+		t.Errorf("expected global config %s, got %s", want, got)
+	}
+
+	t.Setenv("HOOKDECK_CONFIG", "env.toml")
+	if got, _ := WriteConfigPath(); got != "env.toml" {
+		t.Errorf("expected HOOKDECK_CONFIG path, got %s", got)
+	}
+
+	SetConfigPath("flag.toml")
+	t.Cleanup(func() { SetConfigPath("") })
+	if got, _ := WriteConfigPath(); got != "flag.toml" {
+		t.Errorf("expected --config path, got %s", got)
+	}
+}
@@ -4,10 +4,19 @@ package deploy
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/glob"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
 )
 
@@ -65,11 +74,31 @@ type UpsertDestinationResult struct {
 // and name-based references (source.name, destination.name).
 type UpsertConnectionRequest struct {
 	Name          *string                  `json:"name,omitempty"`
+	Description   *string                  `json:"description,omitempty"`
 	SourceID      *string                  `json:"source_id,omitempty"`
 	DestinationID *string                  `json:"destination_id,omitempty"`
 	Source        *ConnectionSourceRef     `json:"source,omitempty"`
 	Destination   *ConnectionDestRef       `json:"destination,omitempty"`
 	Rules         []map[string]interface{} `json:"rules,omitempty"`
+	// Paused is the desired paused state. Hookdeck pauses and unpauses
+	// connections through dedicated endpoints rather than the upsert body,
+	// so it is not serialized; clients apply it after the upsert.
+	Paused *bool `json:"-"`
+}
+
+// MarshalJSON encodes the request, sending "rules": [] when Rules is empty
+// but non-nil. Hookdeck replaces a connection's rules with the array sent and
+// keeps them when rules is omitted, so an explicit empty array is how a
+// deploy removes every rule.
+func (r UpsertConnectionRequest) MarshalJSON() ([]byte, error) {
+	type plain UpsertConnectionRequest
+	if r.Rules == nil || len(r.Rules) > 0 {
+		return json.Marshal(plain(r))
+	}
+	return json.Marshal(struct {
+		plain
+		Rules []map[string]interface{} `json:"rules"`
+	}{plain(r), r.Rules})
 }
 
 // ConnectionSourceRef is a name-based source reference for connection upsert.
@@ -90,9 +119,10 @@ type UpsertConnectionResult struct {
 
 // UpsertTransformationRequest is the payload for upserting a transformation.
 type UpsertTransformationRequest struct {
-	Name string            `json:"name"`
-	Code string            `json:"code"`
-	Env  map[string]string `json:"env,omitempty"`
+	Name        string            `json:"name"`
+	Description *string           `json:"description,omitempty"`
+	Code        string            `json:"code"`
+	Env         map[string]string `json:"env,omitempty"`
 }
 
 // UpsertTransformationResult is the API response after upserting a transformation.
@@ -105,11 +135,16 @@ type UpsertTransformationResult struct {
 // Result types
 // ---------------------------------------------------------------------------
 
+// ActionReplaced is the action reported for a connection deleted and
+// recreated under Options.ReplaceConnections.
+const ActionReplaced = "replaced"
+
 // ResourceResult captures the outcome for a single resource.
 type ResourceResult struct {
 	Name   string `json:"name"`
 	ID     string `json:"id,omitempty"`
-	Action string `json:"action"` // "upserted", "would upsert", "skipped"
+	Action string `json:"action"` // "upserted", "replaced", "would upsert", "skipped", "skipped (disabled)"; dry-run previews also use "new", "would change", "no changes"
+	Reason string `json:"reason,omitempty"`
 }
 
 // Result is the aggregate outcome of a deploy run.
@@ -118,22 +153,159 @@ type Result struct {
 	Transformations []*ResourceResult `json:"transformations,omitempty"`
 	Destinations    []*ResourceResult `json:"destinations,omitempty"`
 	Connections     []*ResourceResult `json:"connections,omitempty"`
+
+	// StartedAt and FinishedAt bracket the Deploy call (see Summary).
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
 }
 
 // DeployInput holds the resolved resource configs to deploy.
 type DeployInput struct {
-	Sources         []*manifest.SourceConfig
-	Destinations    []*manifest.DestinationConfig
-	Transformations []*manifest.TransformationConfig
-	Connections     []*manifest.ConnectionConfig
+	Sources         []*manifest.SourceConfig         `json:"sources,omitempty"`
+	Destinations    []*manifest.DestinationConfig    `json:"destinations,omitempty"`
+	Transformations []*manifest.TransformationConfig `json:"transformations,omitempty"`
+	Connections     []*manifest.ConnectionConfig     `json:"connections,omitempty"`
+
+	// Disabled lists resources excluded by ExcludeDisabled.
+	Disabled []DisabledResource `json:"disabled,omitempty"`
+
+	// External lists placeholders excluded by ExcludeExternal.
+	External []ExternalResource `json:"external,omitempty"`
+
+	// Order, when set, is the sequence Deploy upserts resources in across
+	// kinds (see project.SortDeployInput), so that a resource can depend on
+	// one of a later kind. Resources it doesn't name follow kind by kind.
+	Order []ResourceRef `json:"order,omitempty"`
+}
+
+// Reporter receives progress events as Deploy works through the input.
+// Kinds are "source", "transformation", "destination", and "connection".
+type Reporter interface {
+	// OnResourceStart is called before a resource is upserted (or, in
+	// dry-run mode, before it is recorded).
+	OnResourceStart(kind, name string)
+	// OnResourceDone is called once the resource's result is known.
+	OnResourceDone(kind string, r *ResourceResult)
+}
+
+// UnchangedChecker decides whether a resource already matches its remote
+// state. When it does, the remote ID is returned so that dependents (such as
+// connections referencing a skipped source) can still be wired up by ID.
+type UnchangedChecker interface {
+	SourceUnchanged(ctx context.Context, src *manifest.SourceConfig) (id string, unchanged bool, err error)
+	TransformationUnchanged(ctx context.Context, tr *manifest.TransformationConfig, codeRoot string) (id string, unchanged bool, err error)
+	DestinationUnchanged(ctx context.Context, dst *manifest.DestinationConfig) (id string, unchanged bool, err error)
+	ConnectionUnchanged(ctx context.Context, conn *manifest.ConnectionConfig) (id string, unchanged bool, err error)
+}
+
+// RemoteAuthFetcher looks up a destination's live auth settings for
+// Options.PreserveRemoteAuth. found is false when the destination doesn't
+// exist yet.
+type RemoteAuthFetcher interface {
+	DestinationAuth(ctx context.Context, name string) (authType string, auth map[string]interface{}, found bool, err error)
+}
+
+// ConnectionReplacer finds and deletes live connections for
+// Options.ReplaceConnections.
+type ConnectionReplacer interface {
+	// IncompatibleConnection returns the ID of the live connection named
+	// like conn when it links a different source or destination, which an
+	// upsert can't change, along with a description of the change. It
+	// returns "" when there is no such connection.
+	IncompatibleConnection(ctx context.Context, conn *manifest.ConnectionConfig) (id, reason string, err error)
+	DeleteConnection(ctx context.Context, id string) error
+}
+
+// RemoteSourceTypeFetcher looks up a source's live type for the type change
+// guard (see Options.SourceTypes). found is false when the source doesn't
+// exist yet.
+type RemoteSourceTypeFetcher interface {
+	SourceType(ctx context.Context, name string) (sourceType string, found bool, err error)
+}
+
+// RemoteRulesFetcher looks up a connection's live rules for
+// Options.DeleteOrphanRules. found is false when the connection doesn't
+// exist yet.
+type RemoteRulesFetcher interface {
+	ConnectionRules(ctx context.Context, conn *manifest.ConnectionConfig) (rules []map[string]interface{}, found bool, err error)
 }
 
 // Options controls deploy behaviour.
 type Options struct {
 	DryRun   bool
-	CodeRoot string // base directory for resolving relative code_file paths
+	CodeRoot string   // base directory for resolving relative code_file paths
+	Reporter Reporter // optional; receives per-resource progress events
+
+	// Code supplies transformation code by name, used instead of reading
+	// code files (e.g. when applying a saved Plan).
+	Code map[string]string
+
+	// SkipUnchanged asks Checker before each upsert whether the resource
+	// already matches its remote state, and records unchanged resources
+	// with Action "skipped" instead of upserting them. Ignored in dry-run.
+	SkipUnchanged bool
+	Checker       UnchangedChecker
+
+	// PreserveRemoteAuth fetches each destination's live auth through
+	// AuthFetcher and leaves auth_type and auth out of the upsert when they
+	// already match, so a deploy that doesn't change auth never resends
+	// secrets over ones rotated outside the manifest. Ignored in dry-run.
+	PreserveRemoteAuth bool
+	AuthFetcher        RemoteAuthFetcher
+
+	// DeleteOrphanRules fetches the live rules of each connection that
+	// declares none through RulesFetcher and, when it still has some, sends
+	// an empty rule set so they are removed. Connections that declare rules
+	// always send their full set, which replaces the live one. Ignored in
+	// dry-run.
+	DeleteOrphanRules bool
+	RulesFetcher      RemoteRulesFetcher
+
+	// DumpRequests, when set, receives each upsert request as indented JSON
+	// just before it is sent, for debugging. Every string value is passed
+	// through Redact first, if set. Ignored in dry-run.
+	DumpRequests io.Writer
+	Redact       func(string) string
+
+	// CanonicalRuleOrder sorts each connection's rules, explicit and
+	// shorthand, into CanonicalRuleTypes order before upserting. By default
+	// rules are sent as declared: explicit rules, then the transformations
+	// shorthand, then the filter shorthand. Warnf, if set, is told about
+	// each connection whose explicit rules get reordered.
+	CanonicalRuleOrder bool
+	Warnf              func(format string, args ...interface{})
+
+	// ReplaceConnections asks Replacer, before each connection upsert,
+	// whether a live connection of the same name links a different source
+	// or destination. If so it is deleted and the connection created anew,
+	// reported with ActionReplaced. Any other change is still an in-place
+	// upsert. Ignored in dry-run.
+	ReplaceConnections bool
+	Replacer           ConnectionReplacer
+
+	// SourceTypes, when set, is asked for the live type of every source
+	// that declares one before anything is upserted. Changing a source's
+	// type can make Hookdeck recreate it, so Deploy fails listing each
+	// source whose type would change unless AllowTypeChange is set. Ignored
+	// in dry-run.
+	SourceTypes     RemoteSourceTypeFetcher
+	AllowTypeChange bool
+
+	// MaxCodeSize, when positive, is the largest transformation code in
+	// bytes that Deploy will send. The code of every transformation is
+	// resolved and measured before anything is upserted, so oversized code
+	// fails early with its name and size instead of as an opaque API error.
+	// Also checked in dry-run.
+	MaxCodeSize int
 }
 
+// DefaultMaxCodeSize is the transformation code size limit deploy applies
+// unless told otherwise: 5 MiB. It is this CLI's own default rather than a
+// limit published in the Hookdeck API reference, picked to sit well above
+// any hand-written or bundled transformation; callers can raise it or pass
+// 0 to disable the check if the API accepts larger code.
+const DefaultMaxCodeSize = 5 << 20
+
 // ---------------------------------------------------------------------------
 // Deploy orchestrator
 // ---------------------------------------------------------------------------
@@ -144,13 +316,63 @@ type Options struct {
 //  3. Destinations
 //  4. Connections (references sources, destinations, and optionally transformations)
 //
+// When input.Order is set, resources are upserted in that order instead.
+//
 // In dry-run mode no API calls are made and client may be nil.
+//
+// When ctx is cancelled, Deploy stops before the next resource and returns
+// the partial Result, holding the resources already applied, together with
+// an error wrapping ctx.Err(). A request cut off mid-flight is not included.
 func Deploy(ctx context.Context, client Client, input *DeployInput, opts Options) (*Result, error) {
 	if !opts.DryRun && client == nil {
 		return nil, fmt.Errorf("client must not be nil in live mode")
 	}
+	skipUnchanged := opts.SkipUnchanged && !opts.DryRun
+	if skipUnchanged && opts.Checker == nil {
+		return nil, fmt.Errorf("checker must not be nil when skipping unchanged resources")
+	}
+	preserveAuth := opts.PreserveRemoteAuth && !opts.DryRun
+	if preserveAuth && opts.AuthFetcher == nil {
+		return nil, fmt.Errorf("auth fetcher must not be nil when preserving remote auth")
+	}
+	deleteOrphanRules := opts.DeleteOrphanRules && !opts.DryRun
+	if deleteOrphanRules && opts.RulesFetcher == nil {
+		return nil, fmt.Errorf("rules fetcher must not be nil when deleting orphan rules")
+	}
+	replaceConnections := opts.ReplaceConnections && !opts.DryRun
+	if replaceConnections && opts.Replacer == nil {
+		return nil, fmt.Errorf("replacer must not be nil when replacing connections")
+	}
+
+	if opts.MaxCodeSize > 0 {
+		// The measured code is reused for the upserts below.
+		code, err := checkCodeSize(input, opts)
+		if err != nil {
+			return nil, err
+		}
+		opts.Code = code
+	}
+	if !opts.DryRun {
+		if err := checkTransformationCode(input, opts); err != nil {
+			return nil, err
+		}
+		if opts.SourceTypes != nil && !opts.AllowTypeChange {
+			if err := checkSourceTypes(ctx, input, opts.SourceTypes); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	result := &Result{}
+	result := &Result{StartedAt: time.Now()}
+	// fail returns err, plus the partial result when ctx is done, so callers
+	// can report what was applied before the deploy was interrupted.
+	fail := func(err error) (*Result, error) {
+		if ctx.Err() == nil {
+			return nil, err
+		}
+		result.FinishedAt = time.Now()
+		return result, err
+	}
 
 	// Track IDs resolved from earlier upserts so that the connection step can
 	// reference them by name.
@@ -158,76 +380,362 @@ func Deploy(ctx context.Context, client Client, input *DeployInput, opts Options
 	destinationIDs := make(map[string]string)
 	transformationIDs := make(map[string]string)
 
-	// 1. Sources
-	for _, src := range input.Sources {
-		if opts.DryRun {
-			result.Sources = append(result.Sources, &ResourceResult{Name: src.Name, Action: "would upsert"})
-		} else {
-			req := buildSourceRequest(src)
-			res, err := client.UpsertSource(ctx, req)
+	deploySource := func(src *manifest.SourceConfig) (*ResourceResult, error) {
+		if skipUnchanged {
+			id, unchanged, err := opts.Checker.SourceUnchanged(ctx, src)
 			if err != nil {
-				return nil, fmt.Errorf("upserting source %q: %w", src.Name, err)
+				return nil, fmt.Errorf("checking source %q: %w", src.Name, err)
 			}
-			sourceIDs[src.Name] = res.ID
-			result.Sources = append(result.Sources, &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"})
+			if unchanged {
+				sourceIDs[src.Name] = id
+				return &ResourceResult{Name: src.Name, ID: id, Action: "skipped"}, nil
+			}
+		}
+		req := buildSourceRequest(src)
+		dumpRequest(opts, "source", src.Name, req)
+		res, err := client.UpsertSource(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("upserting source %q: %w", src.Name, err)
 		}
+		sourceIDs[src.Name] = res.ID
+		return &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"}, nil
 	}
 
-	// 2. Transformations (before connections, because connection rules reference them)
-	for _, tr := range input.Transformations {
-		if opts.DryRun {
-			result.Transformations = append(result.Transformations, &ResourceResult{Name: tr.Name, Action: "would upsert"})
-		} else {
-			code, err := resolveCode(tr, opts.CodeRoot)
+	deployTransformation := func(tr *manifest.TransformationConfig) (*ResourceResult, error) {
+		if skipUnchanged {
+			id, unchanged, err := opts.Checker.TransformationUnchanged(ctx, tr, opts.CodeRoot)
 			if err != nil {
-				return nil, fmt.Errorf("resolving transformation code for %q: %w", tr.Name, err)
+				return nil, fmt.Errorf("checking transformation %q: %w", tr.Name, err)
+			}
+			if unchanged {
+				transformationIDs[tr.Name] = id
+				return &ResourceResult{Name: tr.Name, ID: id, Action: "skipped"}, nil
 			}
-			req := buildTransformationRequest(tr, code)
-			res, err := client.UpsertTransformation(ctx, req)
+		}
+		code, ok := opts.Code[tr.Name]
+		if !ok {
+			var err error
+			code, err = resolveCode(tr, opts.CodeRoot)
 			if err != nil {
-				return nil, fmt.Errorf("upserting transformation %q: %w", tr.Name, err)
+				return nil, fmt.Errorf("resolving transformation code for %q: %w", tr.Name, err)
 			}
-			transformationIDs[tr.Name] = res.ID
-			result.Transformations = append(result.Transformations, &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"})
 		}
+		req := buildTransformationRequest(tr, code)
+		dumpRequest(opts, "transformation", tr.Name, req)
+		res, err := client.UpsertTransformation(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("upserting transformation %q: %w", tr.Name, err)
+		}
+		transformationIDs[tr.Name] = res.ID
+		return &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"}, nil
 	}
 
-	// 3. Destinations
-	for _, dst := range input.Destinations {
-		if opts.DryRun {
-			result.Destinations = append(result.Destinations, &ResourceResult{Name: dst.Name, Action: "would upsert"})
-		} else {
-			req := buildDestinationRequest(dst)
-			res, err := client.UpsertDestination(ctx, req)
+	deployDestination := func(dst *manifest.DestinationConfig) (*ResourceResult, error) {
+		if skipUnchanged {
+			id, unchanged, err := opts.Checker.DestinationUnchanged(ctx, dst)
 			if err != nil {
-				return nil, fmt.Errorf("upserting destination %q: %w", dst.Name, err)
+				return nil, fmt.Errorf("checking destination %q: %w", dst.Name, err)
+			}
+			if unchanged {
+				destinationIDs[dst.Name] = id
+				return &ResourceResult{Name: dst.Name, ID: id, Action: "skipped"}, nil
 			}
-			destinationIDs[dst.Name] = res.ID
-			result.Destinations = append(result.Destinations, &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"})
 		}
+		req := buildDestinationRequest(dst)
+		if preserveAuth {
+			if err := omitUnchangedAuth(ctx, opts.AuthFetcher, req); err != nil {
+				return nil, fmt.Errorf("fetching auth for destination %q: %w", dst.Name, err)
+			}
+		}
+		dumpRequest(opts, "destination", dst.Name, req)
+		res, err := client.UpsertDestination(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("upserting destination %q: %w", dst.Name, err)
+		}
+		destinationIDs[dst.Name] = res.ID
+		return &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"}, nil
 	}
 
-	// 4. Connections
-	for _, conn := range input.Connections {
-		if opts.DryRun {
-			result.Connections = append(result.Connections, &ResourceResult{Name: conn.Name, Action: "would upsert"})
-		} else {
-			// Look up resolved IDs by name for this connection
-			sourceID := sourceIDs[conn.Source]
-			destinationID := destinationIDs[conn.Destination]
+	deployConnection := func(conn *manifest.ConnectionConfig) (*ResourceResult, error) {
+		// A live connection linking other endpoints is deleted and
+		// recreated, so there is nothing to compare or clean up first.
+		var replaceID, replaceReason string
+		if replaceConnections {
+			var err error
+			replaceID, replaceReason, err = opts.Replacer.IncompatibleConnection(ctx, conn)
+			if err != nil {
+				return nil, fmt.Errorf("checking connection %q for replacement: %w", conn.Name, err)
+			}
+		}
+		// Look up resolved IDs by name for this connection
+		sourceID := sourceIDs[conn.Source]
+		destinationID := destinationIDs[conn.Destination]
 
-			req := buildConnectionRequest(conn, sourceID, destinationID, transformationIDs)
-			res, err := client.UpsertConnection(ctx, req)
+		req := buildConnectionRequest(conn, sourceID, destinationID, transformationIDs)
+		// Rules the live connection has but the manifest no longer
+		// declares; they would survive an upsert that omits rules.
+		orphanRules := 0
+		if deleteOrphanRules && replaceID == "" && len(req.Rules) == 0 {
+			remote, found, err := opts.RulesFetcher.ConnectionRules(ctx, conn)
+			if err != nil {
+				return nil, fmt.Errorf("fetching rules for connection %q: %w", conn.Name, err)
+			}
+			if found {
+				orphanRules = len(remote)
+			}
+		}
+		if skipUnchanged && orphanRules == 0 && replaceID == "" {
+			id, unchanged, err := opts.Checker.ConnectionUnchanged(ctx, conn)
 			if err != nil {
-				return nil, fmt.Errorf("upserting connection %q: %w", conn.Name, err)
+				return nil, fmt.Errorf("checking connection %q: %w", conn.Name, err)
+			}
+			if unchanged {
+				return &ResourceResult{Name: conn.Name, ID: id, Action: "skipped"}, nil
+			}
+		}
+		if opts.CanonicalRuleOrder {
+			if moved := reorderedRule(conn.Rules); moved != "" && opts.Warnf != nil {
+				opts.Warnf("connection %q: reordering rules into canonical order (%s)", conn.Name, moved)
+			}
+			sortRules(req.Rules)
+		}
+		if orphanRules > 0 {
+			req.Rules = []map[string]interface{}{}
+		}
+		dumpRequest(opts, "connection", conn.Name, req)
+		if replaceID != "" {
+			if err := opts.Replacer.DeleteConnection(ctx, replaceID); err != nil {
+				return nil, fmt.Errorf("deleting connection %q (%s) for replacement: %w", conn.Name, replaceID, err)
+			}
+		}
+		res, err := client.UpsertConnection(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("upserting connection %q: %w", conn.Name, err)
+		}
+		r := &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"}
+		if orphanRules > 0 {
+			r.Reason = fmt.Sprintf("removed %d rule(s) not in the manifest", orphanRules)
+		}
+		if replaceID != "" {
+			r.Action = ActionReplaced
+			r.Reason = replaceReason
+		}
+		return r, nil
+	}
+
+	// Disabled resources are reported before the first resource of their
+	// kind or a later one.
+	reported := 0
+	reportDisabled := func(kind string) {
+		for ; reported < len(deployKinds) && reported <= kindIndex(kind); reported++ {
+			k := deployKinds[reported]
+			for _, r := range input.DisabledResults(k) {
+				results := result.list(k)
+				*results = append(*results, r)
+				reportDone(opts.Reporter, k, r)
 			}
-			result.Connections = append(result.Connections, &ResourceResult{Name: res.Name, ID: res.ID, Action: "upserted"})
 		}
 	}
 
+	// Sources, transformations, destinations, and connections, in that
+	// order unless input.Order says otherwise. Transformations go before
+	// connections because connection rules reference them.
+	for _, st := range input.steps() {
+		reportDisabled(st.kind)
+		if err := ctx.Err(); err != nil {
+			return fail(fmt.Errorf("deploy interrupted before %s %q: %w", st.kind, st.name, err))
+		}
+		reportStart(opts.Reporter, st.kind, st.name)
+		var r *ResourceResult
+		var err error
+		switch {
+		case opts.DryRun:
+			r = &ResourceResult{Name: st.name, Action: "would upsert"}
+		case st.kind == "source":
+			r, err = deploySource(input.Sources[st.index])
+		case st.kind == "transformation":
+			r, err = deployTransformation(input.Transformations[st.index])
+		case st.kind == "destination":
+			r, err = deployDestination(input.Destinations[st.index])
+		case st.kind == "connection":
+			r, err = deployConnection(input.Connections[st.index])
+		}
+		if err != nil {
+			return fail(err)
+		}
+		results := result.list(st.kind)
+		*results = append(*results, r)
+		reportDone(opts.Reporter, st.kind, r)
+	}
+	reportDisabled("connection")
+
+	result.FinishedAt = time.Now()
 	return result, nil
 }
 
+// CheckReferences verifies that every connection in the input references
+// sources, destinations, and transformations that are themselves part of the
+// input or listed in input.External. It mirrors project.Registry.Validate for
+// single-manifest deploys and returns all problems found rather than stopping
+// at the first.
+func CheckReferences(input *DeployInput) []error {
+	sources := make(map[string]bool)
+	for _, src := range input.Sources {
+		sources[src.Name] = true
+	}
+	destinations := make(map[string]bool)
+	for _, dst := range input.Destinations {
+		destinations[dst.Name] = true
+	}
+	transformations := make(map[string]bool)
+	for _, tr := range input.Transformations {
+		transformations[tr.Name] = true
+	}
+
+	var errs []error
+	for _, c := range input.Connections {
+		if c.Source != "" && !sources[c.Source] && !input.isExternal("source", c.Source) {
+			errs = append(errs, fmt.Errorf("connection %q references undefined source %q", c.Name, c.Source))
+		}
+		if c.Destination != "" && !destinations[c.Destination] && !input.isExternal("destination", c.Destination) {
+			errs = append(errs, fmt.Errorf("connection %q references undefined destination %q", c.Name, c.Destination))
+		}
+		for _, trName := range c.Transformations {
+			if !transformations[trName] {
+				errs = append(errs, fmt.Errorf("connection %q references undefined transformation %q", c.Name, trName))
+			}
+		}
+	}
+	return errs
+}
+
+// checkTransformationCode verifies, before anything is upserted, that every
+// transformation has code to send: an entry in opts.Code or a code_file or
+// code_files to read. Each error names the transformation and the connections
+// that reference it, which the late failure from resolveCode could not.
+func checkTransformationCode(input *DeployInput, opts Options) error {
+	var errs []error
+	for _, tr := range input.Transformations {
+		if _, ok := opts.Code[tr.Name]; ok || tr.CodeFile != "" || len(tr.CodeFiles) > 0 {
+			continue
+		}
+		var refs []string
+		for _, conn := range input.Connections {
+			for _, name := range manifest.TransformationRefs(conn) {
+				if name == tr.Name {
+					connName := conn.Name
+					if connName == "" {
+						connName = manifest.DefaultConnectionName(conn)
+					}
+					refs = append(refs, strconv.Quote(connName))
+				}
+			}
+		}
+		if len(refs) == 0 {
+			errs = append(errs, fmt.Errorf("transformation %q has no code_file or code_files", tr.Name))
+			continue
+		}
+		noun := "connection"
+		if len(refs) > 1 {
+			noun = "connections"
+		}
+		errs = append(errs, fmt.Errorf("transformation %q has no code_file or code_files (referenced by %s %s)",
+			tr.Name, noun, strings.Join(refs, ", ")))
+	}
+	return errors.Join(errs...)
+}
+
+// ErrCodeTooLarge is wrapped by the error Deploy returns when a
+// transformation's code is larger than MaxCodeSize.
+var ErrCodeTooLarge = errors.New("transformation code too large")
+
+// CheckCodeSize returns an error wrapping ErrCodeTooLarge if any
+// transformation in input has code larger than opts.MaxCodeSize, as Deploy
+// does before upserting anything. It returns nil when MaxCodeSize isn't
+// positive.
+func CheckCodeSize(input *DeployInput, opts Options) error {
+	if opts.MaxCodeSize <= 0 {
+		return nil
+	}
+	_, err := checkCodeSize(input, opts)
+	return err
+}
+
+// checkCodeSize resolves the code of every transformation in input, taking
+// it from opts.Code when present, and returns it by name. Code larger than
+// opts.MaxCodeSize bytes is reported in an error wrapping ErrCodeTooLarge.
+func checkCodeSize(input *DeployInput, opts Options) (map[string]string, error) {
+	resolved := make(map[string]string, len(input.Transformations))
+	var errs, tooLarge []error
+	for _, tr := range input.Transformations {
+		code, ok := opts.Code[tr.Name]
+		if !ok {
+			if tr.CodeFile == "" && len(tr.CodeFiles) == 0 {
+				// Left to checkTransformationCode.
+				continue
+			}
+			var err error
+			code, err = resolveCode(tr, opts.CodeRoot)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("resolving transformation code for %q: %w", tr.Name, err))
+				continue
+			}
+		}
+		resolved[tr.Name] = code
+		if len(code) > opts.MaxCodeSize {
+			tooLarge = append(tooLarge, fmt.Errorf("transformation %q code is %d bytes, over the %d-byte limit",
+				tr.Name, len(code), opts.MaxCodeSize))
+		}
+	}
+	if len(tooLarge) > 0 {
+		errs = append(errs, fmt.Errorf("%w: %w", ErrCodeTooLarge, errors.Join(tooLarge...)))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// ErrSourceTypeChange is wrapped by the error Deploy returns when a source's
+// declared type differs from its live one and AllowTypeChange isn't set.
+var ErrSourceTypeChange = errors.New("source type change refused")
+
+// checkSourceTypes returns an error for each source in input whose declared
+// type differs from its live one, wrapped in ErrSourceTypeChange.
+func checkSourceTypes(ctx context.Context, input *DeployInput, fetcher RemoteSourceTypeFetcher) error {
+	var errs []error
+	for _, src := range input.Sources {
+		if src.Type == "" {
+			continue
+		}
+		remote, found, err := fetcher.SourceType(ctx, src.Name)
+		if err != nil {
+			return fmt.Errorf("fetching live type of source %q: %w", src.Name, err)
+		}
+		if found && remote != "" && !strings.EqualFold(remote, src.Type) {
+			errs = append(errs, fmt.Errorf("source %q would change type from %s to %s, which can recreate it with a new ingest URL", src.Name, remote, src.Type))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrSourceTypeChange, errors.Join(errs...))
+}
+
+// reportStart forwards a start event to r if it is non-nil.
+func reportStart(r Reporter, kind, name string) {
+	if r != nil {
+		r.OnResourceStart(kind, name)
+	}
+}
+
+// reportDone forwards a completion event to r if it is non-nil.
+func reportDone(r Reporter, kind string, res *ResourceResult) {
+	if r != nil {
+		r.OnResourceDone(kind, res)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Request builders
 // ---------------------------------------------------------------------------
@@ -243,12 +751,52 @@ func buildSourceRequest(src *manifest.SourceConfig) *UpsertSourceRequest {
 		desc := src.Description
 		req.Description = &desc
 	}
-	if src.Config != nil {
-		req.Config = src.Config
+
+	// Like destinations, shorthand fields are written into config over any
+	// explicit entries for the same keys.
+	config := make(map[string]interface{})
+	for k, v := range src.Config {
+		config[k] = normalizeNumbers(v)
+	}
+	if len(src.AllowedHTTPMethods) > 0 {
+		config["allowed_http_methods"] = src.AllowedHTTPMethods
+	}
+	if src.CustomResponse != nil {
+		config["custom_response"] = map[string]interface{}{
+			"content_type": src.CustomResponse.ContentType,
+			"body":         src.CustomResponse.Body,
+		}
+	}
+	if len(config) > 0 {
+		req.Config = config
 	}
 	return req
 }
 
+// destinationCaps describes which config fields a destination type accepts.
+type destinationCaps struct {
+	url  bool // config.url is accepted
+	auth bool // config.auth_type / config.auth are accepted
+}
+
+// destinationTypeCaps maps destination types to the fields they accept.
+// Types not listed here (including the empty, API-defaulted type) are
+// treated like HTTP.
+var destinationTypeCaps = map[string]destinationCaps{
+	"HTTP":             {url: true, auth: true},
+	"CLI":              {url: false, auth: false},
+	"MOCK_API":         {url: false, auth: false},
+	"HOOKDECK_OUTPOST": {url: false, auth: false},
+}
+
+// capsForDestinationType returns the accepted fields for typ.
+func capsForDestinationType(typ string) destinationCaps {
+	if caps, ok := destinationTypeCaps[typ]; ok {
+		return caps
+	}
+	return destinationTypeCaps["HTTP"]
+}
+
 func buildDestinationRequest(dst *manifest.DestinationConfig) *UpsertDestinationRequest {
 	req := &UpsertDestinationRequest{
 		Name: dst.Name,
@@ -267,29 +815,42 @@ func buildDestinationRequest(dst *manifest.DestinationConfig) *UpsertDestination
 
 	// Start with any explicit config entries from the manifest
 	for k, v := range dst.Config {
-		config[k] = v
+		config[k] = normalizeNumbers(v)
 	}
 
-	// Map top-level manifest fields into config
-	if dst.URL != "" {
+	// Map top-level manifest fields into config, skipping fields the
+	// destination type doesn't accept (e.g. CLI destinations have no URL
+	// and no auth).
+	caps := capsForDestinationType(dst.Type)
+	if caps.url && dst.URL != "" {
 		config["url"] = dst.URL
 	}
-	if dst.AuthType != "" {
-		config["auth_type"] = dst.AuthType
-	}
-	if dst.Auth != nil {
-		config["auth"] = dst.Auth
-	} else if dst.AuthType != "" {
-		// The Hookdeck API requires config.auth when auth_type is set.
-		// Default to empty object for auth types like HOOKDECK_SIGNATURE.
-		config["auth"] = map[string]interface{}{}
+	if caps.auth {
+		if dst.AuthType != "" {
+			config["auth_type"] = dst.AuthType
+		}
+		if auth := manifest.DestinationAuth(dst); auth != nil {
+			config["auth"] = auth
+		} else if dst.AuthType != "" {
+			// The Hookdeck API requires config.auth when auth_type is set.
+			// Default to empty object for auth types like HOOKDECK_SIGNATURE.
+			config["auth"] = map[string]interface{}{}
+		}
 	}
-	if dst.RateLimit != 0 {
+	// A zero rate_limit only means "unset" without a period; with one (e.g.
+	// "concurrent") it is an explicit limit and must be sent.
+	if dst.RateLimit != 0 || dst.RateLimitPeriod != "" {
 		config["rate_limit"] = dst.RateLimit
 	}
 	if dst.RateLimitPeriod != "" {
 		config["rate_limit_period"] = dst.RateLimitPeriod
 	}
+	if dst.PathForwardingDisabled != nil {
+		config["path_forwarding_disabled"] = *dst.PathForwardingDisabled
+	}
+	if dst.HTTPMethod != "" {
+		config["http_method"] = dst.HTTPMethod
+	}
 
 	if len(config) > 0 {
 		req.Config = config
@@ -303,6 +864,10 @@ func buildTransformationRequest(tr *manifest.TransformationConfig, code string)
 		Name: tr.Name,
 		Code: code,
 	}
+	if tr.Description != "" {
+		desc := tr.Description
+		req.Description = &desc
+	}
 	if len(tr.Env) > 0 {
 		req.Env = tr.Env
 	}
@@ -312,17 +877,31 @@ func buildTransformationRequest(tr *manifest.TransformationConfig, code string)
 func buildConnectionRequest(conn *manifest.ConnectionConfig, sourceID, destinationID string, transformationIDs map[string]string) *UpsertConnectionRequest {
 	req := &UpsertConnectionRequest{}
 
-	if conn.Name != "" {
-		name := conn.Name
+	// Without a name Hookdeck can't match the existing connection, so a
+	// nameless one gets a stable derived name.
+	name := conn.Name
+	if name == "" {
+		name = manifest.DefaultConnectionName(conn)
+	}
+	if name != "" {
 		req.Name = &name
 	}
-	// Prefer resolved IDs from earlier upserts; fall back to name-based references
-	if sourceID != "" {
+	if conn.Description != "" {
+		desc := conn.Description
+		req.Description = &desc
+	}
+	// Literal IDs from the manifest win; otherwise prefer resolved IDs from
+	// earlier upserts and fall back to name-based references.
+	if conn.SourceID != "" {
+		req.SourceID = &conn.SourceID
+	} else if sourceID != "" {
 		req.SourceID = &sourceID
 	} else if conn.Source != "" {
 		req.Source = &ConnectionSourceRef{Name: conn.Source}
 	}
-	if destinationID != "" {
+	if conn.DestinationID != "" {
+		req.DestinationID = &conn.DestinationID
+	} else if destinationID != "" {
 		req.DestinationID = &destinationID
 	} else if conn.Destination != "" {
 		req.Destination = &ConnectionDestRef{Name: conn.Destination}
@@ -335,16 +914,19 @@ func buildConnectionRequest(conn *manifest.ConnectionConfig, sourceID, destinati
 	for _, rule := range conn.Rules {
 		ruleCopy := make(map[string]interface{})
 		for k, v := range rule {
-			ruleCopy[k] = v
+			ruleCopy[k] = normalizeNumbers(v)
+		}
+		// Exponential retries get a base interval when none is given, so the
+		// request states the schedule that ValidateRules checked.
+		if ruleCopy["type"] == "retry" && ruleCopy["strategy"] == "exponential" {
+			if _, ok := ruleCopy["interval"]; !ok {
+				ruleCopy["interval"] = manifest.DefaultExponentialRetryInterval
+			}
 		}
 		// If this is a transform rule, try to inject the resolved transformation ID
-		if ruleType, ok := ruleCopy["type"].(string); ok && ruleType == "transform" {
-			if trRef, ok := ruleCopy["transformation"].(map[string]interface{}); ok {
-				if name, ok := trRef["name"].(string); ok {
-					if id, ok := transformationIDs[name]; ok {
-						ruleCopy["transformation_id"] = id
-					}
-				}
+		if name := manifest.TransformRuleName(ruleCopy); name != "" {
+			if id, ok := transformationIDs[name]; ok {
+				ruleCopy["transformation_id"] = id
 			}
 		}
 		rules = append(rules, ruleCopy)
@@ -372,32 +954,154 @@ func buildConnectionRequest(conn *manifest.ConnectionConfig, sourceID, destinati
 		})
 	}
 
+	// Convert delay shorthand (seconds) to a delay rule (milliseconds)
+	if conn.Delay > 0 {
+		rules = append(rules, map[string]interface{}{
+			"type":  "delay",
+			"delay": conn.Delay * 1000,
+		})
+	}
+
 	if len(rules) > 0 {
 		req.Rules = rules
 	}
 
+	paused := conn.Disabled
+	req.Paused = &paused
+
 	return req
 }
 
-// resolveCode reads the code file for a transformation.
+// DeclaresRules reports whether the upsert request for conn carries any
+// rule, whether declared directly or through a shorthand.
+func DeclaresRules(conn *manifest.ConnectionConfig) bool {
+	return len(buildConnectionRequest(conn, "", "", nil).Rules) > 0
+}
+
+// maxExactFloatInt is the largest magnitude below which every integer is
+// exactly representable as a float64.
+const maxExactFloatInt = 1 << 53
+
+// normalizeNumbers returns a copy of v, as decoded from JSON, with every
+// whole-number float64 (e.g. an interval written as 60.0) converted to int64,
+// so request values the API expects to be integers are typed as such. Maps
+// and slices are copied rather than modified in place.
+func normalizeNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < maxExactFloatInt {
+			return int64(v)
+		}
+		return v
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			out[k] = normalizeNumbers(elem)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = normalizeNumbers(elem)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// omitUnchangedAuth removes auth_type and auth from req.Config when the live
+// destination already has the same values.
+func omitUnchangedAuth(ctx context.Context, fetcher RemoteAuthFetcher, req *UpsertDestinationRequest) error {
+	localAuth, ok := req.Config["auth"]
+	if !ok {
+		return nil
+	}
+	remoteType, remoteAuth, found, err := fetcher.DestinationAuth(ctx, req.Name)
+	if err != nil || !found {
+		return err
+	}
+	localType, _ := req.Config["auth_type"].(string)
+	if remoteAuth == nil {
+		remoteAuth = map[string]interface{}{}
+	}
+	if localType == remoteType && sameJSON(localAuth, remoteAuth) {
+		delete(req.Config, "auth_type")
+		delete(req.Config, "auth")
+	}
+	return nil
+}
+
+// sameJSON reports whether a and b encode to the same JSON, which ignores
+// differences such as int vs float64 numbers and map key order.
+func sameJSON(a, b interface{}) bool {
+	aj, errA := json.Marshal(a)
+	bj, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	var av, bv interface{}
+	if json.Unmarshal(aj, &av) != nil || json.Unmarshal(bj, &bv) != nil {
+		return false
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// ResolveCode reads the code for a transformation, resolving a relative
+// code_file against codeRoot. It is exported for callers (such as drift
+// detection) that need the same code Deploy would send.
+func ResolveCode(tr *manifest.TransformationConfig, codeRoot string) (string, error) {
+	return resolveCode(tr, codeRoot)
+}
+
+// resolveCode reads the code for a transformation. Each code_file or
+// code_files entry may be a glob; matches are read in sorted order (entries
+// in their listed order) and concatenated into a single script.
 func resolveCode(tr *manifest.TransformationConfig, codeRoot string) (string, error) {
-	if tr.CodeFile == "" {
+	patterns := tr.CodeFiles
+	if tr.CodeFile != "" {
+		patterns = []string{tr.CodeFile}
+	}
+	if len(patterns) == 0 {
 		return "", fmt.Errorf("code_file is required")
 	}
 
-	path := tr.CodeFile
-	if codeRoot != "" {
-		path = filepath.Join(codeRoot, tr.CodeFile)
-	}
+	var code strings.Builder
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		path := pattern
+		if codeRoot != "" && !filepath.IsAbs(pattern) {
+			path = filepath.Join(codeRoot, pattern)
+		}
+
+		paths := []string{path}
+		if glob.HasMeta(path) {
+			matches, err := glob.Expand(path)
+			if err != nil {
+				return "", err
+			}
+			if len(matches) == 0 {
+				return "", fmt.Errorf("code file pattern %q matched no files", path)
+			}
+			paths = matches
+		}
 
-	// For now we pass the code_file path as the code value. In the real deploy
-	// command, the caller reads the file and passes the content via a pre-
-	// processing step, or we read it here. Let's read it here.
-	data, err := readFile(path)
-	if err != nil {
-		return "", fmt.Errorf("reading code file %q: %w", path, err)
+		for _, p := range paths {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			data, err := readFile(p)
+			if err != nil {
+				return "", fmt.Errorf("reading code file %q: %w", p, err)
+			}
+			if code.Len() > 0 && !strings.HasSuffix(code.String(), "\n") {
+				code.WriteByte('\n')
+			}
+			code.Write(data)
+		}
 	}
-	return string(data), nil
+	return code.String(), nil
 }
 
 // readFile is a package-level variable so tests can override it.
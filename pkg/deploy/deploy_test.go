@@ -1,8 +1,14 @@
 package deploy
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
@@ -19,7 +25,9 @@ type mockClient struct {
 	upsertTransformationCalls int
 
 	// Capture last requests for assertions
-	lastConnectionReq *UpsertConnectionRequest
+	lastConnectionReq     *UpsertConnectionRequest
+	lastDestinationReq    *UpsertDestinationRequest
+	lastTransformationReq *UpsertTransformationRequest
 
 	// Allow overriding return values per-name
 	sourceResults         map[string]*UpsertSourceResult
@@ -46,6 +54,7 @@ func (m *mockClient) UpsertSource(_ context.Context, req *UpsertSourceRequest) (
 
 func (m *mockClient) UpsertDestination(_ context.Context, req *UpsertDestinationRequest) (*UpsertDestinationResult, error) {
 	m.upsertDestinationCalls++
+	m.lastDestinationReq = req
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -81,6 +90,7 @@ func (m *mockClient) UpsertConnection(_ context.Context, req *UpsertConnectionRe
 
 func (m *mockClient) UpsertTransformation(_ context.Context, req *UpsertTransformationRequest) (*UpsertTransformationResult, error) {
 	m.upsertTransformationCalls++
+	m.lastTransformationReq = req
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -303,6 +313,66 @@ func TestDeploy_LiveMode_ResolveCodeAbsolutePath(t *testing.T) {
 	}
 }
 
+// writeCodeFiles creates files (slash-separated names) with the given
+// contents under dir.
+func writeCodeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResolveCode_GlobConcatenatesSorted(t *testing.T) {
+	dir := t.TempDir()
+	writeCodeFiles(t, dir, map[string]string{
+		"src/b.js":     "const b = 2;",
+		"src/a.js":     "const a = 1;\n",
+		"src/lib/c.js": "const c = 3;",
+		"src/notes.md": "ignored",
+	})
+
+	tr := &manifest.TransformationConfig{Name: "t", CodeFile: "src/**/*.js"}
+	code, err := resolveCode(tr, dir)
+	if err != nil {
+		t.Fatalf("resolveCode failed: %v", err)
+	}
+	want := "const a = 1;\nconst b = 2;\nconst c = 3;"
+	if code != want {
+		t.Errorf("expected %q, got %q", want, code)
+	}
+}
+
+func TestResolveCode_CodeFilesInListedOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeCodeFiles(t, dir, map[string]string{
+		"lib/util.js": "util();",
+		"handler.js":  "handler();",
+	})
+
+	tr := &manifest.TransformationConfig{Name: "t", CodeFiles: []string{"lib/*.js", "handler.js", "lib/util.js"}}
+	code, err := resolveCode(tr, dir)
+	if err != nil {
+		t.Fatalf("resolveCode failed: %v", err)
+	}
+	if want := "util();\nhandler();"; code != want {
+		t.Errorf("expected %q, got %q", want, code)
+	}
+}
+
+func TestResolveCode_GlobMatchesNothing(t *testing.T) {
+	tr := &manifest.TransformationConfig{Name: "t", CodeFile: "src/*.js"}
+	_, err := resolveCode(tr, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "matched no files") {
+		t.Fatalf("expected matched no files error, got %v", err)
+	}
+}
+
 func TestDeploy_LiveMode_ResolveCodeRelativePath(t *testing.T) {
 	// When CodeFile is relative and CodeRoot is set (single-file mode),
 	// resolveCode should join them.
@@ -357,6 +427,90 @@ func TestDeploy_LiveMode_SourceError(t *testing.T) {
 	}
 }
 
+func TestDeploy_LiveMode_TransformationWithoutCode(t *testing.T) {
+	mc := &mockClient{}
+	input := &DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "src"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "tr"}, {Name: "inline"}},
+		Destinations:    []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections: []*manifest.ConnectionConfig{
+			{Name: "a", Source: "src", Destination: "dst", Transformations: []string{"tr"}},
+			{Source: "src", Destination: "dst", Rules: []map[string]interface{}{
+				{"type": "transform", "transformation": map[string]interface{}{"name": "tr"}},
+			}},
+		},
+	}
+
+	_, err := Deploy(context.Background(), mc, input, Options{Code: map[string]string{"inline": "x"}})
+	if err == nil {
+		t.Fatal("expected error for transformation without code")
+	}
+	want := `transformation "tr" has no code_file or code_files (referenced by connections "a", "src-to-dst")`
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+	if mc.upsertSourceCalls != 0 {
+		t.Errorf("expected no upserts before the check, got %d source upserts", mc.upsertSourceCalls)
+	}
+}
+
+func TestDeploy_LiveMode_CodeTooLarge(t *testing.T) {
+	mc := &mockClient{}
+	input := &DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "src"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "big"}, {Name: "small"}},
+	}
+	code := map[string]string{"big": strings.Repeat("x", 101), "small": strings.Repeat("x", 100)}
+
+	_, err := Deploy(context.Background(), mc, input, Options{Code: code, MaxCodeSize: 100})
+	if err == nil {
+		t.Fatal("expected error for oversized transformation code")
+	}
+	want := `transformation code too large: transformation "big" code is 101 bytes, over the 100-byte limit`
+	if err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+	if !errors.Is(err, ErrCodeTooLarge) {
+		t.Errorf("expected the error to wrap ErrCodeTooLarge, got %v", err)
+	}
+	if mc.upsertSourceCalls != 0 || mc.upsertTransformationCalls != 0 {
+		t.Errorf("expected no upserts before the check, got %d source and %d transformation upserts",
+			mc.upsertSourceCalls, mc.upsertTransformationCalls)
+	}
+
+	if _, err := Deploy(context.Background(), mc, input, Options{Code: code}); err != nil {
+		t.Errorf("expected no limit when MaxCodeSize is zero, got %v", err)
+	}
+	if _, err := Deploy(context.Background(), nil, input, Options{DryRun: true, Code: code, MaxCodeSize: 100}); !errors.Is(err, ErrCodeTooLarge) {
+		t.Errorf("expected dry-run to check the size too, got %v", err)
+	}
+}
+
+func TestDeploy_LiveMode_CodeSizeResolvesCodeOnce(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "t.js"), []byte("addHandler()"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mc := &mockClient{}
+	input := &DeployInput{Transformations: []*manifest.TransformationConfig{{Name: "t", CodeFile: "t.js"}}}
+
+	// The code file is read by the size check and then removed: the upsert
+	// must reuse what was measured rather than resolving it again.
+	opts := Options{CodeRoot: dir, MaxCodeSize: 100, Reporter: removeOnStart{filepath.Join(dir, "t.js")}}
+	if _, err := Deploy(context.Background(), mc, input, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := mc.lastTransformationReq.Code; got != "addHandler()" {
+		t.Errorf("expected the measured code to be upserted, got %q", got)
+	}
+}
+
+// removeOnStart deletes path when the first resource starts.
+type removeOnStart struct{ path string }
+
+func (r removeOnStart) OnResourceStart(string, string)         { os.Remove(r.path) }
+func (r removeOnStart) OnResourceDone(string, *ResourceResult) {}
+
 func TestDeploy_LiveMode_FilterShorthand(t *testing.T) {
 	mc := &mockClient{}
 	input := &DeployInput{
@@ -405,3 +559,968 @@ func TestDeploy_LiveMode_FilterShorthand(t *testing.T) {
 		t.Error("expected rule body to contain key 'data'")
 	}
 }
+
+func TestDeploy_LiveMode_DelayShorthand(t *testing.T) {
+	mc := &mockClient{}
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "my-source"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "my-dest", URL: "https://example.com"}},
+		Connections: []*manifest.ConnectionConfig{{
+			Name:        "my-conn",
+			Source:      "my-source",
+			Destination: "my-dest",
+			Filter:      map[string]interface{}{"type": "order.placed"},
+			Delay:       30,
+		}},
+	}
+
+	if _, err := Deploy(context.Background(), mc, input, Options{}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	// Verify delay shorthand was converted to a delay rule after the filter
+	connReq := mc.lastConnectionReq
+	if connReq == nil {
+		t.Fatal("expected connection request to be captured")
+	}
+	if len(connReq.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(connReq.Rules))
+	}
+	if connReq.Rules[0]["type"] != "filter" {
+		t.Errorf("expected the filter rule first, got %v", connReq.Rules[0]["type"])
+	}
+	rule := connReq.Rules[1]
+	if rule["type"] != "delay" {
+		t.Errorf("expected rule type 'delay', got %v", rule["type"])
+	}
+	if rule["delay"] != 30000 {
+		t.Errorf("expected delay of 30000ms, got %v", rule["delay"])
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Reference checks
+// ---------------------------------------------------------------------------
+
+func TestCheckReferences_AllDefined(t *testing.T) {
+	input := &DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "src"}},
+		Destinations:    []*manifest.DestinationConfig{{Name: "dst"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "tr"}},
+		Connections: []*manifest.ConnectionConfig{{
+			Name:            "conn",
+			Source:          "src",
+			Destination:     "dst",
+			Transformations: []string{"tr"},
+		}},
+	}
+
+	if errs := CheckReferences(input); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestCheckReferences_ReportsAllProblems(t *testing.T) {
+	input := &DeployInput{
+		Sources: []*manifest.SourceConfig{{Name: "src"}},
+		Connections: []*manifest.ConnectionConfig{{
+			Name:            "conn",
+			Source:          "scr",
+			Destination:     "dst",
+			Transformations: []string{"tr"},
+		}},
+	}
+
+	errs := CheckReferences(input)
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d: %v", len(errs), errs)
+	}
+	if got := errs[0].Error(); got != `connection "conn" references undefined source "scr"` {
+		t.Errorf("unexpected first error: %s", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Reporter
+// ---------------------------------------------------------------------------
+
+type recordingReporter struct {
+	events []string
+}
+
+func (r *recordingReporter) OnResourceStart(kind, name string) {
+	r.events = append(r.events, "start "+kind+" "+name)
+}
+
+func (r *recordingReporter) OnResourceDone(kind string, res *ResourceResult) {
+	r.events = append(r.events, "done "+kind+" "+res.Name+" "+res.Action)
+}
+
+func TestDeploy_ReporterReceivesEventsInOrder(t *testing.T) {
+	rep := &recordingReporter{}
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections:  []*manifest.ConnectionConfig{{Name: "conn", Source: "src", Destination: "dst"}},
+	}
+
+	_, err := Deploy(context.Background(), &mockClient{}, input, Options{Reporter: rep})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	want := []string{
+		"start source src",
+		"done source src upserted",
+		"start destination dst",
+		"done destination dst upserted",
+		"start connection conn",
+		"done connection conn upserted",
+	}
+	if len(rep.events) != len(want) {
+		t.Fatalf("expected %d events, got %d: %v", len(want), len(rep.events), rep.events)
+	}
+	for i := range want {
+		if rep.events[i] != want[i] {
+			t.Errorf("event[%d]: expected %q, got %q", i, want[i], rep.events[i])
+		}
+	}
+}
+
+func TestDeploy_FollowsInputOrder(t *testing.T) {
+	rep := &recordingReporter{}
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src"}, {Name: "other"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections:  []*manifest.ConnectionConfig{{Name: "conn", Source: "src", Destination: "dst"}},
+		Disabled:     []DisabledResource{{Kind: "source", Name: "off"}},
+		// "other" is left out, so it follows in the default order.
+		Order: []ResourceRef{{Kind: "destination", Name: "dst"}, {Kind: "source", Name: "src"}, {Kind: "connection", Name: "conn"}},
+	}
+
+	result, err := Deploy(context.Background(), &mockClient{}, input, Options{Reporter: rep})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	var starts []string
+	for _, e := range rep.events {
+		if strings.HasPrefix(e, "start ") {
+			starts = append(starts, strings.TrimPrefix(e, "start "))
+		}
+	}
+	want := []string{"destination dst", "source src", "connection conn", "source other"}
+	if strings.Join(starts, ", ") != strings.Join(want, ", ") {
+		t.Errorf("expected order %v, got %v", want, starts)
+	}
+	if rep.events[0] != "done source off skipped (disabled)" {
+		t.Errorf("expected the disabled source reported first, got %v", rep.events)
+	}
+	if len(result.Sources) != 3 || len(result.Destinations) != 1 || len(result.Connections) != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestDeploy_ReporterDryRun(t *testing.T) {
+	rep := &recordingReporter{}
+	input := &DeployInput{
+		Sources: []*manifest.SourceConfig{{Name: "src"}},
+	}
+
+	_, err := Deploy(context.Background(), nil, input, Options{DryRun: true, Reporter: rep})
+	if err != nil {
+		t.Fatalf("Deploy dry-run failed: %v", err)
+	}
+	if len(rep.events) != 2 || rep.events[1] != "done source src would upsert" {
+		t.Errorf("unexpected events: %v", rep.events)
+	}
+}
+
+// cancelingReporter cancels the deploy once the named resource is done.
+type cancelingReporter struct {
+	after  string
+	cancel context.CancelFunc
+}
+
+func (r *cancelingReporter) OnResourceStart(kind, name string) {}
+
+func (r *cancelingReporter) OnResourceDone(kind string, res *ResourceResult) {
+	if kind+" "+res.Name == r.after {
+		r.cancel()
+	}
+}
+
+func TestDeploy_CancelledReturnsPartialResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mc := &mockClient{}
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src-a"}, {Name: "src-b"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections:  []*manifest.ConnectionConfig{{Name: "conn", Source: "src-a", Destination: "dst"}},
+	}
+
+	result, err := Deploy(ctx, mc, input, Options{Reporter: &cancelingReporter{after: "source src-a", cancel: cancel}})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a context.Canceled error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `before source "src-b"`) {
+		t.Errorf("expected the error to name the next resource, got %v", err)
+	}
+	if result == nil {
+		t.Fatal("expected a partial result")
+	}
+	if len(result.Sources) != 1 || result.Sources[0].Name != "src-a" || result.Sources[0].Action != "upserted" {
+		t.Errorf("expected only src-a upserted, got %+v", result.Sources)
+	}
+	if len(result.Destinations)+len(result.Connections) != 0 || mc.upsertSourceCalls != 1 {
+		t.Errorf("expected nothing after src-a, got result %+v and %d source calls", result, mc.upsertSourceCalls)
+	}
+	if result.FinishedAt.IsZero() {
+		t.Error("expected FinishedAt to be set on a partial result")
+	}
+
+	// Other failures still return no result.
+	result, err = Deploy(context.Background(), &mockClient{err: fmt.Errorf("boom")}, input, Options{})
+	if err == nil || result != nil {
+		t.Errorf("expected an error and no result, got %v, %v", result, err)
+	}
+}
+
+func TestBuildConnectionRequest_DisabledSetsPaused(t *testing.T) {
+	req := buildConnectionRequest(&manifest.ConnectionConfig{Name: "c", Disabled: true}, "", "", nil)
+	if req.Paused == nil || !*req.Paused {
+		t.Errorf("expected Paused=true, got %v", req.Paused)
+	}
+
+	req = buildConnectionRequest(&manifest.ConnectionConfig{Name: "c"}, "", "", nil)
+	if req.Paused == nil || *req.Paused {
+		t.Errorf("expected Paused=false, got %v", req.Paused)
+	}
+}
+
+func TestBuildConnectionRequest_LiteralIDs(t *testing.T) {
+	conn := &manifest.ConnectionConfig{Name: "c", SourceID: "src_ext", DestinationID: "des_ext"}
+	req := buildConnectionRequest(conn, "", "", nil)
+	if req.SourceID == nil || *req.SourceID != "src_ext" {
+		t.Errorf("expected SourceID src_ext, got %v", req.SourceID)
+	}
+	if req.DestinationID == nil || *req.DestinationID != "des_ext" {
+		t.Errorf("expected DestinationID des_ext, got %v", req.DestinationID)
+	}
+	if req.Source != nil || req.Destination != nil {
+		t.Errorf("expected no name-based refs, got source=%v destination=%v", req.Source, req.Destination)
+	}
+}
+
+func TestBuildConnectionRequest_Description(t *testing.T) {
+	req := buildConnectionRequest(&manifest.ConnectionConfig{Name: "c", Description: "orders to processor"}, "", "", nil)
+	if req.Description == nil || *req.Description != "orders to processor" {
+		t.Errorf("expected description to be sent, got %v", req.Description)
+	}
+
+	req = buildConnectionRequest(&manifest.ConnectionConfig{Name: "c"}, "", "", nil)
+	if req.Description != nil {
+		t.Errorf("expected no description, got %q", *req.Description)
+	}
+}
+
+func TestBuildConnectionRequest_NormalizesWholeFloats(t *testing.T) {
+	conn := &manifest.ConnectionConfig{
+		Name: "conn",
+		Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "linear", "count": float64(5), "interval": float64(60)},
+			{"type": "delay", "delay": float64(1.5)},
+		},
+	}
+	req := buildConnectionRequest(conn, "", "", nil)
+
+	data, err := json.Marshal(req.Rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"count":5,"interval":60,"strategy":"linear","type":"retry"},{"delay":1.5,"type":"delay"}]`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+	if conn.Rules[0]["interval"] != float64(60) {
+		t.Error("expected manifest rules to be left untouched")
+	}
+}
+
+func TestBuildConnectionRequest_ExponentialRetryDefaults(t *testing.T) {
+	conn := &manifest.ConnectionConfig{
+		Name: "conn",
+		Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "exponential", "count": float64(5)},
+			{"type": "retry", "strategy": "exponential", "count": float64(5), "interval": float64(30000), "max_interval": float64(600000)},
+			{"type": "retry", "strategy": "linear", "count": float64(5)},
+		},
+	}
+	req := buildConnectionRequest(conn, "", "", nil)
+
+	if got := req.Rules[0]["interval"]; got != manifest.DefaultExponentialRetryInterval {
+		t.Errorf("expected default interval, got %v", got)
+	}
+	if _, ok := conn.Rules[0]["interval"]; ok {
+		t.Error("expected manifest rules to be left untouched")
+	}
+	if req.Rules[1]["interval"] != int64(30000) || req.Rules[1]["max_interval"] != int64(600000) {
+		t.Errorf("expected explicit interval and max_interval preserved, got %v", req.Rules[1])
+	}
+	if _, ok := req.Rules[2]["interval"]; ok {
+		t.Errorf("expected linear rule without interval to be left alone, got %v", req.Rules[2])
+	}
+}
+
+func TestBuildDestinationRequest_NormalizesWholeFloats(t *testing.T) {
+	dst := &manifest.DestinationConfig{
+		Name: "dst",
+		URL:  "https://example.com",
+		Config: map[string]interface{}{
+			"rate_limit":   float64(60),
+			"path_forward": map[string]interface{}{"timeout": float64(30)},
+		},
+	}
+	req := buildDestinationRequest(dst)
+
+	data, err := json.Marshal(req.Config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"path_forward":{"timeout":30},"rate_limit":60,"url":"https://example.com"}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
+
+func TestBuildDestinationRequest_ConcurrentRateLimit(t *testing.T) {
+	for _, limit := range []int{1, 0} {
+		dst := &manifest.DestinationConfig{Name: "dst", URL: "https://example.com", RateLimit: limit, RateLimitPeriod: "concurrent"}
+		req := buildDestinationRequest(dst)
+		got, ok := req.Config["rate_limit"]
+		if !ok || got != limit {
+			t.Errorf("limit %d: expected rate_limit %d to be sent, got %v (present=%v)", limit, limit, got, ok)
+		}
+		if req.Config["rate_limit_period"] != "concurrent" {
+			t.Errorf("limit %d: expected concurrent period, got %v", limit, req.Config["rate_limit_period"])
+		}
+	}
+
+	// Without a period, zero still means unset.
+	req := buildDestinationRequest(&manifest.DestinationConfig{Name: "dst", URL: "https://example.com"})
+	if _, ok := req.Config["rate_limit"]; ok {
+		t.Errorf("expected rate_limit to be omitted, got %v", req.Config)
+	}
+}
+
+func TestBuildDestinationRequest_ConfigShorthands(t *testing.T) {
+	disabled := true
+	dst := &manifest.DestinationConfig{
+		Name:                   "dst",
+		URL:                    "https://example.com",
+		Config:                 map[string]interface{}{"http_method": "PUT", "path_forwarding_disabled": false, "timeout": 30},
+		PathForwardingDisabled: &disabled,
+		HTTPMethod:             "POST",
+	}
+	req := buildDestinationRequest(dst)
+	if req.Config["path_forwarding_disabled"] != true {
+		t.Errorf("expected path_forwarding_disabled true, got %v", req.Config["path_forwarding_disabled"])
+	}
+	if req.Config["http_method"] != "POST" {
+		t.Errorf("expected shorthand http_method to win, got %v", req.Config["http_method"])
+	}
+	if req.Config["timeout"] != 30 {
+		t.Errorf("expected other config keys to pass through, got %v", req.Config)
+	}
+
+	// Unset shorthands leave config untouched.
+	req = buildDestinationRequest(&manifest.DestinationConfig{Name: "dst", Config: map[string]interface{}{"http_method": "PUT"}})
+	if req.Config["http_method"] != "PUT" {
+		t.Errorf("expected config http_method PUT, got %v", req.Config["http_method"])
+	}
+	if _, ok := req.Config["path_forwarding_disabled"]; ok {
+		t.Errorf("expected path_forwarding_disabled to be omitted, got %v", req.Config)
+	}
+}
+
+func TestBuildDestinationRequest_AuthShorthands(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  manifest.DestinationConfig
+		want string
+	}{
+		{
+			name: "api key header",
+			dst:  manifest.DestinationConfig{AuthType: "API_KEY", AuthHeader: "X-API-Key", AuthValue: "secret"},
+			want: `{"api_key":"secret","key":"X-API-Key","to":"header"}`,
+		},
+		{
+			name: "bearer token",
+			dst:  manifest.DestinationConfig{AuthType: "BEARER_TOKEN", AuthValue: "tok"},
+			want: `{"token":"tok"}`,
+		},
+		{
+			name: "basic auth",
+			dst:  manifest.DestinationConfig{AuthType: "BASIC_AUTH", AuthUsername: "user", AuthPassword: "pass"},
+			want: `{"password":"pass","username":"user"}`,
+		},
+		{
+			name: "shorthand wins over raw auth",
+			dst: manifest.DestinationConfig{
+				AuthType:  "API_KEY",
+				Auth:      map[string]interface{}{"key": "Authorization", "api_key": "old", "to": "query"},
+				AuthValue: "new",
+			},
+			want: `{"api_key":"new","key":"Authorization","to":"header"}`,
+		},
+		{
+			name: "raw auth only",
+			dst:  manifest.DestinationConfig{AuthType: "CUSTOM_SIGNATURE", Auth: map[string]interface{}{"key": "X-Sig", "signing_secret": "s"}},
+			want: `{"key":"X-Sig","signing_secret":"s"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.dst.Name = "dst"
+			tt.dst.URL = "https://example.com"
+			req := buildDestinationRequest(&tt.dst)
+			if req.Config["auth_type"] != tt.dst.AuthType {
+				t.Errorf("expected auth_type %q, got %v", tt.dst.AuthType, req.Config["auth_type"])
+			}
+			got, _ := json.Marshal(req.Config["auth"])
+			if string(got) != tt.want {
+				t.Errorf("auth = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDestinationRequest_CLIOmitsURLAndAuth(t *testing.T) {
+	dst := &manifest.DestinationConfig{
+		Name:     "local-dev",
+		Type:     "CLI",
+		URL:      "https://ignored.example.com",
+		AuthType: "HOOKDECK_SIGNATURE",
+		Config:   map[string]interface{}{"path": "/webhooks"},
+	}
+	req := buildDestinationRequest(dst)
+
+	if req.Type != "CLI" {
+		t.Errorf("expected type CLI, got %q", req.Type)
+	}
+	for _, key := range []string{"url", "auth_type", "auth"} {
+		if _, ok := req.Config[key]; ok {
+			t.Errorf("expected config.%s to be omitted for CLI destination, got %v", key, req.Config[key])
+		}
+	}
+	if req.Config["path"] != "/webhooks" {
+		t.Errorf("expected explicit config path to be kept, got %v", req.Config["path"])
+	}
+}
+
+func TestBuildConnectionRequest_DerivesMissingName(t *testing.T) {
+	conn := &manifest.ConnectionConfig{Source: "shop", Destination: "processor"}
+	for i := 0; i < 2; i++ {
+		req := buildConnectionRequest(conn, "src_1", "des_1", nil)
+		if req.Name == nil || *req.Name != "shop-to-processor" {
+			t.Fatalf("expected stable derived name 'shop-to-processor', got %v", req.Name)
+		}
+	}
+}
+
+func TestBuildConnectionRequest_TransformationNameRule(t *testing.T) {
+	conn := &manifest.ConnectionConfig{
+		Name:        "c",
+		Source:      "s",
+		Destination: "d",
+		Rules: []map[string]interface{}{
+			{"type": "transform", "transformation_name": "normalize"},
+			{"type": "transform", "transformation": map[string]interface{}{"name": "enrich"}},
+		},
+	}
+	req := buildConnectionRequest(conn, "src_1", "des_1", map[string]string{"normalize": "trs_1", "enrich": "trs_2"})
+	if len(req.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %v", req.Rules)
+	}
+	if got := req.Rules[0]["transformation_id"]; got != "trs_1" {
+		t.Errorf("expected transformation_name rule to get trs_1, got %v", got)
+	}
+	if got := req.Rules[1]["transformation_id"]; got != "trs_2" {
+		t.Errorf("expected nested name rule to get trs_2, got %v", got)
+	}
+	if _, ok := conn.Rules[0]["transformation_id"]; ok {
+		t.Error("expected the manifest rule to be left unchanged")
+	}
+}
+
+func TestDeclaresRules(t *testing.T) {
+	tests := []struct {
+		name string
+		conn *manifest.ConnectionConfig
+		want bool
+	}{
+		{"none", &manifest.ConnectionConfig{Source: "s", Destination: "d"}, false},
+		{"zero delay", &manifest.ConnectionConfig{Source: "s", Destination: "d", Delay: 0}, false},
+		{"rules", &manifest.ConnectionConfig{Rules: []map[string]interface{}{{"type": "retry"}}}, true},
+		{"transformations", &manifest.ConnectionConfig{Transformations: []string{"t"}}, true},
+		{"filter", &manifest.ConnectionConfig{Filter: map[string]interface{}{"a": 1}}, true},
+		{"delay", &manifest.ConnectionConfig{Delay: 5}, true},
+	}
+	for _, tt := range tests {
+		if got := DeclaresRules(tt.conn); got != tt.want {
+			t.Errorf("%s: DeclaresRules = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuildSourceRequest_ConfigShorthands(t *testing.T) {
+	src := &manifest.SourceConfig{
+		Name: "orders",
+		Config: map[string]interface{}{
+			"allowed_http_methods": []interface{}{"GET"},
+			"auth_type":            "HMAC",
+		},
+		AllowedHTTPMethods: []string{"POST", "PUT"},
+		CustomResponse:     &manifest.CustomResponse{ContentType: "json", Body: `{"ok":true}`},
+	}
+	req := buildSourceRequest(src)
+
+	data, err := json.Marshal(req.Config)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	want := `{"allowed_http_methods":["POST","PUT"],"auth_type":"HMAC","custom_response":{"body":"{\"ok\":true}","content_type":"json"}}`
+	if string(data) != want {
+		t.Errorf("expected config %s, got %s", want, data)
+	}
+	if _, ok := src.Config["custom_response"]; ok {
+		t.Error("expected the manifest's config map not to be modified")
+	}
+}
+
+func TestBuildDestinationRequest_HTTPWithoutAuth(t *testing.T) {
+	dst := &manifest.DestinationConfig{Name: "api", Type: "HTTP", URL: "https://api.example.com/hooks"}
+	req := buildDestinationRequest(dst)
+
+	if req.Config["url"] != "https://api.example.com/hooks" {
+		t.Errorf("expected url in config, got %v", req.Config["url"])
+	}
+	if _, ok := req.Config["auth"]; ok {
+		t.Errorf("expected no auth without auth_type, got %v", req.Config["auth"])
+	}
+	if _, ok := req.Config["auth_type"]; ok {
+		t.Errorf("expected no auth_type, got %v", req.Config["auth_type"])
+	}
+}
+
+func TestBuildDestinationRequest_HTTPAuthTypeDefaultsAuth(t *testing.T) {
+	dst := &manifest.DestinationConfig{Name: "api", URL: "https://api.example.com", AuthType: "HOOKDECK_SIGNATURE"}
+	req := buildDestinationRequest(dst)
+
+	if req.Config["auth_type"] != "HOOKDECK_SIGNATURE" {
+		t.Errorf("expected auth_type in config, got %v", req.Config["auth_type"])
+	}
+	if auth, ok := req.Config["auth"].(map[string]interface{}); !ok || len(auth) != 0 {
+		t.Errorf("expected empty auth object, got %v", req.Config["auth"])
+	}
+}
+
+// stubChecker reports the named resources as unchanged with the given IDs.
+type stubChecker struct {
+	unchanged map[string]string // "kind/name" -> remote ID
+}
+
+func (s *stubChecker) lookup(kind, name string) (string, bool, error) {
+	id, ok := s.unchanged[kind+"/"+name]
+	return id, ok, nil
+}
+
+func (s *stubChecker) SourceUnchanged(_ context.Context, src *manifest.SourceConfig) (string, bool, error) {
+	return s.lookup("source", src.Name)
+}
+
+func (s *stubChecker) TransformationUnchanged(_ context.Context, tr *manifest.TransformationConfig, _ string) (string, bool, error) {
+	return s.lookup("transformation", tr.Name)
+}
+
+func (s *stubChecker) DestinationUnchanged(_ context.Context, dst *manifest.DestinationConfig) (string, bool, error) {
+	return s.lookup("destination", dst.Name)
+}
+
+func (s *stubChecker) ConnectionUnchanged(_ context.Context, conn *manifest.ConnectionConfig) (string, bool, error) {
+	return s.lookup("connection", conn.Name)
+}
+
+func TestDeploy_SkipUnchanged(t *testing.T) {
+	mc := &mockClient{}
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst", URL: "https://example.com"}},
+		Connections:  []*manifest.ConnectionConfig{{Name: "conn", Source: "src", Destination: "dst"}},
+	}
+	checker := &stubChecker{unchanged: map[string]string{"source/src": "src_remote"}}
+
+	result, err := Deploy(context.Background(), mc, input, Options{SkipUnchanged: true, Checker: checker})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	if mc.upsertSourceCalls != 0 {
+		t.Errorf("expected unchanged source not to be upserted, got %d calls", mc.upsertSourceCalls)
+	}
+	if got := result.Sources[0]; got.Action != "skipped" || got.ID != "src_remote" {
+		t.Errorf("expected skipped source with remote ID, got %+v", got)
+	}
+	if mc.upsertDestinationCalls != 1 || mc.upsertConnectionCalls != 1 {
+		t.Errorf("expected changed resources to be upserted, got destination=%d connection=%d",
+			mc.upsertDestinationCalls, mc.upsertConnectionCalls)
+	}
+	// The connection still references the skipped source by its remote ID.
+	if req := mc.lastConnectionReq; req.SourceID == nil || *req.SourceID != "src_remote" {
+		t.Errorf("expected connection to use remote source ID, got %v", req.SourceID)
+	}
+}
+
+func TestDeploy_SkipUnchangedRequiresChecker(t *testing.T) {
+	_, err := Deploy(context.Background(), &mockClient{}, &DeployInput{}, Options{SkipUnchanged: true})
+	if err == nil {
+		t.Fatal("expected error when SkipUnchanged is set without a Checker")
+	}
+}
+
+// stubAuthFetcher serves remote destination auth keyed by name.
+type stubAuthFetcher struct {
+	authType string
+	auth     map[string]map[string]interface{}
+}
+
+func (s *stubAuthFetcher) DestinationAuth(_ context.Context, name string) (string, map[string]interface{}, bool, error) {
+	auth, ok := s.auth[name]
+	return s.authType, auth, ok, nil
+}
+
+func TestDeploy_PreserveRemoteAuth(t *testing.T) {
+	fetcher := &stubAuthFetcher{
+		authType: "API_KEY",
+		auth:     map[string]map[string]interface{}{"dst": {"key": "x-api-key", "api_key": "secret"}},
+	}
+	tests := []struct {
+		name     string
+		auth     map[string]interface{}
+		dstName  string
+		wantAuth bool
+	}{
+		{"unchanged auth is omitted", map[string]interface{}{"api_key": "secret", "key": "x-api-key"}, "dst", false},
+		{"changed auth is sent", map[string]interface{}{"api_key": "rotated", "key": "x-api-key"}, "dst", true},
+		{"new destination sends auth", map[string]interface{}{"api_key": "secret", "key": "x-api-key"}, "new-dst", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &mockClient{}
+			input := &DeployInput{Destinations: []*manifest.DestinationConfig{{
+				Name: tt.dstName, URL: "https://example.com", AuthType: "API_KEY", Auth: tt.auth,
+			}}}
+			_, err := Deploy(context.Background(), mc, input, Options{PreserveRemoteAuth: true, AuthFetcher: fetcher})
+			if err != nil {
+				t.Fatalf("Deploy failed: %v", err)
+			}
+			cfg := mc.lastDestinationReq.Config
+			_, hasAuth := cfg["auth"]
+			_, hasType := cfg["auth_type"]
+			if hasAuth != tt.wantAuth || hasType != tt.wantAuth {
+				t.Errorf("expected auth sent=%v, got config %v", tt.wantAuth, cfg)
+			}
+			if cfg["url"] != "https://example.com" {
+				t.Errorf("expected url to be kept, got %v", cfg["url"])
+			}
+		})
+	}
+}
+
+func TestDeploy_PreserveRemoteAuthRequiresFetcher(t *testing.T) {
+	_, err := Deploy(context.Background(), &mockClient{}, &DeployInput{}, Options{PreserveRemoteAuth: true})
+	if err == nil {
+		t.Fatal("expected error when PreserveRemoteAuth is set without an AuthFetcher")
+	}
+}
+
+// stubRulesFetcher serves remote connection rules keyed by name.
+type stubRulesFetcher struct {
+	rules map[string][]map[string]interface{}
+}
+
+func (s *stubRulesFetcher) ConnectionRules(_ context.Context, conn *manifest.ConnectionConfig) ([]map[string]interface{}, bool, error) {
+	rules, ok := s.rules[conn.Name]
+	return rules, ok, nil
+}
+
+func TestDeploy_DeleteOrphanRules(t *testing.T) {
+	fetcher := &stubRulesFetcher{rules: map[string][]map[string]interface{}{
+		"conn": {{"type": "filter", "body": map[string]interface{}{"type": "order.created"}}},
+	}}
+	tests := []struct {
+		name       string
+		conn       *manifest.ConnectionConfig
+		wantRules  string
+		wantReason string
+	}{
+		{"remote rule not in manifest is removed", &manifest.ConnectionConfig{Name: "conn", Source: "src", Destination: "dst"}, `[]`, "removed 1 rule(s) not in the manifest"},
+		{"new connection omits rules", &manifest.ConnectionConfig{Name: "new-conn", Source: "src", Destination: "dst"}, ``, ""},
+		{"declared rules replace remote ones", &manifest.ConnectionConfig{
+			Name: "conn", Source: "src", Destination: "dst",
+			Rules: []map[string]interface{}{{"type": "delay", "delay": float64(1000)}},
+		}, `[{"delay":1000,"type":"delay"}]`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &mockClient{}
+			input := &DeployInput{Connections: []*manifest.ConnectionConfig{tt.conn}}
+			result, err := Deploy(context.Background(), mc, input, Options{DeleteOrphanRules: true, RulesFetcher: fetcher})
+			if err != nil {
+				t.Fatalf("Deploy failed: %v", err)
+			}
+			data, err := json.Marshal(mc.lastConnectionReq)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(data, &body); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+			if got := string(body["rules"]); got != tt.wantRules {
+				t.Errorf("expected rules %q in request body, got %q", tt.wantRules, got)
+			}
+			if got := result.Connections[0].Reason; got != tt.wantReason {
+				t.Errorf("expected reason %q, got %q", tt.wantReason, got)
+			}
+		})
+	}
+}
+
+func TestDeploy_DeleteOrphanRulesRequiresFetcher(t *testing.T) {
+	_, err := Deploy(context.Background(), &mockClient{}, &DeployInput{}, Options{DeleteOrphanRules: true})
+	if err == nil {
+		t.Fatal("expected error when DeleteOrphanRules is set without a RulesFetcher")
+	}
+}
+
+// stubReplacer reports connections to replace keyed by name and records
+// deletions.
+type stubReplacer struct {
+	replace map[string]string
+	deleted []string
+}
+
+func (s *stubReplacer) IncompatibleConnection(_ context.Context, conn *manifest.ConnectionConfig) (string, string, error) {
+	id, ok := s.replace[conn.Name]
+	if !ok {
+		return "", "", nil
+	}
+	return id, `source changed from "old" to "src"`, nil
+}
+
+func (s *stubReplacer) DeleteConnection(_ context.Context, id string) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+func TestDeploy_ReplaceConnections(t *testing.T) {
+	replacer := &stubReplacer{replace: map[string]string{"moved": "web_old"}}
+	mc := &mockClient{}
+	input := &DeployInput{Connections: []*manifest.ConnectionConfig{
+		{Name: "moved", Source: "src", Destination: "dst"},
+		{Name: "same", Source: "src", Destination: "dst"},
+	}}
+	result, err := Deploy(context.Background(), mc, input, Options{ReplaceConnections: true, Replacer: replacer})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if len(replacer.deleted) != 1 || replacer.deleted[0] != "web_old" {
+		t.Errorf("expected web_old to be deleted, got %v", replacer.deleted)
+	}
+	if mc.upsertConnectionCalls != 2 {
+		t.Errorf("expected 2 connection upserts, got %d", mc.upsertConnectionCalls)
+	}
+	if got := result.Connections[0]; got.Action != ActionReplaced || got.Reason != `source changed from "old" to "src"` {
+		t.Errorf("expected moved to be replaced with a reason, got %+v", got)
+	}
+	if got := result.Connections[1]; got.Action != "upserted" {
+		t.Errorf("expected same to be upserted, got %+v", got)
+	}
+}
+
+func TestDeploy_ReplaceConnectionsIgnoredInDryRun(t *testing.T) {
+	input := &DeployInput{Connections: []*manifest.ConnectionConfig{{Name: "moved", Source: "src", Destination: "dst"}}}
+	if _, err := Deploy(context.Background(), nil, input, Options{DryRun: true, ReplaceConnections: true}); err != nil {
+		t.Fatalf("expected dry-run to ignore ReplaceConnections, got %v", err)
+	}
+}
+
+// stubSourceTypes serves live source types keyed by name.
+type stubSourceTypes map[string]string
+
+func (s stubSourceTypes) SourceType(_ context.Context, name string) (string, bool, error) {
+	t, ok := s[name]
+	return t, ok, nil
+}
+
+func TestDeploy_SourceTypeChangeGuard(t *testing.T) {
+	live := stubSourceTypes{"shop": "SHOPIFY", "pay": "STRIPE"}
+	input := &DeployInput{Sources: []*manifest.SourceConfig{
+		{Name: "shop", Type: "WEBHOOK"},
+		{Name: "pay", Type: "stripe"},
+		{Name: "new", Type: "WEBHOOK"},
+		{Name: "untyped"},
+	}}
+
+	mc := &mockClient{}
+	_, err := Deploy(context.Background(), mc, input, Options{SourceTypes: live})
+	if err == nil {
+		t.Fatal("expected the type change to be refused")
+	}
+	want := `source "shop" would change type from SHOPIFY to WEBHOOK`
+	if !strings.Contains(err.Error(), want) || strings.Contains(err.Error(), `"pay"`) {
+		t.Errorf("expected only shop to be reported, got %v", err)
+	}
+	if !errors.Is(err, ErrSourceTypeChange) {
+		t.Errorf("expected the error to wrap ErrSourceTypeChange, got %v", err)
+	}
+	if mc.upsertSourceCalls != 0 {
+		t.Errorf("expected nothing upserted, got %d source calls", mc.upsertSourceCalls)
+	}
+
+	if _, err := Deploy(context.Background(), &mockClient{}, input, Options{SourceTypes: live, AllowTypeChange: true}); err != nil {
+		t.Errorf("expected AllowTypeChange to proceed, got %v", err)
+	}
+	if _, err := Deploy(context.Background(), nil, input, Options{DryRun: true, SourceTypes: live}); err != nil {
+		t.Errorf("expected dry-run to skip the guard, got %v", err)
+	}
+}
+
+func TestDeploy_DumpRequestsRedactsSecrets(t *testing.T) {
+	var out bytes.Buffer
+	input := &DeployInput{Destinations: []*manifest.DestinationConfig{{
+		Name: "dst", URL: "https://example.com", AuthType: "BEARER_TOKEN",
+		Auth: map[string]interface{}{"token": `s3cr"et`},
+	}}}
+	opts := Options{
+		DumpRequests: &out,
+		Redact:       func(s string) string { return strings.ReplaceAll(s, `s3cr"et`, "***") },
+	}
+	if _, err := Deploy(context.Background(), &mockClient{}, input, opts); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	got := out.String()
+	if !strings.Contains(got, `--- destination "dst" request`) {
+		t.Errorf("expected a request header, got:\n%s", got)
+	}
+	if !strings.Contains(got, `"token": "***"`) {
+		t.Errorf("expected the token to be masked, got:\n%s", got)
+	}
+	if strings.Contains(got, "s3cr") {
+		t.Errorf("expected no secret in the dump, got:\n%s", got)
+	}
+}
+
+func TestExcludeDisabled(t *testing.T) {
+	off := false
+	input := &DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "src"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "tr", Enabled: &off}},
+		Destinations:    []*manifest.DestinationConfig{{Name: "dst"}, {Name: "flaky", Enabled: &off}},
+		Connections: []*manifest.ConnectionConfig{
+			{Name: "ok", Source: "src", Destination: "dst"},
+			{Name: "to-flaky", Source: "src", Destination: "flaky"},
+			{Name: "transformed", Source: "src", Destination: "dst", Transformations: []string{"tr"}},
+			{Name: "off", Source: "src", Destination: "dst", Enabled: &off},
+		},
+	}
+	input.ExcludeDisabled()
+
+	if len(input.Sources) != 1 || len(input.Transformations) != 0 || len(input.Destinations) != 1 {
+		t.Errorf("unexpected remaining resources: %d sources, %d transformations, %d destinations",
+			len(input.Sources), len(input.Transformations), len(input.Destinations))
+	}
+	if len(input.Connections) != 1 || input.Connections[0].Name != "ok" {
+		t.Errorf("expected only connection ok to remain, got %v", input.Connections)
+	}
+	want := []DisabledResource{
+		{Kind: "transformation", Name: "tr"},
+		{Kind: "destination", Name: "flaky"},
+		{Kind: "connection", Name: "to-flaky", Reason: `destination "flaky" is disabled`},
+		{Kind: "connection", Name: "transformed", Reason: `transformation "tr" is disabled`},
+		{Kind: "connection", Name: "off"},
+	}
+	if len(input.Disabled) != len(want) {
+		t.Fatalf("expected %d disabled resources, got %v", len(want), input.Disabled)
+	}
+	for i, d := range want {
+		if input.Disabled[i] != d {
+			t.Errorf("disabled[%d]: expected %+v, got %+v", i, d, input.Disabled[i])
+		}
+	}
+}
+
+func TestDeploy_ReportsDisabled(t *testing.T) {
+	off := false
+	mc := &mockClient{}
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "flaky", Enabled: &off}},
+		Connections:  []*manifest.ConnectionConfig{{Name: "conn", Source: "src", Destination: "flaky"}},
+	}
+	input.ExcludeDisabled()
+
+	result, err := Deploy(context.Background(), mc, input, Options{})
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if mc.upsertDestinationCalls != 0 || mc.upsertConnectionCalls != 0 {
+		t.Errorf("expected no upserts for disabled resources, got destination=%d connection=%d",
+			mc.upsertDestinationCalls, mc.upsertConnectionCalls)
+	}
+	if got := result.Destinations[0]; got.Name != "flaky" || got.Action != ActionSkippedDisabled {
+		t.Errorf("expected flaky skipped as disabled, got %+v", got)
+	}
+	if got := result.Connections[0]; got.Action != ActionSkippedDisabled || got.Reason == "" {
+		t.Errorf("expected conn skipped with a reason, got %+v", got)
+	}
+}
+
+func TestDeploy_ExternalSourceResolvesByName(t *testing.T) {
+	mc := &mockClient{}
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "shared-ingress", External: true}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst", URL: "https://example.com"}},
+		Connections:  []*manifest.ConnectionConfig{{Name: "conn", Source: "shared-ingress", Destination: "dst"}},
+	}
+	input.ExcludeExternal()
+
+	if len(input.Sources) != 0 {
+		t.Fatalf("expected the external source to be excluded, got %v", input.Sources)
+	}
+	if want := []ExternalResource{{Kind: "source", Name: "shared-ingress"}}; len(input.External) != 1 || input.External[0] != want[0] {
+		t.Fatalf("expected external %v, got %v", want, input.External)
+	}
+	if errs := CheckReferences(input); len(errs) != 0 {
+		t.Fatalf("expected the external source to count as defined, got %v", errs)
+	}
+
+	if _, err := Deploy(context.Background(), mc, input, Options{}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if mc.upsertSourceCalls != 0 {
+		t.Errorf("expected no source upserts, got %d", mc.upsertSourceCalls)
+	}
+	req := mc.lastConnectionReq
+	if req == nil {
+		t.Fatal("expected the connection to be upserted")
+	}
+	if req.SourceID != nil {
+		t.Errorf("expected no source_id, got %q", *req.SourceID)
+	}
+	if req.Source == nil || req.Source.Name != "shared-ingress" {
+		t.Errorf("expected the source to be referenced by name, got %+v", req.Source)
+	}
+}
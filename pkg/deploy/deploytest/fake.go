@@ -0,0 +1,187 @@
+// Package deploytest provides an in-memory deploy.Client for tests of code
+// built on the deploy package.
+package deploytest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+// Resource kinds recorded in Call.Kind.
+const (
+	KindSource         = "source"
+	KindTransformation = "transformation"
+	KindDestination    = "destination"
+	KindConnection     = "connection"
+)
+
+// Call is one upsert received by a FakeClient.
+type Call struct {
+	Kind string
+	Name string
+}
+
+// FakeClient is a deploy.Client that records every upsert and returns
+// stubbed results. Unless stubbed, an upsert succeeds with the ID
+// "src_", "trs_", "des_", or "con_" followed by the resource name.
+//
+// Set the exported fields before deploying; they are read-only afterwards.
+// A FakeClient is safe for concurrent use.
+type FakeClient struct {
+	// Per-name results, returned instead of the default result.
+	SourceResults         map[string]*deploy.UpsertSourceResult
+	TransformationResults map[string]*deploy.UpsertTransformationResult
+	DestinationResults    map[string]*deploy.UpsertDestinationResult
+	ConnectionResults     map[string]*deploy.UpsertConnectionResult
+
+	// Errors maps "kind/name" to the error the upsert of that resource
+	// returns, e.g. "destination/backend".
+	Errors map[string]error
+
+	// Err, if set, is returned by every upsert.
+	Err error
+
+	mu              sync.Mutex
+	calls           []Call
+	sources         []*deploy.UpsertSourceRequest
+	transformations []*deploy.UpsertTransformationRequest
+	destinations    []*deploy.UpsertDestinationRequest
+	connections     []*deploy.UpsertConnectionRequest
+}
+
+var _ deploy.Client = (*FakeClient)(nil)
+
+// UpsertSource implements deploy.Client.
+func (c *FakeClient) UpsertSource(_ context.Context, req *deploy.UpsertSourceRequest) (*deploy.UpsertSourceResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Kind: KindSource, Name: req.Name})
+	c.sources = append(c.sources, req)
+	if err := c.errFor(KindSource, req.Name); err != nil {
+		return nil, err
+	}
+	if r, ok := c.SourceResults[req.Name]; ok {
+		return r, nil
+	}
+	return &deploy.UpsertSourceResult{ID: "src_" + req.Name, Name: req.Name}, nil
+}
+
+// UpsertTransformation implements deploy.Client.
+func (c *FakeClient) UpsertTransformation(_ context.Context, req *deploy.UpsertTransformationRequest) (*deploy.UpsertTransformationResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Kind: KindTransformation, Name: req.Name})
+	c.transformations = append(c.transformations, req)
+	if err := c.errFor(KindTransformation, req.Name); err != nil {
+		return nil, err
+	}
+	if r, ok := c.TransformationResults[req.Name]; ok {
+		return r, nil
+	}
+	return &deploy.UpsertTransformationResult{ID: "trs_" + req.Name, Name: req.Name}, nil
+}
+
+// UpsertDestination implements deploy.Client.
+func (c *FakeClient) UpsertDestination(_ context.Context, req *deploy.UpsertDestinationRequest) (*deploy.UpsertDestinationResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = append(c.calls, Call{Kind: KindDestination, Name: req.Name})
+	c.destinations = append(c.destinations, req)
+	if err := c.errFor(KindDestination, req.Name); err != nil {
+		return nil, err
+	}
+	if r, ok := c.DestinationResults[req.Name]; ok {
+		return r, nil
+	}
+	return &deploy.UpsertDestinationResult{ID: "des_" + req.Name, Name: req.Name}, nil
+}
+
+// UpsertConnection implements deploy.Client.
+func (c *FakeClient) UpsertConnection(_ context.Context, req *deploy.UpsertConnectionRequest) (*deploy.UpsertConnectionResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	name := ""
+	if req.Name != nil {
+		name = *req.Name
+	}
+	c.calls = append(c.calls, Call{Kind: KindConnection, Name: name})
+	c.connections = append(c.connections, req)
+	if err := c.errFor(KindConnection, name); err != nil {
+		return nil, err
+	}
+	if r, ok := c.ConnectionResults[name]; ok {
+		return r, nil
+	}
+	return &deploy.UpsertConnectionResult{ID: "con_" + name, Name: name}, nil
+}
+
+// errFor returns the stubbed error for an upsert, if any. c.mu must be held.
+func (c *FakeClient) errFor(kind, name string) error {
+	if c.Err != nil {
+		return c.Err
+	}
+	return c.Errors[kind+"/"+name]
+}
+
+// Calls returns every upsert received so far, in order, including failed
+// ones.
+func (c *FakeClient) Calls() []Call {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Call(nil), c.calls...)
+}
+
+// CallCount returns the number of upserts of kind received so far.
+func (c *FakeClient) CallCount(kind string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, call := range c.calls {
+		if call.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// SourceRequests returns every source upsert request, in order.
+func (c *FakeClient) SourceRequests() []*deploy.UpsertSourceRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*deploy.UpsertSourceRequest(nil), c.sources...)
+}
+
+// TransformationRequests returns every transformation upsert request, in
+// order.
+func (c *FakeClient) TransformationRequests() []*deploy.UpsertTransformationRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*deploy.UpsertTransformationRequest(nil), c.transformations...)
+}
+
+// DestinationRequests returns every destination upsert request, in order.
+func (c *FakeClient) DestinationRequests() []*deploy.UpsertDestinationRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*deploy.UpsertDestinationRequest(nil), c.destinations...)
+}
+
+// ConnectionRequests returns every connection upsert request, in order.
+func (c *FakeClient) ConnectionRequests() []*deploy.UpsertConnectionRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*deploy.UpsertConnectionRequest(nil), c.connections...)
+}
+
+// Reset forgets the recorded calls and requests. Stubs are kept.
+func (c *FakeClient) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls = nil
+	c.sources = nil
+	c.transformations = nil
+	c.destinations = nil
+	c.connections = nil
+}
@@ -0,0 +1,83 @@
+package deploytest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func testInput() *deploy.DeployInput {
+	return &deploy.DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "orders"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "backend", URL: "https://backend.example.com"}},
+		Connections: []*manifest.ConnectionConfig{{
+			Name:        "orders-to-backend",
+			Source:      "orders",
+			Destination: "backend",
+		}},
+	}
+}
+
+func TestFakeClient_RecordsCallsAndRequests(t *testing.T) {
+	client := &FakeClient{
+		SourceResults: map[string]*deploy.UpsertSourceResult{
+			"orders": {ID: "src_stubbed", Name: "orders"},
+		},
+	}
+	if _, err := deploy.Deploy(context.Background(), client, testInput(), deploy.Options{}); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	want := []Call{
+		{Kind: KindSource, Name: "orders"},
+		{Kind: KindDestination, Name: "backend"},
+		{Kind: KindConnection, Name: "orders-to-backend"},
+	}
+	if got := client.Calls(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected calls %v, got %v", want, got)
+	}
+	if n := client.CallCount(KindConnection); n != 1 {
+		t.Errorf("expected 1 connection call, got %d", n)
+	}
+
+	conns := client.ConnectionRequests()
+	if len(conns) != 1 {
+		t.Fatalf("expected 1 connection request, got %d", len(conns))
+	}
+	if conns[0].SourceID == nil || *conns[0].SourceID != "src_stubbed" {
+		t.Errorf("expected stubbed source_id 'src_stubbed', got %v", conns[0].SourceID)
+	}
+	if conns[0].DestinationID == nil || *conns[0].DestinationID != "des_backend" {
+		t.Errorf("expected default destination_id 'des_backend', got %v", conns[0].DestinationID)
+	}
+
+	client.Reset()
+	if len(client.Calls()) != 0 || len(client.ConnectionRequests()) != 0 {
+		t.Error("expected Reset to forget recorded calls and requests")
+	}
+}
+
+func TestFakeClient_StubbedErrors(t *testing.T) {
+	errDown := errors.New("backend unavailable")
+	client := &FakeClient{Errors: map[string]error{"destination/backend": errDown}}
+
+	_, err := deploy.Deploy(context.Background(), client, testInput(), deploy.Options{})
+	if !errors.Is(err, errDown) {
+		t.Fatalf("expected stubbed destination error, got %v", err)
+	}
+	if n := client.CallCount(KindConnection); n != 0 {
+		t.Errorf("expected no connection upsert after the failure, got %d", n)
+	}
+
+	client = &FakeClient{Err: errDown}
+	if _, err := deploy.Deploy(context.Background(), client, testInput(), deploy.Options{}); !errors.Is(err, errDown) {
+		t.Fatalf("expected global error, got %v", err)
+	}
+	if got := client.Calls(); len(got) != 1 || got[0].Kind != KindSource {
+		t.Errorf("expected only the failed source upsert to be recorded, got %v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package deploy
+
+import (
+	"fmt"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// ActionSkippedDisabled is the action reported for resources left out of a
+// deploy because they (or a resource they reference) set "enabled": false.
+const ActionSkippedDisabled = "skipped (disabled)"
+
+// DisabledResource is a resource excluded from a deploy by ExcludeDisabled.
+type DisabledResource struct {
+	Kind   string `json:"kind"` // "source", "transformation", "destination", or "connection"
+	Name   string `json:"name"`
+	Reason string `json:"reason,omitempty"` // why a connection was excluded; empty when it was disabled itself
+}
+
+// ExcludeDisabled moves every resource whose resolved Enabled setting is
+// false out of in and into in.Disabled, along with any connection that
+// references a disabled source, destination, or transformation. Deploy
+// reports each one as ActionSkippedDisabled without calling the API.
+func (in *DeployInput) ExcludeDisabled() {
+	disabled := map[string]bool{}
+	exclude := func(kind, name string, enabled *bool) bool {
+		if manifest.IsEnabled(enabled) {
+			return false
+		}
+		disabled[kind+"/"+name] = true
+		in.Disabled = append(in.Disabled, DisabledResource{Kind: kind, Name: name})
+		return true
+	}
+
+	var sources []*manifest.SourceConfig
+	for _, src := range in.Sources {
+		if !exclude("source", src.Name, src.Enabled) {
+			sources = append(sources, src)
+		}
+	}
+	in.Sources = sources
+
+	var transformations []*manifest.TransformationConfig
+	for _, tr := range in.Transformations {
+		if !exclude("transformation", tr.Name, tr.Enabled) {
+			transformations = append(transformations, tr)
+		}
+	}
+	in.Transformations = transformations
+
+	var destinations []*manifest.DestinationConfig
+	for _, dst := range in.Destinations {
+		if !exclude("destination", dst.Name, dst.Enabled) {
+			destinations = append(destinations, dst)
+		}
+	}
+	in.Destinations = destinations
+
+	var connections []*manifest.ConnectionConfig
+	for _, conn := range in.Connections {
+		if exclude("connection", conn.Name, conn.Enabled) {
+			continue
+		}
+		if reason := disabledReference(conn, disabled); reason != "" {
+			in.Disabled = append(in.Disabled, DisabledResource{Kind: "connection", Name: conn.Name, Reason: reason})
+			continue
+		}
+		connections = append(connections, conn)
+	}
+	in.Connections = connections
+}
+
+// disabledReference describes the first disabled resource conn references,
+// or returns "" if it references none.
+func disabledReference(conn *manifest.ConnectionConfig, disabled map[string]bool) string {
+	if conn.Source != "" && disabled["source/"+conn.Source] {
+		return fmt.Sprintf("source %q is disabled", conn.Source)
+	}
+	if conn.Destination != "" && disabled["destination/"+conn.Destination] {
+		return fmt.Sprintf("destination %q is disabled", conn.Destination)
+	}
+	for _, name := range manifest.TransformationRefs(conn) {
+		if disabled["transformation/"+name] {
+			return fmt.Sprintf("transformation %q is disabled", name)
+		}
+	}
+	return ""
+}
+
+// DisabledResults returns an ActionSkippedDisabled result for every disabled
+// resource of kind, in the order ExcludeDisabled found them.
+func (in *DeployInput) DisabledResults(kind string) []*ResourceResult {
+	var results []*ResourceResult
+	for _, d := range in.Disabled {
+		if d.Kind == kind {
+			results = append(results, &ResourceResult{Name: d.Name, Action: ActionSkippedDisabled, Reason: d.Reason})
+		}
+	}
+	return results
+}
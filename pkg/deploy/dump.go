@@ -0,0 +1,69 @@
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// dumpRequest writes req to opts.DumpRequests, if set, as indented JSON with
+// opts.Redact applied to every string value.
+func dumpRequest(opts Options, kind, name string, req interface{}) {
+	if opts.DumpRequests == nil {
+		return
+	}
+	data, err := encodeRedacted(req, opts.Redact)
+	if err != nil {
+		fmt.Fprintf(opts.DumpRequests, "--- %s %q request: %v\n", kind, name, err)
+		return
+	}
+	fmt.Fprintf(opts.DumpRequests, "--- %s %q request\n%s\n", kind, name, data)
+}
+
+// encodeRedacted encodes req the way the API client sends it, then decodes
+// and re-encodes it indented so that redact sees string values rather than
+// their JSON escapes.
+func encodeRedacted(req interface{}, redact func(string) string) ([]byte, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var body interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return nil, err
+	}
+	if redact != nil {
+		body = redactStrings(body, redact)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(body); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// redactStrings returns a copy of v, as decoded from JSON, with redact
+// applied to every string, including map keys.
+func redactStrings(v interface{}, redact func(string) string) interface{} {
+	switch v := v.(type) {
+	case string:
+		return redact(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, elem := range v {
+			out[redact(k)] = redactStrings(elem, redact)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, elem := range v {
+			out[i] = redactStrings(elem, redact)
+		}
+		return out
+	default:
+		return v
+	}
+}
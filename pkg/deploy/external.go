@@ -0,0 +1,45 @@
+package deploy
+
+import "github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+
+// ExternalResource is a placeholder moved out of a deploy by ExcludeExternal.
+type ExternalResource struct {
+	Kind string `json:"kind"` // "source" or "destination"
+	Name string `json:"name"`
+}
+
+// ExcludeExternal moves every source and destination marked "external": true
+// out of in and into in.External. They are managed elsewhere, so Deploy never
+// upserts them; connections that reference them are sent with the name alone
+// for the API to resolve. CheckReferences still counts them as defined.
+func (in *DeployInput) ExcludeExternal() {
+	var sources []*manifest.SourceConfig
+	for _, src := range in.Sources {
+		if src.External {
+			in.External = append(in.External, ExternalResource{Kind: "source", Name: src.Name})
+			continue
+		}
+		sources = append(sources, src)
+	}
+	in.Sources = sources
+
+	var destinations []*manifest.DestinationConfig
+	for _, dst := range in.Destinations {
+		if dst.External {
+			in.External = append(in.External, ExternalResource{Kind: "destination", Name: dst.Name})
+			continue
+		}
+		destinations = append(destinations, dst)
+	}
+	in.Destinations = destinations
+}
+
+// isExternal reports whether in.External lists the named resource of kind.
+func (in *DeployInput) isExternal(kind, name string) bool {
+	for _, e := range in.External {
+		if e.Kind == kind && e.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package deploy
+
+// ResourceRef names one resource of a DeployInput.
+type ResourceRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// deployKinds lists the resource kinds in the order Deploy works through
+// them by default.
+var deployKinds = []string{"source", "transformation", "destination", "connection"}
+
+// kindIndex returns kind's position in deployKinds.
+func kindIndex(kind string) int {
+	for i, k := range deployKinds {
+		if k == kind {
+			return i
+		}
+	}
+	return len(deployKinds)
+}
+
+// step is one resource for Deploy to handle: entry index of kind's list.
+type step struct {
+	kind  string
+	name  string
+	index int
+}
+
+// steps returns the order Deploy handles in's resources in: the entries of
+// in.Order that name one of them, then the rest kind by kind.
+func (in *DeployInput) steps() []step {
+	var all []step
+	for i, src := range in.Sources {
+		all = append(all, step{"source", src.Name, i})
+	}
+	for i, tr := range in.Transformations {
+		all = append(all, step{"transformation", tr.Name, i})
+	}
+	for i, dst := range in.Destinations {
+		all = append(all, step{"destination", dst.Name, i})
+	}
+	for i, conn := range in.Connections {
+		all = append(all, step{"connection", conn.Name, i})
+	}
+
+	done := make([]bool, len(all))
+	var steps []step
+	for _, ref := range in.Order {
+		for i, st := range all {
+			if !done[i] && st.kind == ref.Kind && st.name == ref.Name {
+				done[i] = true
+				steps = append(steps, st)
+				break
+			}
+		}
+	}
+	for i, st := range all {
+		if !done[i] {
+			steps = append(steps, st)
+		}
+	}
+	return steps
+}
+
+// list returns the result list for kind.
+func (r *Result) list(kind string) *[]*ResourceResult {
+	switch kind {
+	case "source":
+		return &r.Sources
+	case "transformation":
+		return &r.Transformations
+	case "destination":
+		return &r.Destinations
+	default:
+		return &r.Connections
+	}
+}
@@ -0,0 +1,144 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// PlanVersion is the plan file format written by WritePlan. ReadPlan rejects
+// plans with any other version.
+const PlanVersion = 1
+
+// Plan is a saved deploy: the fully resolved input plus the changes it was
+// expected to make against the remote state at CreatedAt. Applying a plan
+// deploys exactly Input, without re-reading manifests, variables, or code
+// files.
+type Plan struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// Env, Profile, and APIBaseURL record where the plan was computed, so
+	// apply targets the same project.
+	Env        string `json:"env,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+	APIBaseURL string `json:"api_base_url,omitempty"`
+	// Client is the project config's client block, kept as JSON because
+	// its type lives in the project package.
+	Client json.RawMessage `json:"client,omitempty"`
+	// Protected records that Env was a protected environment, so apply asks
+	// for confirmation the way deploy does.
+	Protected bool `json:"protected,omitempty"`
+	// AllowTypeChange records plan --allow-type-change for apply's source
+	// type change guard.
+	AllowTypeChange bool `json:"allow_type_change,omitempty"`
+	// MaxCodeSize is the plan's --max-payload-size, applied again by apply;
+	// 0 disables the check.
+	MaxCodeSize int `json:"max_code_size,omitempty"`
+
+	// Input is the resolved, interpolated input, so it may contain secrets.
+	Input *DeployInput `json:"input"`
+	// Code holds each transformation's resolved code by name.
+	Code map[string]string `json:"code,omitempty"`
+
+	Changes []PlannedChange `json:"changes"`
+}
+
+// PlannedChange is the expected outcome for one resource.
+type PlannedChange struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Action string `json:"action"` // a dry-run preview action, or "skipped (disabled)"
+
+	// RemoteID and Fingerprint identify the remote resource the plan was
+	// computed against; both are empty when it didn't exist yet.
+	RemoteID    string `json:"remote_id,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	Fields []PlannedField `json:"fields,omitempty"`
+}
+
+// PlannedField is a field the plan changes, with redacted values.
+type PlannedField struct {
+	Field  string `json:"field"`
+	Local  string `json:"local"`
+	Remote string `json:"remote"`
+}
+
+// WritePlan saves p to path as indented JSON. The file is only readable by
+// its owner because the input may contain secrets.
+func WritePlan(path string, p *Plan) error {
+	// Transformation code is kept readable rather than HTML-escaped.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(p); err != nil {
+		return fmt.Errorf("encoding plan: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("writing plan: %w", err)
+	}
+	return nil
+}
+
+// ReadPlan loads a plan saved by WritePlan.
+func ReadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading plan: %w", err)
+	}
+	var p Plan
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parsing plan: %w", err)
+	}
+	if p.Version != PlanVersion {
+		return nil, fmt.Errorf("unsupported plan version %d (expected %d); re-run plan with this version of hookdeck-deploy", p.Version, PlanVersion)
+	}
+	if p.Input == nil {
+		return nil, fmt.Errorf("plan has no input")
+	}
+	return &p, nil
+}
+
+// Checker returns an UnchangedChecker that reports exactly the resources the
+// plan marked "no changes" as unchanged, with their planned remote IDs, so
+// Deploy with SkipUnchanged replays the plan without comparing again.
+func (p *Plan) Checker() UnchangedChecker {
+	unchanged := map[string]string{}
+	for _, c := range p.Changes {
+		if c.Action == "no changes" {
+			unchanged[c.Kind+"/"+c.Name] = c.RemoteID
+		}
+	}
+	return planChecker(unchanged)
+}
+
+// planChecker maps "kind/name" to the remote ID of each unchanged resource.
+type planChecker map[string]string
+
+func (c planChecker) lookup(kind, name string) (string, bool, error) {
+	id, ok := c[kind+"/"+name]
+	return id, ok, nil
+}
+
+func (c planChecker) SourceUnchanged(_ context.Context, src *manifest.SourceConfig) (string, bool, error) {
+	return c.lookup("source", src.Name)
+}
+
+func (c planChecker) TransformationUnchanged(_ context.Context, tr *manifest.TransformationConfig, _ string) (string, bool, error) {
+	return c.lookup("transformation", tr.Name)
+}
+
+func (c planChecker) DestinationUnchanged(_ context.Context, dst *manifest.DestinationConfig) (string, bool, error) {
+	return c.lookup("destination", dst.Name)
+}
+
+func (c planChecker) ConnectionUnchanged(_ context.Context, conn *manifest.ConnectionConfig) (string, bool, error) {
+	return c.lookup("connection", conn.Name)
+}
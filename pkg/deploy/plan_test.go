@@ -0,0 +1,124 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func TestWriteReadPlan_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	plan := &Plan{
+		Version:   PlanVersion,
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Env:       "production",
+		Client:    json.RawMessage(`{"retry_attempts":3}`),
+		Protected: true,
+		Input: &DeployInput{
+			Sources:         []*manifest.SourceConfig{{Name: "src"}},
+			Transformations: []*manifest.TransformationConfig{{Name: "tr", CodeFile: "tr.js"}},
+			Disabled:        []DisabledResource{{Kind: "destination", Name: "flaky"}},
+		},
+		Code: map[string]string{"tr": "addHandler('transform', (r) => r);"},
+
+		AllowTypeChange: true,
+		MaxCodeSize:     1024,
+		Changes: []PlannedChange{
+			{Kind: "source", Name: "src", Action: "no changes", RemoteID: "src_1", Fingerprint: "abc"},
+		},
+	}
+	if err := WritePlan(path, plan); err != nil {
+		t.Fatalf("WritePlan failed: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected plan file mode 0600, got %o", perm)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "(r) => r") {
+		t.Errorf("expected transformation code to be written unescaped, got %s", data)
+	}
+
+	got, err := ReadPlan(path)
+	if err != nil {
+		t.Fatalf("ReadPlan failed: %v", err)
+	}
+	if got.Env != "production" || !got.CreatedAt.Equal(plan.CreatedAt) || !got.Protected {
+		t.Errorf("unexpected plan metadata: %+v", got)
+	}
+	var client struct {
+		RetryAttempts int `json:"retry_attempts"`
+	}
+	if err := json.Unmarshal(got.Client, &client); err != nil || client.RetryAttempts != 3 {
+		t.Errorf("unexpected client settings: %s", got.Client)
+	}
+	if !got.AllowTypeChange || got.MaxCodeSize != 1024 {
+		t.Errorf("unexpected deploy settings: allow type change %v, max code size %d", got.AllowTypeChange, got.MaxCodeSize)
+	}
+	if len(got.Input.Sources) != 1 || got.Input.Sources[0].Name != "src" {
+		t.Errorf("unexpected sources: %v", got.Input.Sources)
+	}
+	if len(got.Input.Disabled) != 1 || got.Input.Disabled[0].Name != "flaky" {
+		t.Errorf("unexpected disabled resources: %v", got.Input.Disabled)
+	}
+	if got.Code["tr"] != plan.Code["tr"] {
+		t.Errorf("unexpected code: %q", got.Code["tr"])
+	}
+}
+
+func TestReadPlan_RejectsOtherVersions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "plan.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "input": {}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadPlan(path)
+	if err == nil || !strings.Contains(err.Error(), "unsupported plan version 99") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
+
+func TestDeploy_ReplaysPlan(t *testing.T) {
+	mc := &mockClient{}
+	plan := &Plan{
+		Input: &DeployInput{
+			Sources:         []*manifest.SourceConfig{{Name: "src"}},
+			Transformations: []*manifest.TransformationConfig{{Name: "tr", CodeFile: "missing.js"}},
+			Destinations:    []*manifest.DestinationConfig{{Name: "dst", URL: "https://example.com"}},
+			Connections:     []*manifest.ConnectionConfig{{Name: "conn", Source: "src", Destination: "dst"}},
+		},
+		Code: map[string]string{"tr": "code"},
+		Changes: []PlannedChange{
+			{Kind: "source", Name: "src", Action: "no changes", RemoteID: "src_remote"},
+			{Kind: "transformation", Name: "tr", Action: "new"},
+			{Kind: "destination", Name: "dst", Action: "would change", RemoteID: "des_remote"},
+			{Kind: "connection", Name: "conn", Action: "would upsert", RemoteID: "con_remote"},
+		},
+	}
+
+	opts := Options{SkipUnchanged: true, Checker: plan.Checker(), Code: plan.Code}
+	result, err := Deploy(context.Background(), mc, plan.Input, opts)
+	if err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if mc.upsertSourceCalls != 0 || result.Sources[0].Action != "skipped" {
+		t.Errorf("expected unchanged source to be skipped, got %d calls, %+v", mc.upsertSourceCalls, result.Sources[0])
+	}
+	// The planned code is used, so the missing code file is never read.
+	if mc.upsertTransformationCalls != 1 || mc.upsertDestinationCalls != 1 || mc.upsertConnectionCalls != 1 {
+		t.Errorf("expected planned changes to be upserted, got transformation=%d destination=%d connection=%d",
+			mc.upsertTransformationCalls, mc.upsertDestinationCalls, mc.upsertConnectionCalls)
+	}
+	if req := mc.lastConnectionReq; req.SourceID == nil || *req.SourceID != "src_remote" {
+		t.Errorf("expected connection to use the planned source ID, got %v", req.SourceID)
+	}
+}
@@ -0,0 +1,46 @@
+package deploy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// CanonicalRuleTypes is the order Options.CanonicalRuleOrder puts connection
+// rules in: events are transformed first, then filtered on the transformed
+// payload, and retry, delay, and deduplicate settings come last. Rules of
+// any other type keep their relative order after these.
+var CanonicalRuleTypes = []string{"transform", "filter", "retry", "delay", "deduplicate"}
+
+// ruleRank returns the position of rule's type in CanonicalRuleTypes, or
+// len(CanonicalRuleTypes) for an unknown type.
+func ruleRank(rule map[string]interface{}) int {
+	typ, _ := rule["type"].(string)
+	for i, t := range CanonicalRuleTypes {
+		if t == typ {
+			return i
+		}
+	}
+	return len(CanonicalRuleTypes)
+}
+
+// sortRules stably sorts rules into canonical order in place.
+func sortRules(rules []map[string]interface{}) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return ruleRank(rules[i]) < ruleRank(rules[j])
+	})
+}
+
+// reorderedRule describes the first explicit rule that canonical ordering
+// moves ahead of an earlier one, or returns "" when rules are already in
+// canonical order. Shorthand rules are left out, since their place in the
+// request was never chosen by the manifest author.
+func reorderedRule(rules []map[string]interface{}) string {
+	for i := 1; i < len(rules); i++ {
+		for j := 0; j < i; j++ {
+			if ruleRank(rules[i]) < ruleRank(rules[j]) {
+				return fmt.Sprintf("%v rule %d moves before %v rule %d", rules[i]["type"], i+1, rules[j]["type"], j+1)
+			}
+		}
+	}
+	return ""
+}
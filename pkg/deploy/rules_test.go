@@ -0,0 +1,72 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func ruleTypes(rules []map[string]interface{}) []string {
+	types := make([]string, len(rules))
+	for i, r := range rules {
+		types[i], _ = r["type"].(string)
+	}
+	return types
+}
+
+func TestDeploy_CanonicalRuleOrder(t *testing.T) {
+	input := &DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "src"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "tr"}},
+		Destinations:    []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections: []*manifest.ConnectionConfig{{
+			Name:        "conn",
+			Source:      "src",
+			Destination: "dst",
+			Rules: []map[string]interface{}{
+				{"type": "retry", "strategy": "linear", "count": 3, "interval": 1000},
+				{"type": "custom"},
+				{"type": "filter", "body": map[string]interface{}{"a": 1}},
+			},
+			Transformations: []string{"tr"},
+		}},
+	}
+	code := map[string]string{"tr": "x"}
+
+	mc := &mockClient{}
+	if _, err := Deploy(context.Background(), mc, input, Options{Code: code}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(ruleTypes(mc.lastConnectionReq.Rules)), "[retry custom filter transform]"; got != want {
+		t.Errorf("default order: got %s, want %s", got, want)
+	}
+
+	var warnings []string
+	mc = &mockClient{}
+	opts := Options{
+		Code:               code,
+		CanonicalRuleOrder: true,
+		Warnf:              func(format string, args ...interface{}) { warnings = append(warnings, fmt.Sprintf(format, args...)) },
+	}
+	if _, err := Deploy(context.Background(), mc, input, opts); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fmt.Sprint(ruleTypes(mc.lastConnectionReq.Rules)), "[transform filter retry custom]"; got != want {
+		t.Errorf("canonical order: got %s, want %s", got, want)
+	}
+	if len(warnings) != 1 || warnings[0] != `connection "conn": reordering rules into canonical order (filter rule 3 moves before retry rule 1)` {
+		t.Errorf("unexpected warnings: %q", warnings)
+	}
+	if got := fmt.Sprint(ruleTypes(input.Connections[0].Rules)); got != "[retry custom filter]" {
+		t.Errorf("expected manifest rules untouched, got %s", got)
+	}
+}
+
+func TestReorderedRule_InOrderIsSilent(t *testing.T) {
+	rules := []map[string]interface{}{{"type": "transform"}, {"type": "filter"}, {"type": "retry"}, {"type": "unknown"}}
+	if moved := reorderedRule(rules); moved != "" {
+		t.Errorf("expected no reordering, got %q", moved)
+	}
+}
@@ -0,0 +1,105 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Summary aggregates a Result into counts per kind and per action.
+type Summary struct {
+	// Kinds maps each resource kind to its count (e.g. "source": 2).
+	Kinds map[string]int `json:"kinds"`
+	// Actions maps each action to its count across all kinds
+	// (e.g. "upserted": 4, "skipped": 2).
+	Actions map[string]int `json:"actions"`
+	// Duration is the wall-clock time Deploy took.
+	Duration time.Duration `json:"-"`
+	// DurationMS is Duration in milliseconds, for JSON consumers.
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// Summary computes the counts and duration for r.
+func (r *Result) Summary() Summary {
+	s := Summary{Kinds: map[string]int{}, Actions: map[string]int{}}
+	add := func(kind string, results []*ResourceResult) {
+		if len(results) == 0 {
+			return
+		}
+		s.Kinds[kind] += len(results)
+		for _, res := range results {
+			s.Actions[res.Action]++
+		}
+	}
+	add("source", r.Sources)
+	add("transformation", r.Transformations)
+	add("destination", r.Destinations)
+	add("connection", r.Connections)
+
+	if !r.StartedAt.IsZero() && !r.FinishedAt.IsZero() {
+		s.Duration = r.FinishedAt.Sub(r.StartedAt)
+		s.DurationMS = s.Duration.Milliseconds()
+	}
+	return s
+}
+
+// MarshalJSON includes the computed summary alongside the per-resource results.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		plain
+		Summary Summary `json:"summary"`
+	}{plain(r), r.Summary()})
+}
+
+// summaryKinds and summaryActions fix the rendering order; unknown actions
+// follow in alphabetical order.
+var (
+	summaryKinds   = []string{"source", "transformation", "destination", "connection"}
+	summaryActions = []string{"upserted", ActionReplaced, "would upsert", "new", "would change", "no changes", "skipped", ActionSkippedDisabled}
+)
+
+// String renders the summary as a single line, e.g.
+// "2 sources, 1 connection: 2 upserted, 1 skipped in 1.2s".
+func (s Summary) String() string {
+	var kinds []string
+	for _, kind := range summaryKinds {
+		if n := s.Kinds[kind]; n > 0 {
+			kinds = append(kinds, plural(n, kind))
+		}
+	}
+	if len(kinds) == 0 {
+		return fmt.Sprintf("no resources deployed in %s", s.Duration.Round(time.Millisecond))
+	}
+
+	var actions []string
+	seen := map[string]bool{}
+	for _, action := range summaryActions {
+		seen[action] = true
+		if n := s.Actions[action]; n > 0 {
+			actions = append(actions, fmt.Sprintf("%d %s", n, action))
+		}
+	}
+	var others []string
+	for action := range s.Actions {
+		if !seen[action] {
+			others = append(others, action)
+		}
+	}
+	sort.Strings(others)
+	for _, action := range others {
+		actions = append(actions, fmt.Sprintf("%d %s", s.Actions[action], action))
+	}
+
+	return fmt.Sprintf("%s: %s in %s", strings.Join(kinds, ", "), strings.Join(actions, ", "), s.Duration.Round(time.Millisecond))
+}
+
+// plural formats n and noun, adding an "s" when n != 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,80 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func TestResultSummary(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &Result{
+		Sources: []*ResourceResult{
+			{Name: "s1", Action: "upserted"},
+			{Name: "s2", Action: "skipped"},
+		},
+		Connections: []*ResourceResult{
+			{Name: "c1", Action: "upserted"},
+		},
+		StartedAt:  start,
+		FinishedAt: start.Add(1500 * time.Millisecond),
+	}
+
+	s := r.Summary()
+	if s.Kinds["source"] != 2 || s.Kinds["connection"] != 1 {
+		t.Errorf("unexpected kind counts: %v", s.Kinds)
+	}
+	if s.Actions["upserted"] != 2 || s.Actions["skipped"] != 1 {
+		t.Errorf("unexpected action counts: %v", s.Actions)
+	}
+	if s.DurationMS != 1500 {
+		t.Errorf("expected 1500ms, got %d", s.DurationMS)
+	}
+
+	want := "2 sources, 1 connection: 2 upserted, 1 skipped in 1.5s"
+	if got := s.String(); got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestResultSummary_DryRun(t *testing.T) {
+	input := &DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "s"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "d"}},
+	}
+	result, err := Deploy(context.Background(), nil, input, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("Deploy dry-run failed: %v", err)
+	}
+	if result.StartedAt.IsZero() || result.FinishedAt.IsZero() {
+		t.Error("expected start and finish times to be recorded")
+	}
+	if got := result.Summary().String(); !strings.HasPrefix(got, "1 source, 1 destination: 2 would upsert in ") {
+		t.Errorf("unexpected dry-run summary: %q", got)
+	}
+}
+
+func TestResult_MarshalJSONIncludesSummary(t *testing.T) {
+	r := Result{Sources: []*ResourceResult{{Name: "s", Action: "upserted"}}}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	var decoded struct {
+		Sources []ResourceResult `json:"sources"`
+		Summary Summary          `json:"summary"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if len(decoded.Sources) != 1 {
+		t.Errorf("expected sources to be kept, got %s", data)
+	}
+	if decoded.Summary.Actions["upserted"] != 1 {
+		t.Errorf("expected summary in JSON, got %s", data)
+	}
+}
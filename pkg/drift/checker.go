@@ -0,0 +1,204 @@
+package drift
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// Fetcher retrieves live resources by name. *hookdeck.Client and
+// *hookdeck.CachingClient both satisfy it.
+type Fetcher interface {
+	GetSourceByName(ctx context.Context, name string) (*hookdeck.SourceDetail, error)
+	GetDestinationByName(ctx context.Context, name string) (*hookdeck.DestinationDetail, error)
+	GetConnectionByFullName(ctx context.Context, fullName string) (*hookdeck.ConnectionDetail, error)
+	GetTransformationByName(ctx context.Context, name string) (*hookdeck.TransformationDetail, error)
+}
+
+// FetchConnection looks up the live connection for conn by each of
+// manifest.ConnectionLookupNames in turn: its full name, then its declared
+// name. It returns nil when none finds a connection.
+func FetchConnection(ctx context.Context, f Fetcher, conn *manifest.ConnectionConfig) (*hookdeck.ConnectionDetail, error) {
+	for _, name := range manifest.ConnectionLookupNames(conn) {
+		remote, err := f.GetConnectionByFullName(ctx, name)
+		if err != nil || remote != nil {
+			return remote, err
+		}
+	}
+	return nil, nil
+}
+
+// Checker implements deploy.UnchangedChecker with the same comparisons
+// Detect uses. It errs on the side of upserting: a resource that sets
+// anything drift detection can't compare against the API response (source
+// type or config, destination auth or config, connection rules) is always
+// reported as changed.
+type Checker struct {
+	fetcher Fetcher
+}
+
+// NewChecker returns a Checker that fetches remote state through f.
+func NewChecker(f Fetcher) *Checker {
+	return &Checker{fetcher: f}
+}
+
+// SourceUnchanged reports whether src matches its live source.
+func (c *Checker) SourceUnchanged(ctx context.Context, src *manifest.SourceConfig) (string, bool, error) {
+	remote, err := c.fetcher.GetSourceByName(ctx, src.Name)
+	if err != nil || remote == nil {
+		return "", false, err
+	}
+	if src.Type != "" || len(src.Config) > 0 {
+		return remote.ID, false, nil
+	}
+	return remote.ID, detectSource(src, remote) == nil, nil
+}
+
+// TransformationUnchanged reports whether tr matches its live transformation.
+func (c *Checker) TransformationUnchanged(ctx context.Context, tr *manifest.TransformationConfig, codeRoot string) (string, bool, error) {
+	remote, err := c.fetcher.GetTransformationByName(ctx, tr.Name)
+	if err != nil || remote == nil {
+		return "", false, err
+	}
+	return remote.ID, detectTransformation(tr, remote, codeRoot) == nil, nil
+}
+
+// DestinationUnchanged reports whether dst matches its live destination.
+func (c *Checker) DestinationUnchanged(ctx context.Context, dst *manifest.DestinationConfig) (string, bool, error) {
+	remote, err := c.fetcher.GetDestinationByName(ctx, dst.Name)
+	if err != nil || remote == nil {
+		return "", false, err
+	}
+	if dst.Type != "" && dst.Type != remote.Type {
+		return remote.ID, false, nil
+	}
+	if dst.Description != "" && dst.Description != remote.Description {
+		return remote.ID, false, nil
+	}
+	if manifest.DestinationAuth(dst) != nil || len(dst.Config) > 0 {
+		return remote.ID, false, nil
+	}
+	return remote.ID, detectDestination(dst, remote) == nil, nil
+}
+
+// DestinationAuth returns the live auth_type and auth of the named destination.
+// It implements deploy.RemoteAuthFetcher.
+func (c *Checker) DestinationAuth(ctx context.Context, name string) (string, map[string]interface{}, bool, error) {
+	remote, err := c.fetcher.GetDestinationByName(ctx, name)
+	if err != nil || remote == nil {
+		return "", nil, false, err
+	}
+	return remote.Config.AuthType, remote.Config.Auth, true, nil
+}
+
+// SourceType returns the live type of the named source. It implements
+// deploy.RemoteSourceTypeFetcher.
+func (c *Checker) SourceType(ctx context.Context, name string) (string, bool, error) {
+	remote, err := c.fetcher.GetSourceByName(ctx, name)
+	if err != nil || remote == nil {
+		return "", false, err
+	}
+	return remote.Type, true, nil
+}
+
+// ConnectionRules returns the live rules of conn's connection. It implements
+// deploy.RemoteRulesFetcher.
+func (c *Checker) ConnectionRules(ctx context.Context, conn *manifest.ConnectionConfig) ([]map[string]interface{}, bool, error) {
+	remote, err := FetchConnection(ctx, c.fetcher, conn)
+	if err != nil || remote == nil {
+		return nil, false, err
+	}
+	return remote.Rules, true, nil
+}
+
+// ConnectionUnchanged reports whether conn matches its live connection.
+func (c *Checker) ConnectionUnchanged(ctx context.Context, conn *manifest.ConnectionConfig) (string, bool, error) {
+	remote, err := FetchConnection(ctx, c.fetcher, conn)
+	if err != nil || remote == nil {
+		return "", false, err
+	}
+	if deploy.DeclaresRules(conn) {
+		return remote.ID, false, nil
+	}
+	if remote.Source == nil || !endpointMatches(conn.Source, conn.SourceID, remote.Source.Name, remote.Source.ID) {
+		return remote.ID, false, nil
+	}
+	if remote.Destination == nil || !endpointMatches(conn.Destination, conn.DestinationID, remote.Destination.Name, remote.Destination.ID) {
+		return remote.ID, false, nil
+	}
+	return remote.ID, detectConnection(conn, remote) == nil, nil
+}
+
+// endpointMatches reports whether a connection endpoint, referenced by name
+// or by literal ID, is the one the live connection points at.
+func endpointMatches(name, id, remoteName, remoteID string) bool {
+	if id != "" {
+		return id == remoteID
+	}
+	return name == remoteName
+}
+
+// Fingerprint returns the live ID of the named resource and a hash of its
+// full remote state, or empty strings when it doesn't exist. Any remote
+// change, including one made outside the manifest, changes the hash. kind is
+// "source", "transformation", "destination", or "connection"; a connection is
+// looked up by name only, so prefer ConnectionFingerprint when its config is
+// at hand.
+func (c *Checker) Fingerprint(ctx context.Context, kind, name string) (id, fingerprint string, err error) {
+	var remote interface{}
+	switch kind {
+	case "source":
+		r, err := c.fetcher.GetSourceByName(ctx, name)
+		if err != nil || r == nil {
+			return "", "", err
+		}
+		id, remote = r.ID, r
+	case "transformation":
+		r, err := c.fetcher.GetTransformationByName(ctx, name)
+		if err != nil || r == nil {
+			return "", "", err
+		}
+		id, remote = r.ID, r
+	case "destination":
+		r, err := c.fetcher.GetDestinationByName(ctx, name)
+		if err != nil || r == nil {
+			return "", "", err
+		}
+		id, remote = r.ID, r
+	case "connection":
+		r, err := c.fetcher.GetConnectionByFullName(ctx, name)
+		if err != nil || r == nil {
+			return "", "", err
+		}
+		id, remote = r.ID, r
+	default:
+		return "", "", fmt.Errorf("unknown resource kind %q", kind)
+	}
+	return fingerprintOf(id, remote)
+}
+
+// ConnectionFingerprint is Fingerprint for conn, found the way
+// FetchConnection finds it.
+func (c *Checker) ConnectionFingerprint(ctx context.Context, conn *manifest.ConnectionConfig) (id, fingerprint string, err error) {
+	remote, err := FetchConnection(ctx, c.fetcher, conn)
+	if err != nil || remote == nil {
+		return "", "", err
+	}
+	return fingerprintOf(remote.ID, remote)
+}
+
+// fingerprintOf hashes the JSON encoding of remote.
+func fingerprintOf(id string, remote interface{}) (string, string, error) {
+	data, err := json.Marshal(remote)
+	if err != nil {
+		return "", "", fmt.Errorf("encoding remote state: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return id, hex.EncodeToString(sum[:]), nil
+}
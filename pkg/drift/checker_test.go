@@ -0,0 +1,225 @@
+package drift
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// fakeFetcher serves remote state from in-memory maps.
+type fakeFetcher struct {
+	sources         map[string]*hookdeck.SourceDetail
+	transformations map[string]*hookdeck.TransformationDetail
+	destinations    map[string]*hookdeck.DestinationDetail
+	connections     map[string]*hookdeck.ConnectionDetail
+}
+
+func (f *fakeFetcher) GetSourceByName(_ context.Context, name string) (*hookdeck.SourceDetail, error) {
+	return f.sources[name], nil
+}
+
+func (f *fakeFetcher) GetDestinationByName(_ context.Context, name string) (*hookdeck.DestinationDetail, error) {
+	return f.destinations[name], nil
+}
+
+func (f *fakeFetcher) GetConnectionByFullName(_ context.Context, name string) (*hookdeck.ConnectionDetail, error) {
+	return f.connections[name], nil
+}
+
+func (f *fakeFetcher) GetTransformationByName(_ context.Context, name string) (*hookdeck.TransformationDetail, error) {
+	return f.transformations[name], nil
+}
+
+func TestChecker_Transformation(t *testing.T) {
+	checker := NewChecker(&fakeFetcher{transformations: map[string]*hookdeck.TransformationDetail{
+		"tr": {ID: "trs_1", Name: "tr", Description: "Adds headers", Code: "addHandler()"},
+	}})
+	ctx := context.Background()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tr.js"), []byte("addHandler()"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	id, unchanged, err := checker.TransformationUnchanged(ctx, &manifest.TransformationConfig{
+		Name: "tr", Description: "Adds headers", CodeFile: "tr.js",
+	}, dir)
+	if err != nil || !unchanged || id != "trs_1" {
+		t.Errorf("expected unchanged trs_1 with the same description, got id=%q unchanged=%v err=%v", id, unchanged, err)
+	}
+
+	_, unchanged, _ = checker.TransformationUnchanged(ctx, &manifest.TransformationConfig{
+		Name: "tr", Description: "Strips headers", CodeFile: "tr.js",
+	}, dir)
+	if unchanged {
+		t.Error("expected description change to be detected")
+	}
+}
+
+func TestChecker_Destination(t *testing.T) {
+	checker := NewChecker(&fakeFetcher{destinations: map[string]*hookdeck.DestinationDetail{
+		"dst": {ID: "des_1", Name: "dst", Config: hookdeck.DestinationConfigDetail{URL: "https://example.com"}},
+	}})
+	ctx := context.Background()
+
+	id, unchanged, err := checker.DestinationUnchanged(ctx, &manifest.DestinationConfig{Name: "dst", URL: "https://example.com"})
+	if err != nil || !unchanged || id != "des_1" {
+		t.Errorf("expected unchanged des_1, got id=%q unchanged=%v err=%v", id, unchanged, err)
+	}
+
+	_, unchanged, _ = checker.DestinationUnchanged(ctx, &manifest.DestinationConfig{Name: "dst", URL: "https://other.example.com"})
+	if unchanged {
+		t.Error("expected URL change to be detected")
+	}
+
+	// Auth can't be compared against the API response, so it always upserts.
+	_, unchanged, _ = checker.DestinationUnchanged(ctx, &manifest.DestinationConfig{
+		Name: "dst", URL: "https://example.com", Auth: map[string]interface{}{"api_key": "secret"},
+	})
+	if unchanged {
+		t.Error("expected destination with auth to be treated as changed")
+	}
+
+	_, unchanged, _ = checker.DestinationUnchanged(ctx, &manifest.DestinationConfig{Name: "missing"})
+	if unchanged {
+		t.Error("expected missing destination to be treated as changed")
+	}
+}
+
+func TestChecker_DestinationAuth(t *testing.T) {
+	checker := NewChecker(&fakeFetcher{destinations: map[string]*hookdeck.DestinationDetail{
+		"dst": {ID: "des_1", Name: "dst", Config: hookdeck.DestinationConfigDetail{
+			AuthType: "API_KEY", Auth: map[string]interface{}{"api_key": "secret"},
+		}},
+	}})
+	ctx := context.Background()
+
+	authType, auth, found, err := checker.DestinationAuth(ctx, "dst")
+	if err != nil || !found || authType != "API_KEY" || auth["api_key"] != "secret" {
+		t.Errorf("unexpected auth: type=%q auth=%v found=%v err=%v", authType, auth, found, err)
+	}
+
+	_, _, found, err = checker.DestinationAuth(ctx, "missing")
+	if err != nil || found {
+		t.Errorf("expected missing destination not to be found, got found=%v err=%v", found, err)
+	}
+}
+
+func TestChecker_Fingerprint(t *testing.T) {
+	fetcher := &fakeFetcher{destinations: map[string]*hookdeck.DestinationDetail{
+		"dst": {ID: "des_1", Name: "dst", Config: hookdeck.DestinationConfigDetail{URL: "https://example.com"}},
+	}}
+	checker := NewChecker(fetcher)
+	ctx := context.Background()
+
+	id, before, err := checker.Fingerprint(ctx, "destination", "dst")
+	if err != nil || id != "des_1" || before == "" {
+		t.Fatalf("unexpected fingerprint: id=%q fingerprint=%q err=%v", id, before, err)
+	}
+	if _, again, _ := checker.Fingerprint(ctx, "destination", "dst"); again != before {
+		t.Error("expected fingerprint to be stable")
+	}
+
+	fetcher.destinations["dst"].Config.URL = "https://other.example.com"
+	if _, after, _ := checker.Fingerprint(ctx, "destination", "dst"); after == before {
+		t.Error("expected a remote change to change the fingerprint")
+	}
+
+	id, fingerprint, err := checker.Fingerprint(ctx, "source", "missing")
+	if err != nil || id != "" || fingerprint != "" {
+		t.Errorf("expected empty fingerprint for missing resource, got id=%q fingerprint=%q err=%v", id, fingerprint, err)
+	}
+	if _, _, err := checker.Fingerprint(ctx, "widget", "x"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestChecker_Connection(t *testing.T) {
+	checker := NewChecker(&fakeFetcher{connections: map[string]*hookdeck.ConnectionDetail{
+		"conn": {
+			ID:          "web_1",
+			Name:        "conn",
+			Source:      &hookdeck.SourceDetail{ID: "src_1", Name: "src"},
+			Destination: &hookdeck.DestinationDetail{ID: "des_1", Name: "dst"},
+		},
+	}})
+	ctx := context.Background()
+
+	_, unchanged, _ := checker.ConnectionUnchanged(ctx, &manifest.ConnectionConfig{Name: "conn", Source: "src", Destination: "dst"})
+	if !unchanged {
+		t.Error("expected matching connection to be unchanged")
+	}
+
+	_, unchanged, _ = checker.ConnectionUnchanged(ctx, &manifest.ConnectionConfig{Name: "conn", SourceID: "src_1", Destination: "dst"})
+	if !unchanged {
+		t.Error("expected matching literal source ID to be unchanged")
+	}
+
+	_, unchanged, _ = checker.ConnectionUnchanged(ctx, &manifest.ConnectionConfig{Name: "conn", Source: "other", Destination: "dst"})
+	if unchanged {
+		t.Error("expected source change to be detected")
+	}
+
+	_, unchanged, _ = checker.ConnectionUnchanged(ctx, &manifest.ConnectionConfig{
+		Name: "conn", Source: "src", Destination: "dst", Filter: map[string]interface{}{"a": 1},
+	})
+	if unchanged {
+		t.Error("expected connection with rules to be treated as changed")
+	}
+}
+
+func TestFetchConnection_ByFullName(t *testing.T) {
+	byFullName := &hookdeck.ConnectionDetail{ID: "web_1", Name: "orders", FullName: "shop->processor"}
+	byName := &hookdeck.ConnectionDetail{ID: "web_2", Name: "legacy"}
+	f := &fakeFetcher{connections: map[string]*hookdeck.ConnectionDetail{
+		"shop->processor": byFullName,
+		"legacy":          byName,
+	}}
+	ctx := context.Background()
+
+	got, err := FetchConnection(ctx, f, &manifest.ConnectionConfig{Name: "orders", Source: "shop", Destination: "processor"})
+	if err != nil || got != byFullName {
+		t.Errorf("expected lookup by full name, got %v, %v", got, err)
+	}
+	got, _ = FetchConnection(ctx, f, &manifest.ConnectionConfig{Name: "legacy", Source: "a", Destination: "b"})
+	if got != byName {
+		t.Errorf("expected fallback to the declared name, got %v", got)
+	}
+	got, _ = FetchConnection(ctx, f, &manifest.ConnectionConfig{Name: "missing", Source: "a", Destination: "b"})
+	if got != nil {
+		t.Errorf("expected nil for a missing connection, got %v", got)
+	}
+}
+
+// Two live connections named "main" link different sources; each manifest
+// connection must resolve to its own, not whichever a name lookup returns.
+func TestFetchConnection_SharedName(t *testing.T) {
+	fetcher := &fakeFetcher{connections: map[string]*hookdeck.ConnectionDetail{
+		"shop->api":    {ID: "web_1", Name: "main", FullName: "shop->api"},
+		"billing->api": {ID: "web_2", Name: "main", FullName: "billing->api"},
+		"main":         {ID: "web_1", Name: "main"},
+	}}
+	checker := NewChecker(fetcher)
+	ctx := context.Background()
+
+	for source, wantID := range map[string]string{"shop": "web_1", "billing": "web_2"} {
+		conn := &manifest.ConnectionConfig{Name: "main", Source: source, Destination: "api"}
+		remote, err := FetchConnection(ctx, fetcher, conn)
+		if err != nil || remote == nil || remote.ID != wantID {
+			t.Errorf("source %s: expected %s, got %+v (err %v)", source, wantID, remote, err)
+		}
+		id, fingerprint, err := checker.ConnectionFingerprint(ctx, conn)
+		if err != nil || id != wantID || fingerprint == "" {
+			t.Errorf("source %s: expected a fingerprint of %s, got %q %q (err %v)", source, wantID, id, fingerprint, err)
+		}
+	}
+
+	// Without endpoint names only the declared name is left to go by.
+	remote, err := FetchConnection(ctx, fetcher, &manifest.ConnectionConfig{Name: "main", SourceID: "src_9", Destination: "api"})
+	if err != nil || remote == nil || remote.ID != "web_1" {
+		t.Errorf("expected the name lookup to find web_1, got %+v (err %v)", remote, err)
+	}
+}
@@ -4,7 +4,10 @@ package drift
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
 )
@@ -23,17 +26,17 @@ const (
 
 // Diff describes the drift status of a single resource.
 type Diff struct {
-	Kind   string      // "source", "destination", "connection", "transformation"
-	Name   string      // resource name
-	Status DriftStatus // missing, drifted, or in_sync
-	Fields []FieldDiff // populated when Status == Drifted
+	Kind   string      `json:"kind"`             // "source", "destination", "connection", "transformation"
+	Name   string      `json:"name"`             // resource name
+	Status DriftStatus `json:"status"`           // missing, drifted, or in_sync
+	Fields []FieldDiff `json:"fields,omitempty"` // populated when Status == Drifted
 }
 
 // FieldDiff describes a single field that has drifted.
 type FieldDiff struct {
-	Field  string // field name (e.g. "url", "env.KEY")
-	Local  string // value from the manifest
-	Remote string // value from the live resource
+	Field  string `json:"field"`  // field name (e.g. "url", "env.KEY")
+	Local  string `json:"local"`  // value from the manifest
+	Remote string `json:"remote"` // value from the live resource
 }
 
 // RemoteState holds the live Hookdeck resources to compare against a manifest.
@@ -52,12 +55,16 @@ type RemoteState struct {
 // The remote slices are expected to be positionally aligned with the local slices
 // (i.e., remote.Sources[0] corresponds to sources[0], etc.). A nil entry in a remote
 // slice means the resource was not found remotely.
+//
+// codeRoot is the base directory for resolving relative transformation
+// code_file paths, as in deploy.Options.CodeRoot.
 func Detect(
 	sources []*manifest.SourceConfig,
 	destinations []*manifest.DestinationConfig,
 	transformations []*manifest.TransformationConfig,
 	connections []*manifest.ConnectionConfig,
 	remote *RemoteState,
+	codeRoot string,
 ) []Diff {
 	var diffs []Diff
 
@@ -96,7 +103,7 @@ func Detect(
 		if i < len(remote.Transformations) {
 			remoteTr = remote.Transformations[i]
 		}
-		if d := detectTransformation(tr, remoteTr); d != nil {
+		if d := detectTransformation(tr, remoteTr, codeRoot); d != nil {
 			diffs = append(diffs, *d)
 		}
 	}
@@ -104,6 +111,42 @@ func Detect(
 	return diffs
 }
 
+// Values for the drift command's --fail-on option, selecting which diffs fail
+// the check. Diffs that don't fail it are still reported.
+const (
+	FailOnMissing = "missing" // only resources missing on Hookdeck
+	FailOnDrifted = "drifted" // only resources whose fields differ
+	FailOnAny     = "any"     // every diff
+)
+
+// Count returns the number of missing and drifted diffs.
+func Count(diffs []Diff) (missing, drifted int) {
+	for _, d := range diffs {
+		switch d.Status {
+		case Missing:
+			missing++
+		case Drifted:
+			drifted++
+		}
+	}
+	return missing, drifted
+}
+
+// Failing returns the number of diffs that fail a check run with failOn
+// (FailOnMissing, FailOnDrifted, or FailOnAny).
+func Failing(diffs []Diff, failOn string) (int, error) {
+	missing, drifted := Count(diffs)
+	switch failOn {
+	case FailOnMissing:
+		return missing, nil
+	case FailOnDrifted:
+		return drifted, nil
+	case FailOnAny:
+		return missing + drifted, nil
+	}
+	return 0, fmt.Errorf("unknown fail-on value %q: expected %s, %s, or %s", failOn, FailOnMissing, FailOnDrifted, FailOnAny)
+}
+
 // detectSource checks a source config against its live state.
 func detectSource(local *manifest.SourceConfig, remote *hookdeck.SourceDetail) *Diff {
 	if remote == nil {
@@ -114,9 +157,30 @@ func detectSource(local *manifest.SourceConfig, remote *hookdeck.SourceDetail) *
 	if local.Name != remote.Name {
 		fields = append(fields, FieldDiff{"name", local.Name, remote.Name})
 	}
+	if local.Type != "" && remote.Type != "" && !strings.EqualFold(local.Type, remote.Type) {
+		fields = append(fields, FieldDiff{"type", local.Type, remote.Type})
+	}
 	if local.Description != "" && local.Description != remote.Description {
 		fields = append(fields, FieldDiff{"description", local.Description, remote.Description})
 	}
+	if len(local.AllowedHTTPMethods) > 0 {
+		localMethods, remoteMethods := sortedJoin(local.AllowedHTTPMethods), sortedJoin(remote.Config.AllowedHTTPMethods)
+		if localMethods != remoteMethods {
+			fields = append(fields, FieldDiff{"allowed_http_methods", localMethods, remoteMethods})
+		}
+	}
+	if local.CustomResponse != nil {
+		var remoteResp hookdeck.CustomResponseDetail
+		if remote.Config.CustomResponse != nil {
+			remoteResp = *remote.Config.CustomResponse
+		}
+		if local.CustomResponse.ContentType != remoteResp.ContentType {
+			fields = append(fields, FieldDiff{"custom_response.content_type", local.CustomResponse.ContentType, remoteResp.ContentType})
+		}
+		if local.CustomResponse.Body != remoteResp.Body {
+			fields = append(fields, FieldDiff{"custom_response.body", local.CustomResponse.Body, remoteResp.Body})
+		}
+	}
 
 	if len(fields) > 0 {
 		return &Diff{Kind: "source", Name: local.Name, Status: Drifted, Fields: fields}
@@ -133,18 +197,29 @@ func detectDestination(local *manifest.DestinationConfig, remote *hookdeck.Desti
 	// The API returns url, auth_type, rate_limit, rate_limit_period inside config.
 	cfg := remote.Config
 	var fields []FieldDiff
+	if local.Description != "" && local.Description != remote.Description {
+		fields = append(fields, FieldDiff{"description", local.Description, remote.Description})
+	}
 	if local.URL != "" && local.URL != cfg.URL {
 		fields = append(fields, FieldDiff{"url", local.URL, cfg.URL})
 	}
 	if local.AuthType != "" && local.AuthType != cfg.AuthType {
 		fields = append(fields, FieldDiff{"auth_type", local.AuthType, cfg.AuthType})
 	}
-	if local.RateLimit != 0 && local.RateLimit != cfg.RateLimit {
+	// As in the request builder, rate_limit 0 is only "unset" when no period
+	// is given, so a concurrent limit of 0 or 1 is still compared.
+	if (local.RateLimit != 0 || local.RateLimitPeriod != "") && local.RateLimit != cfg.RateLimit {
 		fields = append(fields, FieldDiff{"rate_limit", fmt.Sprint(local.RateLimit), fmt.Sprint(cfg.RateLimit)})
 	}
 	if local.RateLimitPeriod != "" && local.RateLimitPeriod != cfg.RateLimitPeriod {
 		fields = append(fields, FieldDiff{"rate_limit_period", local.RateLimitPeriod, cfg.RateLimitPeriod})
 	}
+	if local.PathForwardingDisabled != nil && *local.PathForwardingDisabled != cfg.PathForwardingDisabled {
+		fields = append(fields, FieldDiff{"path_forwarding_disabled", fmt.Sprint(*local.PathForwardingDisabled), fmt.Sprint(cfg.PathForwardingDisabled)})
+	}
+	if local.HTTPMethod != "" && local.HTTPMethod != cfg.HTTPMethod {
+		fields = append(fields, FieldDiff{"http_method", local.HTTPMethod, cfg.HTTPMethod})
+	}
 
 	if len(fields) > 0 {
 		return &Diff{Kind: "destination", Name: local.Name, Status: Drifted, Fields: fields}
@@ -159,8 +234,25 @@ func detectConnection(local *manifest.ConnectionConfig, remote *hookdeck.Connect
 	}
 
 	var fields []FieldDiff
-	// Connection drift is currently limited to existence checks.
 	// Future: compare rules, filter, transformations.
+	if local.Description != "" && local.Description != remote.Description {
+		fields = append(fields, FieldDiff{"description", local.Description, remote.Description})
+	}
+	// A connection re-pointed in the dashboard keeps its name, so compare
+	// the endpoints it is wired to.
+	var remoteSource, remoteSourceID string
+	if remote.Source != nil {
+		remoteSource, remoteSourceID = remote.Source.Name, remote.Source.ID
+	}
+	var remoteDestination, remoteDestinationID string
+	if remote.Destination != nil {
+		remoteDestination, remoteDestinationID = remote.Destination.Name, remote.Destination.ID
+	}
+	fields = appendEndpointDiff(fields, "source", local.Source, local.SourceID, remoteSource, remoteSourceID)
+	fields = appendEndpointDiff(fields, "destination", local.Destination, local.DestinationID, remoteDestination, remoteDestinationID)
+	if remotePaused := remote.PausedAt != nil; local.Disabled != remotePaused {
+		fields = append(fields, FieldDiff{"disabled", fmt.Sprint(local.Disabled), fmt.Sprint(remotePaused)})
+	}
 
 	if len(fields) > 0 {
 		return &Diff{Kind: "connection", Name: local.Name, Status: Drifted, Fields: fields}
@@ -168,13 +260,47 @@ func detectConnection(local *manifest.ConnectionConfig, remote *hookdeck.Connect
 	return nil
 }
 
+// appendEndpointDiff adds a diff when a connection endpoint referenced by
+// name (field) or literal ID (field+"_id") doesn't match the remote one. An
+// endpoint the manifest doesn't set is not compared.
+func appendEndpointDiff(fields []FieldDiff, field, name, id, remoteName, remoteID string) []FieldDiff {
+	switch {
+	case id != "" && id != remoteID:
+		return append(fields, FieldDiff{field + "_id", id, remoteID})
+	case id == "" && name != "" && name != remoteName:
+		return append(fields, FieldDiff{field, name, remoteName})
+	}
+	return fields
+}
+
+// sortedJoin returns values sorted and comma-separated, so lists that differ
+// only in order compare equal.
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
 // detectTransformation checks a transformation config against its live state.
-func detectTransformation(local *manifest.TransformationConfig, remote *hookdeck.TransformationDetail) *Diff {
+func detectTransformation(local *manifest.TransformationConfig, remote *hookdeck.TransformationDetail, codeRoot string) *Diff {
 	if remote == nil {
 		return &Diff{Kind: "transformation", Name: local.Name, Status: Missing}
 	}
 
 	var fields []FieldDiff
+	if local.Description != "" && local.Description != remote.Description {
+		fields = append(fields, FieldDiff{"description", local.Description, remote.Description})
+	}
+
+	if local.CodeFile != "" || len(local.CodeFiles) > 0 {
+		code, err := deploy.ResolveCode(local, codeRoot)
+		if err != nil {
+			fields = append(fields, FieldDiff{"code", fmt.Sprintf("(unreadable: %v)", err), codePreview(remote.Code, 0)})
+		} else if localCode, remoteCode := normalizeCode(code), normalizeCode(remote.Code); localCode != remoteCode {
+			offset := firstDifference(localCode, remoteCode)
+			fields = append(fields, FieldDiff{"code", codePreview(localCode, offset), codePreview(remoteCode, offset)})
+		}
+	}
 
 	// Check env vars — each key defined locally must match the remote value.
 	for k, v := range local.Env {
@@ -192,3 +318,55 @@ func detectTransformation(local *manifest.TransformationConfig, remote *hookdeck
 	}
 	return nil
 }
+
+// codePreviewLen is the maximum number of characters shown from each side of
+// a code diff.
+const codePreviewLen = 40
+
+// normalizeCode removes whitespace differences that don't matter for drift:
+// CRLF line endings, trailing whitespace on each line, and leading/trailing
+// blank lines.
+func normalizeCode(code string) string {
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// firstDifference returns the byte offset of the first differing character
+// between a and b.
+func firstDifference(a, b string) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return n
+}
+
+// codePreview renders a short, single-line excerpt of code starting near
+// offset, so drift output doesn't dump whole files.
+func codePreview(code string, offset int) string {
+	start := offset - codePreviewLen/4
+	if start < 0 {
+		start = 0
+	}
+	end := start + codePreviewLen
+	if end > len(code) {
+		end = len(code)
+	}
+
+	snippet := strings.ReplaceAll(code[start:end], "\n", "\\n")
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(code) {
+		snippet += "..."
+	}
+	return fmt.Sprintf("%q (%d bytes)", snippet, len(code))
+}
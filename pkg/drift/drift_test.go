@@ -1,6 +1,11 @@
 package drift
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
@@ -13,7 +18,7 @@ func TestDetect_SourceMissing(t *testing.T) {
 		Sources: []*hookdeck.SourceDetail{nil},
 	}
 
-	diffs := Detect(sources, nil, nil, nil, remote)
+	diffs := Detect(sources, nil, nil, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -35,7 +40,106 @@ func TestDetect_SourceDescriptionDrift(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(sources, nil, nil, nil, remote)
+	diffs := Detect(sources, nil, nil, nil, remote, "")
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	if diffs[0].Status != Drifted {
+		t.Errorf("expected drifted, got %v", diffs[0].Status)
+	}
+	if len(diffs[0].Fields) != 1 || diffs[0].Fields[0].Field != "description" {
+		t.Errorf("expected description field diff, got %v", diffs[0].Fields)
+	}
+}
+
+func TestDetect_SourceTypeDrift(t *testing.T) {
+	remote := &RemoteState{
+		Sources: []*hookdeck.SourceDetail{{ID: "src_123", Name: "my-source", Type: "SHOPIFY"}},
+	}
+
+	diffs := Detect([]*manifest.SourceConfig{{Name: "my-source", Type: "WEBHOOK"}}, nil, nil, nil, remote, "")
+	if len(diffs) != 1 || len(diffs[0].Fields) != 1 || diffs[0].Fields[0].Field != "type" {
+		t.Fatalf("expected a type field diff, got %v", diffs)
+	}
+
+	diffs = Detect([]*manifest.SourceConfig{{Name: "my-source", Type: "shopify"}}, nil, nil, nil, remote, "")
+	if len(diffs) != 0 {
+		t.Errorf("expected types to compare case-insensitively, got %v", diffs)
+	}
+}
+
+func TestDetect_SourceConfigShorthandDrift(t *testing.T) {
+	sources := []*manifest.SourceConfig{{
+		Name:               "my-source",
+		AllowedHTTPMethods: []string{"PUT", "POST"},
+		CustomResponse:     &manifest.CustomResponse{ContentType: "json", Body: `{"ok":true}`},
+	}}
+	remote := &RemoteState{
+		Sources: []*hookdeck.SourceDetail{{
+			ID:   "src_123",
+			Name: "my-source",
+			Config: hookdeck.SourceConfigDetail{
+				AllowedHTTPMethods: []string{"POST", "PUT"},
+				CustomResponse:     &hookdeck.CustomResponseDetail{ContentType: "json", Body: `{"ok":false}`},
+			},
+		}},
+	}
+
+	diffs := Detect(sources, nil, nil, nil, remote, "")
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	// Method order doesn't matter; only the response body differs.
+	if len(diffs[0].Fields) != 1 || diffs[0].Fields[0].Field != "custom_response.body" {
+		t.Errorf("expected custom_response.body field diff, got %v", diffs[0].Fields)
+	}
+
+	remote.Sources[0].Config = hookdeck.SourceConfigDetail{}
+	diffs = Detect(sources, nil, nil, nil, remote, "")
+	if len(diffs) != 1 || len(diffs[0].Fields) != 3 {
+		t.Errorf("expected drift on methods and both response fields when unset remotely, got %v", diffs)
+	}
+}
+
+func TestDetect_DestinationDescriptionDrift(t *testing.T) {
+	destinations := []*manifest.DestinationConfig{{
+		Name:        "my-dest",
+		Description: "new description",
+	}}
+	remote := &RemoteState{
+		Destinations: []*hookdeck.DestinationDetail{{
+			ID:          "des_123",
+			Name:        "my-dest",
+			Description: "old description",
+		}},
+	}
+
+	diffs := Detect(nil, destinations, nil, nil, remote, "")
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	if diffs[0].Status != Drifted {
+		t.Errorf("expected drifted, got %v", diffs[0].Status)
+	}
+	if len(diffs[0].Fields) != 1 || diffs[0].Fields[0].Field != "description" {
+		t.Errorf("expected description field diff, got %v", diffs[0].Fields)
+	}
+}
+
+func TestDetect_TransformationDescriptionDrift(t *testing.T) {
+	transformations := []*manifest.TransformationConfig{{
+		Name:        "my-transform",
+		Description: "new description",
+	}}
+	remote := &RemoteState{
+		Transformations: []*hookdeck.TransformationDetail{{
+			ID:          "trs_123",
+			Name:        "my-transform",
+			Description: "old description",
+		}},
+	}
+
+	diffs := Detect(nil, nil, transformations, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -56,7 +160,7 @@ func TestDetect_DestinationMissing(t *testing.T) {
 		Destinations: []*hookdeck.DestinationDetail{nil},
 	}
 
-	diffs := Detect(nil, destinations, nil, nil, remote)
+	diffs := Detect(nil, destinations, nil, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -80,7 +184,7 @@ func TestDetect_DestinationURLDrift(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(nil, destinations, nil, nil, remote)
+	diffs := Detect(nil, destinations, nil, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -108,7 +212,7 @@ func TestDetect_DestinationRateLimitDrift(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(nil, destinations, nil, nil, remote)
+	diffs := Detect(nil, destinations, nil, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -120,13 +224,95 @@ func TestDetect_DestinationRateLimitDrift(t *testing.T) {
 	}
 }
 
+func TestDetect_DestinationConfigShorthands(t *testing.T) {
+	disabled := true
+	destinations := []*manifest.DestinationConfig{{
+		Name:                   "my-dest",
+		URL:                    "https://example.com",
+		PathForwardingDisabled: &disabled,
+		HTTPMethod:             "POST",
+	}}
+	remote := &RemoteState{
+		Destinations: []*hookdeck.DestinationDetail{{
+			ID:   "dst_123",
+			Name: "my-dest",
+			Config: hookdeck.DestinationConfigDetail{
+				URL:        "https://example.com",
+				HTTPMethod: "PUT",
+			},
+		}},
+	}
+
+	diffs := Detect(nil, destinations, nil, nil, remote, "")
+	if len(diffs) != 1 || len(diffs[0].Fields) != 2 {
+		t.Fatalf("expected 1 diff with 2 fields, got %v", diffs)
+	}
+	if diffs[0].Fields[0].Field != "path_forwarding_disabled" || diffs[0].Fields[1].Field != "http_method" {
+		t.Errorf("unexpected field diffs: %v", diffs[0].Fields)
+	}
+
+	remote.Destinations[0].Config.PathForwardingDisabled = true
+	remote.Destinations[0].Config.HTTPMethod = "POST"
+	if diffs := Detect(nil, destinations, nil, nil, remote, ""); len(diffs) != 0 {
+		t.Errorf("expected no drift, got %v", diffs)
+	}
+}
+
+func TestDetect_DestinationConcurrentRateLimit(t *testing.T) {
+	remoteWith := func(limit int) *RemoteState {
+		return &RemoteState{Destinations: []*hookdeck.DestinationDetail{{
+			ID:   "dst_123",
+			Name: "my-dest",
+			Config: hookdeck.DestinationConfigDetail{
+				URL:             "https://example.com",
+				RateLimit:       limit,
+				RateLimitPeriod: "concurrent",
+			},
+		}}}
+	}
+	tests := []struct {
+		name        string
+		local       int
+		remote      int
+		wantDrifted bool
+	}{
+		{"limit 1 in sync", 1, 1, false},
+		{"limit 1 drifted", 1, 5, true},
+		{"limit 0 in sync", 0, 0, false},
+		{"limit 0 drifted", 0, 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destinations := []*manifest.DestinationConfig{{
+				Name:            "my-dest",
+				URL:             "https://example.com",
+				RateLimit:       tt.local,
+				RateLimitPeriod: "concurrent",
+			}}
+			diffs := Detect(nil, destinations, nil, nil, remoteWith(tt.remote), "")
+			if !tt.wantDrifted {
+				if len(diffs) != 0 {
+					t.Errorf("expected no drift, got %v", diffs)
+				}
+				return
+			}
+			if len(diffs) != 1 || len(diffs[0].Fields) != 1 || diffs[0].Fields[0].Field != "rate_limit" {
+				t.Fatalf("expected a rate_limit diff, got %v", diffs)
+			}
+			if got := diffs[0].Fields[0]; got.Local != fmt.Sprint(tt.local) || got.Remote != fmt.Sprint(tt.remote) {
+				t.Errorf("unexpected values: %+v", got)
+			}
+		})
+	}
+}
+
 func TestDetect_ConnectionMissing(t *testing.T) {
 	connections := []*manifest.ConnectionConfig{{Name: "my-conn"}}
 	remote := &RemoteState{
 		Connections: []*hookdeck.ConnectionDetail{nil},
 	}
 
-	diffs := Detect(nil, nil, nil, connections, remote)
+	diffs := Detect(nil, nil, nil, connections, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -141,7 +327,7 @@ func TestDetect_TransformationMissing(t *testing.T) {
 		Transformations: []*hookdeck.TransformationDetail{nil},
 	}
 
-	diffs := Detect(nil, nil, transformations, nil, remote)
+	diffs := Detect(nil, nil, transformations, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -163,7 +349,7 @@ func TestDetect_TransformationEnvDrift(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(nil, nil, transformations, nil, remote)
+	diffs := Detect(nil, nil, transformations, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -188,7 +374,7 @@ func TestDetect_TransformationEnvMissing(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(nil, nil, transformations, nil, remote)
+	diffs := Detect(nil, nil, transformations, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -206,7 +392,7 @@ func TestDetect_NoDrift(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(sources, nil, nil, nil, remote)
+	diffs := Detect(sources, nil, nil, nil, remote, "")
 	if len(diffs) != 0 {
 		t.Errorf("expected no diffs, got %d: %v", len(diffs), diffs)
 	}
@@ -234,7 +420,7 @@ func TestDetect_AllInSync(t *testing.T) {
 		Transformations: []*hookdeck.TransformationDetail{{ID: "tr_123", Name: "my-transform"}},
 	}
 
-	diffs := Detect(sources, destinations, transformations, connections, remote)
+	diffs := Detect(sources, destinations, transformations, connections, remote, "")
 	if len(diffs) != 0 {
 		t.Errorf("expected no diffs, got %d: %v", len(diffs), diffs)
 	}
@@ -256,7 +442,7 @@ func TestDetect_MultipleDrifts(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(sources, destinations, nil, nil, remote)
+	diffs := Detect(sources, destinations, nil, nil, remote, "")
 	if len(diffs) != 2 {
 		t.Fatalf("expected 2 diffs, got %d: %v", len(diffs), diffs)
 	}
@@ -276,7 +462,7 @@ func TestDetect_EmptyManifest(t *testing.T) {
 		Sources: []*hookdeck.SourceDetail{{ID: "src_123", Name: "orphan-source"}},
 	}
 
-	diffs := Detect(nil, nil, nil, nil, remote)
+	diffs := Detect(nil, nil, nil, nil, remote, "")
 	if len(diffs) != 0 {
 		t.Errorf("expected no diffs for empty manifest, got %d: %v", len(diffs), diffs)
 	}
@@ -303,7 +489,7 @@ func TestDetect_DestinationMultipleFieldDrifts(t *testing.T) {
 		}},
 	}
 
-	diffs := Detect(nil, destinations, nil, nil, remote)
+	diffs := Detect(nil, destinations, nil, nil, remote, "")
 	if len(diffs) != 1 {
 		t.Fatalf("expected 1 diff, got %d", len(diffs))
 	}
@@ -314,3 +500,196 @@ func TestDetect_DestinationMultipleFieldDrifts(t *testing.T) {
 		t.Errorf("expected 4 field diffs, got %d: %v", len(diffs[0].Fields), diffs[0].Fields)
 	}
 }
+
+func TestDetect_TransformationCodeDrift(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "handler.js"), []byte("addHandler('transform', (req) => req.body.v2);\n"), 0o644)
+
+	transformations := []*manifest.TransformationConfig{{
+		Name:     "my-transform",
+		CodeFile: "handler.js",
+	}}
+	remote := &RemoteState{
+		Transformations: []*hookdeck.TransformationDetail{{
+			ID:   "trs_123",
+			Name: "my-transform",
+			Code: "addHandler('transform', (req) => req.body.v1);",
+		}},
+	}
+
+	diffs := Detect(nil, nil, transformations, nil, remote, dir)
+	if len(diffs) != 1 {
+		t.Fatalf("expected 1 diff, got %d", len(diffs))
+	}
+	if len(diffs[0].Fields) != 1 || diffs[0].Fields[0].Field != "code" {
+		t.Fatalf("expected code field diff, got %v", diffs[0].Fields)
+	}
+	f := diffs[0].Fields[0]
+	if !strings.Contains(f.Local, "v2") || !strings.Contains(f.Remote, "v1") {
+		t.Errorf("expected previews around the difference, got local=%s remote=%s", f.Local, f.Remote)
+	}
+}
+
+func TestDetect_TransformationCodeWhitespaceIgnored(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "handler.js"), []byte("line one  \r\nline two\n\n"), 0o644)
+
+	transformations := []*manifest.TransformationConfig{{
+		Name:     "my-transform",
+		CodeFile: "handler.js",
+	}}
+	remote := &RemoteState{
+		Transformations: []*hookdeck.TransformationDetail{{
+			ID:   "trs_123",
+			Name: "my-transform",
+			Code: "line one\nline two",
+		}},
+	}
+
+	diffs := Detect(nil, nil, transformations, nil, remote, dir)
+	if len(diffs) != 0 {
+		t.Errorf("expected no diffs for whitespace-only changes, got %v", diffs)
+	}
+}
+
+func TestCodePreview_Truncates(t *testing.T) {
+	code := strings.Repeat("a", 100) + "X" + strings.Repeat("b", 100)
+	preview := codePreview(code, 100)
+	if !strings.HasPrefix(preview, `"...`) || !strings.Contains(preview, "X") {
+		t.Errorf("unexpected preview: %s", preview)
+	}
+	if !strings.HasSuffix(preview, "(201 bytes)") {
+		t.Errorf("expected byte count suffix, got %s", preview)
+	}
+}
+
+func TestDetect_ConnectionPausedDrift(t *testing.T) {
+	connections := []*manifest.ConnectionConfig{{Name: "my-conn", Disabled: true}}
+	remote := &RemoteState{
+		Connections: []*hookdeck.ConnectionDetail{{ID: "con_1", Name: "my-conn"}},
+	}
+
+	diffs := Detect(nil, nil, nil, connections, remote, "")
+	if len(diffs) != 1 || diffs[0].Status != Drifted {
+		t.Fatalf("expected 1 drifted diff, got %v", diffs)
+	}
+	f := diffs[0].Fields[0]
+	if f.Field != "disabled" || f.Local != "true" || f.Remote != "false" {
+		t.Errorf("unexpected field diff: %+v", f)
+	}
+}
+
+func TestDetect_ConnectionDescriptionDrift(t *testing.T) {
+	connections := []*manifest.ConnectionConfig{{Name: "my-conn", Description: "new"}}
+	remote := &RemoteState{
+		Connections: []*hookdeck.ConnectionDetail{{ID: "con_1", Name: "my-conn", Description: "old"}},
+	}
+
+	diffs := Detect(nil, nil, nil, connections, remote, "")
+	if len(diffs) != 1 || diffs[0].Status != Drifted {
+		t.Fatalf("expected 1 drifted diff, got %v", diffs)
+	}
+	f := diffs[0].Fields[0]
+	if f.Field != "description" || f.Local != "new" || f.Remote != "old" {
+		t.Errorf("unexpected field diff: %+v", f)
+	}
+}
+
+func TestDiff_JSON(t *testing.T) {
+	diffs := []Diff{
+		{Kind: "source", Name: "missing-src", Status: Missing},
+		{Kind: "destination", Name: "my-dest", Status: Drifted, Fields: []FieldDiff{
+			{Field: "url", Local: "https://new.example.com", Remote: "https://old.example.com"},
+		}},
+	}
+
+	data, err := json.Marshal(diffs)
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	want := `[{"kind":"source","name":"missing-src","status":"missing"},` +
+		`{"kind":"destination","name":"my-dest","status":"drifted","fields":[` +
+		`{"field":"url","local":"https://new.example.com","remote":"https://old.example.com"}]}]`
+	if string(data) != want {
+		t.Errorf("unexpected JSON:\n got: %s\nwant: %s", data, want)
+	}
+}
+
+func TestFailing(t *testing.T) {
+	diffs := []Diff{
+		{Kind: "source", Name: "a", Status: Missing},
+		{Kind: "destination", Name: "b", Status: Drifted},
+		{Kind: "connection", Name: "c", Status: Drifted},
+	}
+	tests := []struct {
+		failOn string
+		want   int
+	}{
+		{FailOnMissing, 1},
+		{FailOnDrifted, 2},
+		{FailOnAny, 3},
+	}
+	for _, tt := range tests {
+		got, err := Failing(diffs, tt.failOn)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.failOn, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %d failing, got %d", tt.failOn, tt.want, got)
+		}
+	}
+
+	if n, _ := Failing(diffs[1:], FailOnMissing); n != 0 {
+		t.Errorf("expected drifted-only diffs to pass --fail-on missing, got %d failing", n)
+	}
+	if _, err := Failing(diffs, "everything"); err == nil {
+		t.Error("expected an error for an unknown fail-on value")
+	}
+}
+
+func TestDetect_ConnectionEndpointDrift(t *testing.T) {
+	remote := &hookdeck.ConnectionDetail{
+		ID:          "con_1",
+		Name:        "my-conn",
+		Source:      &hookdeck.SourceDetail{ID: "src_1", Name: "shop"},
+		Destination: &hookdeck.DestinationDetail{ID: "des_2", Name: "other-processor"},
+	}
+	tests := []struct {
+		name  string
+		local manifest.ConnectionConfig
+		want  []FieldDiff
+	}{
+		{"matching", manifest.ConnectionConfig{Source: "shop", Destination: "other-processor"}, nil},
+		{"re-pointed destination", manifest.ConnectionConfig{Source: "shop", Destination: "processor"},
+			[]FieldDiff{{"destination", "processor", "other-processor"}}},
+		{"literal IDs", manifest.ConnectionConfig{SourceID: "src_1", DestinationID: "des_1"},
+			[]FieldDiff{{"destination_id", "des_1", "des_2"}}},
+		{"unset endpoints are skipped", manifest.ConnectionConfig{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.local.Name = "my-conn"
+			diff := detectConnection(&tt.local, remote)
+			if tt.want == nil {
+				if diff != nil {
+					t.Errorf("expected no drift, got %+v", diff)
+				}
+				return
+			}
+			if diff == nil || len(diff.Fields) != len(tt.want) {
+				t.Fatalf("expected fields %+v, got %+v", tt.want, diff)
+			}
+			for i, f := range tt.want {
+				if diff.Fields[i] != f {
+					t.Errorf("field %d = %+v, want %+v", i, diff.Fields[i], f)
+				}
+			}
+		})
+	}
+
+	// A remote connection without endpoints drifts from a wired local one.
+	diff := detectConnection(&manifest.ConnectionConfig{Name: "my-conn", Source: "shop", Destination: "processor"}, &hookdeck.ConnectionDetail{ID: "con_1"})
+	if diff == nil || len(diff.Fields) != 2 || diff.Fields[0].Remote != "" {
+		t.Errorf("expected source and destination drift against a nil remote endpoint, got %+v", diff)
+	}
+}
@@ -0,0 +1,104 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// ConnectionLister lists and deletes live connections. *hookdeck.Client
+// satisfies it.
+type ConnectionLister interface {
+	ListConnectionsByName(ctx context.Context, name string) ([]hookdeck.ConnectionDetail, error)
+	DeleteConnection(ctx context.Context, id string) error
+}
+
+// Replacer implements deploy.ConnectionReplacer. Connections are looked up
+// by name rather than full name, since the full name changes along with the
+// endpoints being compared.
+type Replacer struct {
+	client ConnectionLister
+}
+
+// NewReplacer returns a Replacer that lists and deletes connections through
+// c.
+func NewReplacer(c ConnectionLister) *Replacer {
+	return &Replacer{client: c}
+}
+
+// IncompatibleConnection returns the live connection named like conn when it
+// links a different source or destination. It returns "" when no connection
+// has that name or one of them already links conn's endpoints, in which case
+// an upsert is enough. Several non-matching connections are an error, since
+// it isn't clear which one conn replaces.
+func (r *Replacer) IncompatibleConnection(ctx context.Context, conn *manifest.ConnectionConfig) (string, string, error) {
+	name := conn.Name
+	if name == "" {
+		name = manifest.DefaultConnectionName(conn)
+	}
+	if name == "" {
+		return "", "", nil
+	}
+	remotes, err := r.client.ListConnectionsByName(ctx, name)
+	if err != nil {
+		return "", "", err
+	}
+
+	var mismatched []hookdeck.ConnectionDetail
+	for _, remote := range remotes {
+		if remote.Name != name {
+			continue
+		}
+		reason := endpointChanges(conn, &remote)
+		if reason == "" {
+			return "", "", nil
+		}
+		mismatched = append(mismatched, remote)
+	}
+	switch len(mismatched) {
+	case 0:
+		return "", "", nil
+	case 1:
+		return mismatched[0].ID, endpointChanges(conn, &mismatched[0]), nil
+	}
+	ids := make([]string, len(mismatched))
+	for i, remote := range mismatched {
+		ids[i] = remote.ID
+	}
+	return "", "", fmt.Errorf("%d live connections are named %q (%s); delete the stale ones by hand", len(mismatched), name, strings.Join(ids, ", "))
+}
+
+// DeleteConnection deletes the live connection with the given ID.
+func (r *Replacer) DeleteConnection(ctx context.Context, id string) error {
+	return r.client.DeleteConnection(ctx, id)
+}
+
+// endpointChanges describes how remote's source and destination differ from
+// conn's, e.g. `source changed from "a" to "b"`, or returns "" when both
+// match. An endpoint missing from the API response is treated as unchanged,
+// since nothing is known to differ.
+func endpointChanges(conn *manifest.ConnectionConfig, remote *hookdeck.ConnectionDetail) string {
+	var changes []string
+	if s := remote.Source; s != nil && !endpointMatches(conn.Source, conn.SourceID, s.Name, s.ID) {
+		changes = append(changes, endpointChange("source", conn.Source, conn.SourceID, s.Name, s.ID))
+	}
+	if d := remote.Destination; d != nil && !endpointMatches(conn.Destination, conn.DestinationID, d.Name, d.ID) {
+		changes = append(changes, endpointChange("destination", conn.Destination, conn.DestinationID, d.Name, d.ID))
+	}
+	return strings.Join(changes, ", ")
+}
+
+// endpointChange describes a changed endpoint by name, or by ID when the
+// manifest references it by literal ID.
+func endpointChange(kind, name, id, remoteName, remoteID string) string {
+	if id != "" {
+		return fmt.Sprintf("%s changed from %q to %q", kind, remoteID, id)
+	}
+	if remoteName == "" {
+		remoteName = remoteID
+	}
+	return fmt.Sprintf("%s changed from %q to %q", kind, remoteName, name)
+}
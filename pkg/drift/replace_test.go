@@ -0,0 +1,109 @@
+package drift
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/hookdeck"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// fakeLister serves connections by name and records deletions.
+type fakeLister struct {
+	connections []hookdeck.ConnectionDetail
+	deleted     []string
+}
+
+func (f *fakeLister) ListConnectionsByName(_ context.Context, name string) ([]hookdeck.ConnectionDetail, error) {
+	var out []hookdeck.ConnectionDetail
+	for _, c := range f.connections {
+		if c.Name == name {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeLister) DeleteConnection(_ context.Context, id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func liveConnection(id, name, source, destination string) hookdeck.ConnectionDetail {
+	return hookdeck.ConnectionDetail{
+		ID:          id,
+		Name:        name,
+		Source:      &hookdeck.SourceDetail{ID: "src_" + source, Name: source},
+		Destination: &hookdeck.DestinationDetail{ID: "des_" + destination, Name: destination},
+	}
+}
+
+func TestReplacer_IncompatibleConnection(t *testing.T) {
+	ctx := context.Background()
+	r := NewReplacer(&fakeLister{connections: []hookdeck.ConnectionDetail{
+		liveConnection("web_1", "orders", "shop", "api"),
+	}})
+
+	tests := []struct {
+		name       string
+		conn       *manifest.ConnectionConfig
+		wantID     string
+		wantReason string
+	}{
+		{"unchanged", &manifest.ConnectionConfig{Name: "orders", Source: "shop", Destination: "api"}, "", ""},
+		{"missing", &manifest.ConnectionConfig{Name: "refunds", Source: "shop", Destination: "api"}, "", ""},
+		{"source changed", &manifest.ConnectionConfig{Name: "orders", Source: "store", Destination: "api"},
+			"web_1", `source changed from "shop" to "store"`},
+		{"both changed", &manifest.ConnectionConfig{Name: "orders", Source: "store", Destination: "backend"},
+			"web_1", `source changed from "shop" to "store", destination changed from "api" to "backend"`},
+		{"destination by ID", &manifest.ConnectionConfig{Name: "orders", Source: "shop", DestinationID: "des_other"},
+			"web_1", `destination changed from "des_api" to "des_other"`},
+		{"matching ID", &manifest.ConnectionConfig{Name: "orders", Source: "shop", DestinationID: "des_api"}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, reason, err := r.IncompatibleConnection(ctx, tt.conn)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if id != tt.wantID || reason != tt.wantReason {
+				t.Errorf("got (%q, %q), want (%q, %q)", id, reason, tt.wantID, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestReplacer_DefaultName(t *testing.T) {
+	r := NewReplacer(&fakeLister{connections: []hookdeck.ConnectionDetail{
+		liveConnection("web_1", "shop-to-api", "shop", "backend"),
+	}})
+	id, _, err := r.IncompatibleConnection(context.Background(), &manifest.ConnectionConfig{Source: "shop", Destination: "api"})
+	if err != nil || id != "web_1" {
+		t.Errorf("expected web_1 to be replaced, got id=%q err=%v", id, err)
+	}
+}
+
+// A connection with the same name on another source is left alone when one
+// of them already links the declared endpoints.
+func TestReplacer_OneMatchAmongSeveral(t *testing.T) {
+	r := NewReplacer(&fakeLister{connections: []hookdeck.ConnectionDetail{
+		liveConnection("web_1", "orders", "legacy", "api"),
+		liveConnection("web_2", "orders", "shop", "api"),
+	}})
+	id, _, err := r.IncompatibleConnection(context.Background(), &manifest.ConnectionConfig{Name: "orders", Source: "shop", Destination: "api"})
+	if err != nil || id != "" {
+		t.Errorf("expected no replacement, got id=%q err=%v", id, err)
+	}
+}
+
+func TestReplacer_AmbiguousError(t *testing.T) {
+	r := NewReplacer(&fakeLister{connections: []hookdeck.ConnectionDetail{
+		liveConnection("web_1", "orders", "legacy", "api"),
+		liveConnection("web_2", "orders", "old", "api"),
+	}})
+	_, _, err := r.IncompatibleConnection(context.Background(), &manifest.ConnectionConfig{Name: "orders", Source: "shop", Destination: "api"})
+	if err == nil || !strings.Contains(err.Error(), "web_1, web_2") {
+		t.Errorf("expected ambiguity error naming both IDs, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package glob matches slash-separated path patterns that, unlike
+// path/filepath.Match, support "**" for any number of directories.
+package glob
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Compile converts a slash-separated glob into a regular expression.
+// "*" matches within a path segment, "?" matches one non-separator
+// character, and "**" matches any number of segments (including none).
+func Compile(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// HasMeta reports whether pattern contains any glob wildcards.
+func HasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?")
+}
+
+// Expand returns the regular files matching pattern, a filesystem path that
+// may contain wildcards, in sorted order. The directories before the first
+// wildcard are walked; nothing matching is not an error. A pattern without
+// wildcards matches itself if it names a regular file.
+func Expand(pattern string) ([]string, error) {
+	if !HasMeta(pattern) {
+		if info, err := os.Stat(pattern); err == nil && info.Mode().IsRegular() {
+			return []string{pattern}, nil
+		}
+		return nil, nil
+	}
+	slashed := filepath.ToSlash(pattern)
+	segments := strings.Split(slashed, "/")
+	static := 0
+	for static < len(segments) && !HasMeta(segments[static]) {
+		static++
+	}
+	base := strings.Join(segments[:static], "/")
+	if base == "" && strings.HasPrefix(slashed, "/") {
+		base = "/"
+	} else if base == "" {
+		base = "."
+	}
+	re, err := Compile(strings.Join(segments[static:], "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	root := filepath.FromSlash(base)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		if re.MatchString(filepath.ToSlash(rel)) {
+			matches = append(matches, path)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("expanding %q: %w", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
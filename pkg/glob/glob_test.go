@@ -0,0 +1,73 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"services/payments/**", "services/payments/hookdeck.jsonc", true},
+		{"services/payments/**", "services/payments/stripe/hookdeck.jsonc", true},
+		{"services/payments/**", "services/orders/hookdeck.jsonc", false},
+		{"**/hookdeck.jsonc", "hookdeck.jsonc", true},
+		{"**/hookdeck.jsonc", "a/b/hookdeck.jsonc", true},
+		{"services/*/hookdeck.jsonc", "services/payments/hookdeck.jsonc", true},
+		{"services/*/hookdeck.jsonc", "services/payments/stripe/hookdeck.jsonc", false},
+		{"services/pay?ents/**", "services/payments/hookdeck.jsonc", true},
+		{"a.b/**", "axb/hookdeck.jsonc", false},
+	}
+	for _, tt := range tests {
+		re, err := Compile(tt.pattern)
+		if err != nil {
+			t.Fatalf("Compile(%q) failed: %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("glob %q on %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/b.js", "src/a.js", "src/lib/c.js", "src/readme.md"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"src/*.js", []string{"src/a.js", "src/b.js"}},
+		{"src/**/*.js", []string{"src/a.js", "src/b.js", "src/lib/c.js"}},
+		{"src/a.js", []string{"src/a.js"}},
+		{"src/*.ts", nil},
+		{"missing/*.js", nil},
+	}
+	for _, tt := range tests {
+		got, err := Expand(filepath.Join(dir, filepath.FromSlash(tt.pattern)))
+		if err != nil {
+			t.Fatalf("Expand(%q) failed: %v", tt.pattern, err)
+		}
+		var rel []string
+		for _, p := range got {
+			r, _ := filepath.Rel(dir, p)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		if !reflect.DeepEqual(rel, tt.want) {
+			t.Errorf("Expand(%q) = %v, want %v", tt.pattern, rel, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package hookdeck
+
+import (
+	"context"
+	"sync"
+)
+
+// CachingClient wraps a Client and memoizes the Get*ByName lookups, so each
+// distinct name is fetched at most once. It is meant to live for a single
+// command run; results (including "not found") are never persisted. Errors
+// are not cached, so a failed lookup is retried on the next call.
+type CachingClient struct {
+	*Client
+
+	mu              sync.Mutex
+	sources         map[string]*SourceDetail
+	destinations    map[string]*DestinationDetail
+	connections     map[string]*ConnectionDetail
+	transformations map[string]*TransformationDetail
+}
+
+// NewCachingClient returns a CachingClient backed by c.
+func NewCachingClient(c *Client) *CachingClient {
+	return &CachingClient{
+		Client:          c,
+		sources:         make(map[string]*SourceDetail),
+		destinations:    make(map[string]*DestinationDetail),
+		connections:     make(map[string]*ConnectionDetail),
+		transformations: make(map[string]*TransformationDetail),
+	}
+}
+
+// GetSourceByName returns the cached source detail for name, fetching it on first use.
+func (c *CachingClient) GetSourceByName(ctx context.Context, name string) (*SourceDetail, error) {
+	return cachedLookup(&c.mu, c.sources, name, func() (*SourceDetail, error) {
+		return c.Client.GetSourceByName(ctx, name)
+	})
+}
+
+// GetDestinationByName returns the cached destination detail for name, fetching it on first use.
+func (c *CachingClient) GetDestinationByName(ctx context.Context, name string) (*DestinationDetail, error) {
+	return cachedLookup(&c.mu, c.destinations, name, func() (*DestinationDetail, error) {
+		return c.Client.GetDestinationByName(ctx, name)
+	})
+}
+
+// GetConnectionByFullName returns the cached connection detail for fullName, fetching it on first use.
+func (c *CachingClient) GetConnectionByFullName(ctx context.Context, fullName string) (*ConnectionDetail, error) {
+	return cachedLookup(&c.mu, c.connections, fullName, func() (*ConnectionDetail, error) {
+		return c.Client.GetConnectionByFullName(ctx, fullName)
+	})
+}
+
+// GetTransformationByName returns the cached transformation detail for name, fetching it on first use.
+func (c *CachingClient) GetTransformationByName(ctx context.Context, name string) (*TransformationDetail, error) {
+	return cachedLookup(&c.mu, c.transformations, name, func() (*TransformationDetail, error) {
+		return c.Client.GetTransformationByName(ctx, name)
+	})
+}
+
+// cachedLookup returns cache[key] if present, otherwise calls fetch and
+// stores a successful result (a nil result records "not found").
+func cachedLookup[T any](mu *sync.Mutex, cache map[string]*T, key string, fetch func() (*T, error)) (*T, error) {
+	mu.Lock()
+	v, ok := cache[key]
+	mu.Unlock()
+	if ok {
+		return v, nil
+	}
+
+	v, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	cache[key] = v
+	mu.Unlock()
+	return v, nil
+}
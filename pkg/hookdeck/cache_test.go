@@ -0,0 +1,84 @@
+package hookdeck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCachingClient_FetchesEachNameOnce(t *testing.T) {
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		hits[name]++
+		if name == "missing" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}, "count": 0})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"models": []map[string]interface{}{{"id": "src_" + name, "name": name}},
+			"count":  1,
+		})
+	}))
+	defer srv.Close()
+
+	client := NewCachingClient(NewClient("test-key", "", WithBaseURL(srv.URL)))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		src, err := client.GetSourceByName(ctx, "shared")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if src == nil || src.ID != "src_shared" {
+			t.Fatalf("unexpected source: %+v", src)
+		}
+		missing, err := client.GetSourceByName(ctx, "missing")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if missing != nil {
+			t.Fatalf("expected nil for missing source, got %+v", missing)
+		}
+	}
+
+	if hits["shared"] != 1 {
+		t.Errorf("expected 1 request for 'shared', got %d", hits["shared"])
+	}
+	if hits["missing"] != 1 {
+		t.Errorf("expected 1 request for 'missing', got %d", hits["missing"])
+	}
+}
+
+func TestCachingClient_DoesNotCacheErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"models": []map[string]interface{}{{"id": "dst_1", "name": "my-dest"}},
+			"count":  1,
+		})
+	}))
+	defer srv.Close()
+
+	client := NewCachingClient(NewClient("test-key", "", WithBaseURL(srv.URL)))
+	if _, err := client.GetDestinationByName(context.Background(), "my-dest"); err == nil {
+		t.Fatal("expected error on first call")
+	}
+	dst, err := client.GetDestinationByName(context.Background(), "my-dest")
+	if err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if dst == nil || dst.ID != "dst_1" {
+		t.Errorf("unexpected destination: %+v", dst)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 requests, got %d", calls)
+	}
+}
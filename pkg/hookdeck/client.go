@@ -6,22 +6,52 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
 )
 
 const defaultBaseURL = "https://api.hookdeck.com/2025-07-01"
 
+// userAgentProduct is the product token sent in the User-Agent header.
+const userAgentProduct = "hookdeck-deploy-cli"
+
+// SourceIngestURL returns the Hookdeck ingest URL that receives events for
+// the source with the given ID.
+func SourceIngestURL(id string) string {
+	return fmt.Sprintf("https://hk-%s.hookdeck.com", id)
+}
+
 // Client is a concrete HTTP client for the Hookdeck API.
 type Client struct {
 	baseURL    string
 	apiKey     string
 	projectID  string
 	httpClient *http.Client
+
+	version        string   // CLI version reported in the User-Agent
+	userAgentExtra []string // caller identifiers appended to the User-Agent
+
+	logger Logger // traces requests when set
+
+	conflictRetries int // retries of an upsert rejected with 409 Conflict
+
+	retryAttempts  int           // retries of a request that failed transiently
+	retryBaseDelay time.Duration // backoff before the first retry
+	limiter        *rateLimiter  // spaces out requests when set
+	timeout        time.Duration // per-request timeout, including the body
+}
+
+// Logger receives a debug line for every API request: method, path, and
+// response status, never bodies, query values, or headers.
+type Logger interface {
+	Debugf(format string, args ...interface{})
 }
 
 // ClientOption configures the Client.
@@ -34,13 +64,93 @@ func WithBaseURL(baseURL string) ClientOption {
 	}
 }
 
-// WithHTTPClient overrides the default http.Client.
+// WithHTTPClient overrides the default http.Client, which pools connections
+// to the API host.
 func WithHTTPClient(hc *http.Client) ClientOption {
 	return func(c *Client) {
 		c.httpClient = hc
 	}
 }
 
+// WithVersion sets the CLI version reported in the User-Agent header
+// (default: "dev").
+func WithVersion(version string) ClientOption {
+	return func(c *Client) {
+		c.version = version
+	}
+}
+
+// WithUserAgent appends an identifier (e.g. "my-pipeline/1.2") to the
+// default User-Agent header.
+func WithUserAgent(ua string) ClientOption {
+	return func(c *Client) {
+		c.userAgentExtra = append(c.userAgentExtra, ua)
+	}
+}
+
+// WithConflictRetries retries an upsert rejected with 409 Conflict up to n
+// times, with jittered exponential backoff. Such conflicts come from another
+// deploy writing the same resource at the same moment; since an upsert sends
+// the full desired state, repeating it is safe.
+func WithConflictRetries(n int) ClientOption {
+	return func(c *Client) {
+		c.conflictRetries = n
+	}
+}
+
+// WithRetries retries a request that failed with 429 Too Many Requests, a
+// 5xx status, or a network error up to attempts times, with jittered
+// exponential backoff starting at baseDelay (default 500ms when zero).
+func WithRetries(attempts int, baseDelay time.Duration) ClientOption {
+	return func(c *Client) {
+		c.retryAttempts = attempts
+		if baseDelay > 0 {
+			c.retryBaseDelay = baseDelay
+		}
+	}
+}
+
+// WithRateLimit caps the client at rps requests per second, shared by all
+// concurrent callers. Zero or less means no limit.
+func WithRateLimit(rps float64) ClientOption {
+	return func(c *Client) {
+		c.limiter = newRateLimiter(rps)
+	}
+}
+
+// WithTimeout bounds each request, including reading its response body, to
+// d. Zero means no timeout beyond the caller's context.
+func WithTimeout(d time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithLogger traces each request to l at debug level.
+func WithLogger(l Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// maxIdleConnsPerHost is how many idle connections the default transport
+// keeps to the API host. It covers the concurrent lookups status and drift
+// make, where http.DefaultTransport's 2 would close the surplus connections
+// and pay for a new TCP and TLS handshake on the next request.
+const maxIdleConnsPerHost = 16
+
+// defaultHTTPClient is shared by clients created without WithHTTPClient, so
+// every client in the process reuses the same connection pool.
+var defaultHTTPClient = &http.Client{Transport: newTransport()}
+
+// newTransport returns http.DefaultTransport's settings (proxy from the
+// environment, timeouts, HTTP/2) with a larger idle pool per host.
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	return t
+}
+
 // NewClient creates a Hookdeck API client. The apiKey is required.
 // The projectID is optional (omit if the API key is scoped to one project).
 func NewClient(apiKey, projectID string, opts ...ClientOption) *Client {
@@ -48,11 +158,19 @@ func NewClient(apiKey, projectID string, opts ...ClientOption) *Client {
 		baseURL:    defaultBaseURL,
 		apiKey:     apiKey,
 		projectID:  projectID,
-		httpClient: http.DefaultClient,
+		httpClient: defaultHTTPClient,
+		version:    "dev",
 	}
+	c.retryBaseDelay = defaultRetryBaseDelay
 	for _, opt := range opts {
 		opt(c)
 	}
+	if c.timeout > 0 {
+		// A copy keeps the shared client, and its connection pool, intact.
+		hc := *c.httpClient
+		hc.Timeout = c.timeout
+		c.httpClient = &hc
+	}
 	return c
 }
 
@@ -79,12 +197,28 @@ func (c *Client) UpsertDestination(ctx context.Context, req *deploy.UpsertDestin
 }
 
 // UpsertConnection creates or updates a connection (PUT /connections).
+// When req.Paused is set and differs from the connection's current state,
+// the connection is then paused or unpaused via its dedicated endpoint.
 func (c *Client) UpsertConnection(ctx context.Context, req *deploy.UpsertConnectionRequest) (*deploy.UpsertConnectionResult, error) {
-	var result deploy.UpsertConnectionResult
+	var result struct {
+		deploy.UpsertConnectionResult
+		PausedAt *string `json:"paused_at"`
+	}
 	if err := c.put(ctx, "/connections", req, &result); err != nil {
 		return nil, err
 	}
-	return &result, nil
+
+	if req.Paused != nil && *req.Paused != (result.PausedAt != nil) {
+		action := "/unpause"
+		if *req.Paused {
+			action = "/pause"
+		}
+		var ignored json.RawMessage
+		if err := c.put(ctx, "/connections/"+url.PathEscape(result.ID)+action, struct{}{}, &ignored); err != nil {
+			return nil, fmt.Errorf("setting paused state: %w", err)
+		}
+	}
+	return &result.UpsertConnectionResult, nil
 }
 
 // UpsertTransformation creates or updates a transformation by name (PUT /transformations).
@@ -96,6 +230,18 @@ func (c *Client) UpsertTransformation(ctx context.Context, req *deploy.UpsertTra
 	return &result, nil
 }
 
+// BaseURL returns the API base URL the client sends requests to.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// Verify checks that the client's credentials are accepted by making the
+// cheapest authenticated request available (listing at most one source).
+func (c *Client) Verify(ctx context.Context) error {
+	_, err := c.get(ctx, "/sources", url.Values{"limit": {"1"}})
+	return err
+}
+
 // ---------------------------------------------------------------------------
 // Query helpers (used by the status command)
 // ---------------------------------------------------------------------------
@@ -106,6 +252,17 @@ type ResourceInfo struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
 	URL  string `json:"url,omitempty"`
+
+	// CreatedAt and UpdatedAt are the API's timestamps for the resource,
+	// zero when the response has none.
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// timestamps are the created_at and updated_at fields of every model.
+type timestamps struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // listResponse is the generic envelope returned by Hookdeck list endpoints.
@@ -119,12 +276,14 @@ type sourceModel struct {
 	ID  string `json:"id"`
 	Name string `json:"name"`
 	URL  string `json:"url"`
+	timestamps
 }
 
 // genericModel is the subset of fields we care about from most responses.
 type genericModel struct {
 	ID   string `json:"id"`
 	Name string `json:"name"`
+	timestamps
 }
 
 // connectionModel has full_name instead of name.
@@ -132,6 +291,7 @@ type connectionModel struct {
 	ID       string `json:"id"`
 	Name     string `json:"name"`
 	FullName string `json:"full_name"`
+	timestamps
 }
 
 // FindSourceByName queries GET /sources?name=<name> and returns the first match.
@@ -154,7 +314,7 @@ func (c *Client) FindSourceByName(ctx context.Context, name string) (*ResourceIn
 	if err := json.Unmarshal(list.Models[0], &src); err != nil {
 		return nil, fmt.Errorf("decoding source model: %w", err)
 	}
-	return &ResourceInfo{ID: src.ID, Name: src.Name, URL: src.URL}, nil
+	return &ResourceInfo{ID: src.ID, Name: src.Name, URL: src.URL, CreatedAt: src.CreatedAt, UpdatedAt: src.UpdatedAt}, nil
 }
 
 // FindDestinationByName queries GET /destinations?name=<name> and returns the first match.
@@ -177,7 +337,7 @@ func (c *Client) FindDestinationByName(ctx context.Context, name string) (*Resou
 	if err := json.Unmarshal(list.Models[0], &dst); err != nil {
 		return nil, fmt.Errorf("decoding destination model: %w", err)
 	}
-	return &ResourceInfo{ID: dst.ID, Name: dst.Name}, nil
+	return &ResourceInfo{ID: dst.ID, Name: dst.Name, CreatedAt: dst.CreatedAt, UpdatedAt: dst.UpdatedAt}, nil
 }
 
 // FindConnectionByFullName queries GET /connections?full_name=<name> and returns the first match.
@@ -204,7 +364,7 @@ func (c *Client) FindConnectionByFullName(ctx context.Context, fullName string)
 	if name == "" {
 		name = conn.Name
 	}
-	return &ResourceInfo{ID: conn.ID, Name: name}, nil
+	return &ResourceInfo{ID: conn.ID, Name: name, CreatedAt: conn.CreatedAt, UpdatedAt: conn.UpdatedAt}, nil
 }
 
 // FindTransformationByName queries GET /transformations?name=<name> and returns the first match.
@@ -227,7 +387,57 @@ func (c *Client) FindTransformationByName(ctx context.Context, name string) (*Re
 	if err := json.Unmarshal(list.Models[0], &tr); err != nil {
 		return nil, fmt.Errorf("decoding transformation model: %w", err)
 	}
-	return &ResourceInfo{ID: tr.ID, Name: tr.Name}, nil
+	return &ResourceInfo{ID: tr.ID, Name: tr.Name, CreatedAt: tr.CreatedAt, UpdatedAt: tr.UpdatedAt}, nil
+}
+
+// existsQueries maps a resource kind to its list endpoint and name filter.
+var existsQueries = map[string]struct{ path, param string }{
+	"source":         {"/sources", "name"},
+	"destination":    {"/destinations", "name"},
+	"transformation": {"/transformations", "name"},
+	"connection":     {"/connections", "full_name"},
+}
+
+// ExistsByName is a lightweight existence check for a resource of the given
+// kind ("source", "destination", "transformation", or "connection", matched
+// by full name). It asks for a single result and decodes only its ID,
+// returning "" when nothing matches.
+func (c *Client) ExistsByName(ctx context.Context, kind, name string) (string, error) {
+	info, err := c.FindByName(ctx, kind, name)
+	if err != nil || info == nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// FindByName is like ExistsByName but also returns the resource's
+// timestamps, or nil when nothing matches. Name is set to the name asked
+// for.
+func (c *Client) FindByName(ctx context.Context, kind, name string) (*ResourceInfo, error) {
+	q, ok := existsQueries[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource kind %q", kind)
+	}
+	params := url.Values{q.param: {name}, "limit": {"1"}}
+	body, err := c.get(ctx, q.path, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Models []struct {
+			ID string `json:"id"`
+			timestamps
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("decoding %s list: %w", kind, err)
+	}
+	if len(list.Models) == 0 {
+		return nil, nil
+	}
+	m := list.Models[0]
+	return &ResourceInfo{ID: m.ID, Name: name, CreatedAt: m.CreatedAt, UpdatedAt: m.UpdatedAt}, nil
 }
 
 // ---------------------------------------------------------------------------
@@ -236,10 +446,25 @@ func (c *Client) FindTransformationByName(ctx context.Context, name string) (*Re
 
 // SourceDetail is the full representation of a Hookdeck source.
 type SourceDetail struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	URL         string `json:"url"`
-	Description string `json:"description"`
+	ID          string             `json:"id"`
+	Name        string             `json:"name"`
+	Type        string             `json:"type"`
+	URL         string             `json:"url"`
+	Description string             `json:"description"`
+	Config      SourceConfigDetail `json:"config"`
+}
+
+// SourceConfigDetail is the config sub-object of a Hookdeck source, limited
+// to the keys the manifest has shorthands for.
+type SourceConfigDetail struct {
+	AllowedHTTPMethods []string              `json:"allowed_http_methods"`
+	CustomResponse     *CustomResponseDetail `json:"custom_response"`
+}
+
+// CustomResponseDetail is a source's custom response to webhook senders.
+type CustomResponseDetail struct {
+	ContentType string `json:"content_type"`
+	Body        string `json:"body"`
 }
 
 // DestinationDetail is the full representation of a Hookdeck destination.
@@ -259,6 +484,9 @@ type DestinationConfigDetail struct {
 	Auth            map[string]interface{} `json:"auth"`
 	RateLimit       int                    `json:"rate_limit"`
 	RateLimitPeriod string                 `json:"rate_limit_period"`
+
+	PathForwardingDisabled bool   `json:"path_forwarding_disabled"`
+	HTTPMethod             string `json:"http_method"`
 }
 
 // ConnectionDetail is the full representation of a Hookdeck connection.
@@ -266,17 +494,20 @@ type ConnectionDetail struct {
 	ID          string                   `json:"id"`
 	Name        string                   `json:"name"`
 	FullName    string                   `json:"full_name"`
+	Description string                   `json:"description"`
 	Source      *SourceDetail            `json:"source"`
 	Destination *DestinationDetail       `json:"destination"`
 	Rules       []map[string]interface{} `json:"rules"`
+	PausedAt    *string                  `json:"paused_at"`
 }
 
 // TransformationDetail is the full representation of a Hookdeck transformation.
 type TransformationDetail struct {
-	ID   string            `json:"id"`
-	Name string            `json:"name"`
-	Code string            `json:"code"`
-	Env  map[string]string `json:"env"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Code        string            `json:"code"`
+	Env         map[string]string `json:"env"`
 }
 
 // ---------------------------------------------------------------------------
@@ -343,6 +574,49 @@ func (c *Client) GetConnectionByFullName(ctx context.Context, fullName string) (
 	return &list.Models[0], nil
 }
 
+// ListConnectionsByName queries GET /connections?name=<name> and returns
+// every connection with that name. Names are only unique per source, so
+// there may be several.
+func (c *Client) ListConnectionsByName(ctx context.Context, name string) ([]ConnectionDetail, error) {
+	params := url.Values{"name": {name}}
+	body, err := c.get(ctx, "/connections", params)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Models []ConnectionDetail `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("decoding connection list: %w", err)
+	}
+	return list.Models, nil
+}
+
+// DeleteConnection deletes the connection with the given ID
+// (DELETE /connections/<id>).
+func (c *Client) DeleteConnection(ctx context.Context, id string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.baseURL+"/connections/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	c.setHeaders(req)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response body: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp.StatusCode, body)
+	}
+	return nil
+}
+
 // GetTransformationByName queries GET /transformations?name=<name> and returns full transformation details.
 func (c *Client) GetTransformationByName(ctx context.Context, name string) (*TransformationDetail, error) {
 	params := url.Values{"name": {name}}
@@ -367,18 +641,121 @@ func (c *Client) GetTransformationByName(ctx context.Context, name string) (*Tra
 // HTTP helpers
 // ---------------------------------------------------------------------------
 
-// apiError is the error body returned by the Hookdeck API.
-type apiError struct {
+// do sends req, tracing it to the logger if one is set.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	delay := c.retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+		resp, err := c.send(req)
+		if attempt >= c.retryAttempts || ctx.Err() != nil || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+		wait := jitter(delay)
+		if c.logger != nil {
+			c.logger.Debugf("%s %s failed transiently; retrying in %s (%d/%d)", req.Method, req.URL.Path, wait.Round(time.Millisecond), attempt+1, c.retryAttempts)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// send performs one attempt of req, tracing it when a logger is set.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if c.logger != nil {
+		elapsed := time.Since(start).Round(time.Millisecond)
+		if err != nil {
+			c.logger.Debugf("%s %s failed after %s", req.Method, req.URL.Path, elapsed)
+		} else {
+			c.logger.Debugf("%s %s -> %d (%s)", req.Method, req.URL.Path, resp.StatusCode, elapsed)
+		}
+	}
+	return resp, err
+}
+
+// apiErrorBody is the error body returned by the Hookdeck API.
+type apiErrorBody struct {
 	Message string `json:"message"`
 }
 
-// put sends a PUT request with a JSON body and decodes the response into out.
+// APIError is a non-2xx response from the Hookdeck API. Message is the
+// API's error message, or the raw response body when it has none.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+}
+
+// IsConflict reports whether the API rejected the request with 409 Conflict,
+// as it may when another deploy updates the same resource concurrently.
+func (e *APIError) IsConflict() bool {
+	return e.StatusCode == http.StatusConflict
+}
+
+// newAPIError builds the APIError for a response with the given status and
+// body.
+func newAPIError(status int, body []byte) *APIError {
+	var parsed apiErrorBody
+	if json.Unmarshal(body, &parsed) == nil && parsed.Message != "" {
+		return &APIError{StatusCode: status, Message: parsed.Message}
+	}
+	return &APIError{StatusCode: status, Message: string(body)}
+}
+
+// conflictBackoff is the base delay before retrying a conflicting upsert. It
+// doubles on each retry, and the actual delay is drawn at random from
+// [delay/2, delay) so concurrent deploys don't retry in lockstep.
+var conflictBackoff = 250 * time.Millisecond
+
+// put sends a PUT request and, when the client retries conflicts (see
+// WithConflictRetries), repeats it after a 409 response.
 func (c *Client) put(ctx context.Context, path string, body interface{}, out interface{}) error {
 	payload, err := json.Marshal(body)
 	if err != nil {
 		return fmt.Errorf("marshaling request body: %w", err)
 	}
 
+	delay := conflictBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.putOnce(ctx, path, payload, out)
+		var apiErr *APIError
+		if attempt >= c.conflictRetries || !errors.As(err, &apiErr) || !apiErr.IsConflict() {
+			return err
+		}
+		wait := jitter(delay)
+		if c.logger != nil {
+			c.logger.Debugf("PUT %s conflicted; retrying in %s (%d/%d)", path, wait.Round(time.Millisecond), attempt+1, c.conflictRetries)
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// putOnce sends a PUT request with a JSON payload and decodes the response
+// into out.
+func (c *Client) putOnce(ctx context.Context, path string, payload []byte, out interface{}) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+path, bytes.NewReader(payload))
 	if err != nil {
 		return fmt.Errorf("creating request: %w", err)
@@ -386,7 +763,7 @@ func (c *Client) put(ctx context.Context, path string, body interface{}, out int
 	c.setHeaders(req)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return fmt.Errorf("sending request: %w", err)
 	}
@@ -398,11 +775,7 @@ func (c *Client) put(ctx context.Context, path string, body interface{}, out int
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var apiErr apiError
-		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Message != "" {
-			return fmt.Errorf("API error %d: %s", resp.StatusCode, apiErr.Message)
-		}
-		return fmt.Errorf("API error %d: %s", resp.StatusCode, string(respBody))
+		return newAPIError(resp.StatusCode, respBody)
 	}
 
 	if err := json.Unmarshal(respBody, out); err != nil {
@@ -424,7 +797,7 @@ func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byt
 	}
 	c.setHeaders(req)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("sending request: %w", err)
 	}
@@ -436,11 +809,7 @@ func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byt
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		var apiErr apiError
-		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
-			return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, apiErr.Message)
-		}
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp.StatusCode, body)
 	}
 
 	return body, nil
@@ -450,8 +819,16 @@ func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byt
 func (c *Client) setHeaders(req *http.Request) {
 	// Hookdeck uses HTTP Basic Auth: API key as username, empty password.
 	req.SetBasicAuth(c.apiKey, "")
+	req.Header.Set("User-Agent", c.userAgent())
 
 	if c.projectID != "" {
 		req.Header.Set("X-Project-ID", c.projectID)
 	}
 }
+
+// userAgent returns the User-Agent header value: the product token and
+// version, followed by any identifiers added with WithUserAgent.
+func (c *Client) userAgent() string {
+	parts := append([]string{userAgentProduct + "/" + c.version}, c.userAgentExtra...)
+	return strings.Join(parts, " ")
+}
@@ -3,9 +3,18 @@ package hookdeck
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
 )
 
 func TestGetSourceByName(t *testing.T) {
@@ -253,6 +262,97 @@ func TestGetConnectionByFullName_NotFound(t *testing.T) {
 	}
 }
 
+func TestListConnectionsByName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/connections" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("name") != "my-conn" {
+			t.Errorf("unexpected name query: %s", r.URL.Query().Get("name"))
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"models": []map[string]interface{}{
+				{"id": "con_1", "name": "my-conn", "source": map[string]interface{}{"id": "src_1", "name": "a"}},
+				{"id": "con_2", "name": "my-conn", "source": map[string]interface{}{"id": "src_2", "name": "b"}},
+			},
+			"count": 2,
+		})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	conns, err := client.ListConnectionsByName(context.Background(), "my-conn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conns) != 2 {
+		t.Fatalf("expected 2 connections, got %d", len(conns))
+	}
+	if conns[0].ID != "con_1" || conns[1].ID != "con_2" {
+		t.Errorf("unexpected ids: %s, %s", conns[0].ID, conns[1].ID)
+	}
+	if conns[1].Source == nil || conns[1].Source.Name != "b" {
+		t.Errorf("expected second connection's source b, got %+v", conns[1].Source)
+	}
+}
+
+func TestListConnectionsByName_NotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"models": []interface{}{},
+			"count":  0,
+		})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	conns, err := client.ListConnectionsByName(context.Background(), "nonexistent")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(conns) != 0 {
+		t.Errorf("expected no connections, got %+v", conns)
+	}
+}
+
+func TestDeleteConnection(t *testing.T) {
+	var gotMethod, gotPath string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.EscapedPath()
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "con_1"})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	if err := client.DeleteConnection(context.Background(), "con/1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotMethod != http.MethodDelete {
+		t.Errorf("expected DELETE, got %s", gotMethod)
+	}
+	if gotPath != "/connections/con%2F1" {
+		t.Errorf("expected escaped id in path, got %s", gotPath)
+	}
+}
+
+func TestDeleteConnection_APIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"not found"}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	err := client.DeleteConnection(context.Background(), "con_missing")
+	if err == nil {
+		t.Fatal("expected error for 404 response")
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected an APIError with status 404, got %v", err)
+	}
+}
+
 func TestGetTransformationByName(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/transformations" {
@@ -364,3 +464,329 @@ func TestGetSourceByName_SetsAuthHeaders(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestUpsertConnection_PausesWhenRequested(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		if r.URL.Path == "/connections" {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if _, ok := body["paused"]; ok {
+				t.Error("paused must not be sent in the upsert body")
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "con_1", "name": "my-conn", "paused_at": nil})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "con_1"})
+	}))
+	defer srv.Close()
+
+	paused := true
+	name := "my-conn"
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	result, err := client.UpsertConnection(context.Background(), &deploy.UpsertConnectionRequest{Name: &name, Paused: &paused})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.ID != "con_1" {
+		t.Errorf("expected id con_1, got %s", result.ID)
+	}
+	if len(paths) != 2 || paths[1] != "PUT /connections/con_1/pause" {
+		t.Errorf("expected upsert followed by pause, got %v", paths)
+	}
+}
+
+func TestUpsertConnection_SkipsPauseWhenStateMatches(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "con_1", "name": "my-conn", "paused_at": nil})
+	}))
+	defer srv.Close()
+
+	paused := false
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	if _, err := client.UpsertConnection(context.Background(), &deploy.UpsertConnectionRequest{Paused: &paused}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected only the upsert call, got %d calls", calls)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sources" || r.URL.Query().Get("limit") != "1" {
+			t.Errorf("unexpected request: %s", r.URL.String())
+		}
+		if user, _, _ := r.BasicAuth(); user != "good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"message": "Unauthorized"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}, "count": 0})
+	}))
+	defer srv.Close()
+
+	if err := NewClient("good-key", "", WithBaseURL(srv.URL)).Verify(context.Background()); err != nil {
+		t.Errorf("expected valid key to verify, got %v", err)
+	}
+	if err := NewClient("bad-key", "", WithBaseURL(srv.URL)).Verify(context.Background()); err == nil {
+		t.Error("expected invalid key to fail verification")
+	}
+}
+
+func TestExistsByName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("limit") != "1" {
+			t.Errorf("expected limit=1, got %s", r.URL.String())
+		}
+		if r.URL.Path == "/connections" && r.URL.Query().Get("full_name") == "src -> dst" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"models": []map[string]interface{}{{"id": "web_123", "name": "conn"}},
+				"count":  1,
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}, "count": 0})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	id, err := client.ExistsByName(context.Background(), "connection", "src -> dst")
+	if err != nil || id != "web_123" {
+		t.Errorf("expected web_123, got %q (err %v)", id, err)
+	}
+	id, err = client.ExistsByName(context.Background(), "destination", "missing")
+	if err != nil || id != "" {
+		t.Errorf("expected empty id for missing destination, got %q (err %v)", id, err)
+	}
+	if _, err := client.ExistsByName(context.Background(), "webhook", "x"); err == nil {
+		t.Error("expected error for unknown kind")
+	}
+}
+
+func TestFindByName_DecodesTimestamps(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"models": []map[string]interface{}{{
+				"id":         "src_1",
+				"name":       "s",
+				"url":        "https://hkdk.example.com/src_1",
+				"created_at": "2024-04-01T08:00:00.000Z",
+				"updated_at": "2024-05-01T10:00:00.000Z",
+			}},
+			"count": 1,
+		})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	wantCreated := time.Date(2024, 4, 1, 8, 0, 0, 0, time.UTC)
+	wantUpdated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	info, err := client.FindByName(context.Background(), "destination", "s")
+	if err != nil || info == nil {
+		t.Fatalf("FindByName: got %+v (err %v)", info, err)
+	}
+	if info.ID != "src_1" || !info.CreatedAt.Equal(wantCreated) || !info.UpdatedAt.Equal(wantUpdated) {
+		t.Errorf("FindByName: got %+v", info)
+	}
+
+	info, err = client.FindSourceByName(context.Background(), "s")
+	if err != nil || info == nil {
+		t.Fatalf("FindSourceByName: got %+v (err %v)", info, err)
+	}
+	if !info.UpdatedAt.Equal(wantUpdated) || info.URL == "" {
+		t.Errorf("FindSourceByName: got %+v", info)
+	}
+}
+
+func TestUserAgent_SentOnGetAndPut(t *testing.T) {
+	var agents []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents = append(agents, r.Method+" "+r.Header.Get("User-Agent"))
+		if r.Method == http.MethodPut {
+			json.NewEncoder(w).Encode(map[string]interface{}{"id": "src_1", "name": "s"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}, "count": 0})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL), WithVersion("1.2.3"), WithUserAgent("ci-runner/7"))
+	if _, err := client.GetSourceByName(context.Background(), "s"); err != nil {
+		t.Fatalf("GetSourceByName failed: %v", err)
+	}
+	if _, err := client.UpsertSource(context.Background(), &deploy.UpsertSourceRequest{Name: "s"}); err != nil {
+		t.Fatalf("UpsertSource failed: %v", err)
+	}
+
+	want := []string{
+		"GET hookdeck-deploy-cli/1.2.3 ci-runner/7",
+		"PUT hookdeck-deploy-cli/1.2.3 ci-runner/7",
+	}
+	if len(agents) != len(want) {
+		t.Fatalf("expected %d requests, got %v", len(want), agents)
+	}
+	for i := range want {
+		if agents[i] != want[i] {
+			t.Errorf("request %d: expected %q, got %q", i, want[i], agents[i])
+		}
+	}
+}
+
+func TestUserAgent_Default(t *testing.T) {
+	client := NewClient("test-key", "")
+	if got := client.userAgent(); got != "hookdeck-deploy-cli/dev" {
+		t.Errorf("expected default user agent, got %q", got)
+	}
+}
+
+// recordingLogger collects Debugf lines.
+type recordingLogger struct{ lines []string }
+
+func (l *recordingLogger) Debugf(format string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, args...))
+}
+
+func TestClient_WithLoggerTracesRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}, "count": 0})
+	}))
+	defer srv.Close()
+
+	logger := &recordingLogger{}
+	client := NewClient("secret-key", "", WithBaseURL(srv.URL), WithLogger(logger))
+	if _, err := client.GetSourceByName(context.Background(), "secret-name"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(logger.lines) != 1 || !strings.HasPrefix(logger.lines[0], "GET /sources -> 200 (") {
+		t.Fatalf("expected one GET /sources trace, got %q", logger.lines)
+	}
+	if strings.Contains(logger.lines[0], "secret") {
+		t.Errorf("trace leaked a query value or key: %q", logger.lines[0])
+	}
+}
+
+// countConnections runs waves of concurrent lookups through client options
+// opts and returns how many TCP connections the server accepted.
+func countConnections(t *testing.T, waves, perWave int, opts ...ClientOption) int64 {
+	t.Helper()
+	var wave atomic.Pointer[sync.WaitGroup]
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Hold every request until the whole wave has arrived, so the
+		// wave really needs perWave connections at once.
+		wg := wave.Load()
+		wg.Done()
+		wg.Wait()
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}})
+	}))
+	var conns atomic.Int64
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	client := NewClient("test-key", "", append([]ClientOption{WithBaseURL(srv.URL)}, opts...)...)
+	for i := 0; i < waves; i++ {
+		wg := &sync.WaitGroup{}
+		wg.Add(perWave)
+		wave.Store(wg)
+		var done sync.WaitGroup
+		for j := 0; j < perWave; j++ {
+			done.Add(1)
+			go func() {
+				defer done.Done()
+				if _, err := client.ExistsByName(context.Background(), "source", "src"); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+		}
+		done.Wait()
+	}
+	return conns.Load()
+}
+
+func TestClient_DefaultTransportReusesConnections(t *testing.T) {
+	// Three waves of 8 concurrent lookups: the default client reuses the
+	// first wave's connections, while a transport with Go's default idle
+	// limit of 2 per host redials most of them on every wave.
+	// A connection goes back to the pool just after its response is read,
+	// so allow for the odd one the next wave dials before that happens.
+	pooled := countConnections(t, 3, 8)
+	if pooled > 10 {
+		t.Errorf("expected the default client to reuse its 8 connections, got %d", pooled)
+	}
+
+	unpooled := &http.Transport{}
+	defer unpooled.CloseIdleConnections()
+	if n := countConnections(t, 3, 8, WithHTTPClient(&http.Client{Transport: unpooled})); n <= pooled {
+		t.Errorf("expected more connections without pooling, got %d (pooled: %d)", n, pooled)
+	}
+}
+
+func TestUpsert_RetriesOnConflict(t *testing.T) {
+	defer func(d time.Duration) { conflictBackoff = d }(conflictBackoff)
+	conflictBackoff = time.Millisecond
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"message": "resource was modified"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id": "src_1", "name": "src"})
+	}))
+	defer srv.Close()
+
+	// Without retries the conflict surfaces as a typed error.
+	client := NewClient("test-key", "", WithBaseURL(srv.URL))
+	_, err := client.UpsertSource(context.Background(), &deploy.UpsertSourceRequest{Name: "src"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !apiErr.IsConflict() || apiErr.Message != "resource was modified" {
+		t.Fatalf("expected a conflict APIError, got %v", err)
+	}
+
+	calls.Store(0)
+	client = NewClient("test-key", "", WithBaseURL(srv.URL), WithConflictRetries(3))
+	res, err := client.UpsertSource(context.Background(), &deploy.UpsertSourceRequest{Name: "src"})
+	if err != nil {
+		t.Fatalf("expected the retry to succeed, got %v", err)
+	}
+	if res.ID != "src_1" || calls.Load() != 2 {
+		t.Errorf("expected success on the second attempt, got %+v after %d calls", res, calls.Load())
+	}
+}
+
+func TestUpsert_ConflictRetriesAreBounded(t *testing.T) {
+	defer func(d time.Duration) { conflictBackoff = d }(conflictBackoff)
+	conflictBackoff = time.Millisecond
+
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL), WithConflictRetries(2))
+	if _, err := client.UpsertSource(context.Background(), &deploy.UpsertSourceRequest{Name: "src"}); err == nil {
+		t.Fatal("expected the conflict to be returned once retries run out")
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 1 attempt plus 2 retries, got %d", calls.Load())
+	}
+}
+
+func TestSourceIngestURL(t *testing.T) {
+	if got, want := SourceIngestURL("src_123"), "https://hk-src_123.hookdeck.com"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,87 @@
+package hookdeck
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultRetryBaseDelay is the backoff before the first retry when
+// WithRetries is given no base delay.
+const defaultRetryBaseDelay = 500 * time.Millisecond
+
+// retryable reports whether a request that got resp and err is worth
+// repeating: a network error or timeout, 429 Too Many Requests, or a 5xx
+// status. Upserts send the full desired state, so repeating one is as safe as
+// repeating a lookup. The caller checks its own context separately.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// rewind returns a copy of req whose body can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	next := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		next.Body = body
+	}
+	return next, nil
+}
+
+// jitter draws a delay at random from [delay/2, delay), so concurrent
+// clients backing off from the same failure don't retry in lockstep.
+func jitter(delay time.Duration) time.Duration {
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// rateLimiter spaces requests at least interval apart. A nil *rateLimiter
+// never waits.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // earliest time the next request may start
+}
+
+// newRateLimiter returns a limiter allowing rps requests per second, or nil
+// when rps is not positive.
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+// wait blocks until the caller's turn to send a request, or until ctx is
+// done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+package hookdeck
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+)
+
+func TestClient_RetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int64
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			json.NewEncoder(w).Encode(map[string]string{"id": "src_1", "name": "src"})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL), WithRetries(2, time.Millisecond))
+	res, err := client.UpsertSource(context.Background(), &deploy.UpsertSourceRequest{Name: "src"})
+	if err != nil {
+		t.Fatalf("expected the retries to succeed, got %v", err)
+	}
+	if res.ID != "src_1" || calls.Load() != 3 {
+		t.Errorf("expected success on the third attempt, got %+v after %d calls", res, calls.Load())
+	}
+	for i, body := range bodies {
+		if body != bodies[0] || body == "" {
+			t.Errorf("attempt %d sent body %q, want %q", i+1, body, bodies[0])
+		}
+	}
+}
+
+func TestClient_RetriesAreBoundedAndSkipClientErrors(t *testing.T) {
+	var calls atomic.Int64
+	status := http.StatusInternalServerError
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL), WithRetries(2, time.Millisecond))
+	_, err := client.GetSourceByName(context.Background(), "src")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected the last 500 to surface, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 1 attempt plus 2 retries, got %d calls", calls.Load())
+	}
+
+	calls.Store(0)
+	status = http.StatusNotFound
+	if _, err := client.GetSourceByName(context.Background(), "src"); err == nil {
+		t.Fatal("expected 404 to fail")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected a 404 not to be retried, got %d calls", calls.Load())
+	}
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": []interface{}{}, "count": 0})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL), WithRateLimit(50))
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := client.GetSourceByName(context.Background(), "src"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// At 50 requests per second, requests 2 to 5 each wait 20ms.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("expected 5 requests at 50/s to take at least 80ms, took %s", elapsed)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-key", "", WithBaseURL(srv.URL), WithTimeout(20*time.Millisecond))
+	if _, err := client.GetSourceByName(context.Background(), "src"); err == nil {
+		t.Fatal("expected the request to time out")
+	}
+	if defaultHTTPClient.Timeout != 0 {
+		t.Errorf("expected the shared client to keep no timeout, got %s", defaultHTTPClient.Timeout)
+	}
+}
@@ -0,0 +1,120 @@
+// Package logging is a minimal leveled logger for the CLI's diagnostic
+// output. Info lines are written as-is; debug, warning, and error lines get
+// a prefix, so the default output reads like plain progress messages.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Level is the minimum severity a Logger writes.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// levelNames maps each Level to the name ParseLevel accepts.
+var levelNames = map[Level]string{
+	LevelDebug: "debug",
+	LevelInfo:  "info",
+	LevelWarn:  "warn",
+	LevelError: "error",
+}
+
+func (l Level) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel parses a level name: debug, info, warn (or warning), or error.
+// Matching is case-insensitive.
+func ParseLevel(s string) (Level, error) {
+	name := strings.ToLower(s)
+	if name == "warning" {
+		name = "warn"
+	}
+	for level, n := range levelNames {
+		if n == name {
+			return level, nil
+		}
+	}
+	return LevelInfo, fmt.Errorf("unknown log level %q: expected debug, info, warn, or error", s)
+}
+
+// Logger writes leveled lines to a writer. It is safe for concurrent use.
+type Logger struct {
+	mu    sync.Mutex
+	w     io.Writer
+	level Level
+}
+
+// New returns a Logger that writes lines at level or above to w.
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{w: w, level: level}
+}
+
+// SetOutput changes the writer lines are written to and returns the previous
+// one.
+func (l *Logger) SetOutput(w io.Writer) io.Writer {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev := l.w
+	l.w = w
+	return prev
+}
+
+// SetLevel changes the minimum level written.
+func (l *Logger) SetLevel(level Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = level
+}
+
+// Enabled reports whether lines at level are written.
+func (l *Logger) Enabled(level Level) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return level >= l.level
+}
+
+// Debugf writes a "debug: " line.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.logf(LevelDebug, "debug: ", format, args...)
+}
+
+// Infof writes a line without a prefix.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.logf(LevelInfo, "", format, args...)
+}
+
+// Warnf writes a "Warning: " line.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.logf(LevelWarn, "Warning: ", format, args...)
+}
+
+// Errorf writes an "Error: " line.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.logf(LevelError, "Error: ", format, args...)
+}
+
+func (l *Logger) logf(level Level, prefix, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level < l.level {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	io.WriteString(l.w, prefix+msg)
+}
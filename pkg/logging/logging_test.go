@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLogger_Levels(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, LevelInfo)
+	l.Debugf("hidden %d", 1)
+	l.Infof("Loading manifest: %s", "hookdeck.jsonc")
+	l.Warnf("wrangler sync failed: %v", "boom")
+	l.Errorf("deploy failed\n")
+
+	want := "Loading manifest: hookdeck.jsonc\nWarning: wrangler sync failed: boom\nError: deploy failed\n"
+	if buf.String() != want {
+		t.Errorf("unexpected output:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	l.SetLevel(LevelDebug)
+	l.Debugf("GET %s -> %d", "/sources", 200)
+	if buf.String() != "debug: GET /sources -> 200\n" {
+		t.Errorf("unexpected debug output: %q", buf.String())
+	}
+
+	buf.Reset()
+	l.SetLevel(LevelError)
+	l.Infof("progress")
+	l.Warnf("warning")
+	if buf.Len() != 0 {
+		t.Errorf("expected only errors at error level, got %q", buf.String())
+	}
+	if l.Enabled(LevelWarn) || !l.Enabled(LevelError) {
+		t.Error("unexpected Enabled result at error level")
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Level
+	}{
+		{"debug", LevelDebug},
+		{"INFO", LevelInfo},
+		{"warn", LevelWarn},
+		{"warning", LevelWarn},
+		{"error", LevelError},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, %v; want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an error for an unknown level")
+	}
+}
+
+func TestLogger_SetOutput(t *testing.T) {
+	var first, second bytes.Buffer
+	l := New(&first, LevelInfo)
+	if prev := l.SetOutput(&second); prev != &first {
+		t.Errorf("SetOutput returned %v, want the previous writer", prev)
+	}
+	l.Infof("moved")
+	if first.Len() != 0 || second.String() != "moved\n" {
+		t.Errorf("first = %q, second = %q", first.String(), second.String())
+	}
+}
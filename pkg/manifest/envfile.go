@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadEnvFiles reads .env and, when envName is set, .env.<envName> from dir
+// and returns the combined variables. Values from .env.<envName> override
+// those from .env. Missing files are skipped silently.
+func LoadEnvFiles(dir, envName string) (map[string]string, error) {
+	paths := []string{filepath.Join(dir, ".env")}
+	if envName != "" {
+		paths = append(paths, filepath.Join(dir, ".env."+envName))
+	}
+
+	vars := make(map[string]string)
+	for _, path := range paths {
+		fileVars, err := ParseEnvFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	return vars, nil
+}
+
+// ParseEnvFile reads a dotenv-style file of KEY=VALUE lines. Blank lines and
+// lines starting with # are ignored, an optional leading "export " is
+// stripped, and values may be single- or double-quoted. The returned error
+// satisfies os.IsNotExist when the file is missing.
+func ParseEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNo)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: invalid quoted value: %w", path, lineNo, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			// Strip trailing inline comments from unquoted values.
+			if i := strings.Index(value, " #"); i >= 0 {
+				value = strings.TrimSpace(value[:i])
+			}
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return vars, nil
+}
@@ -0,0 +1,91 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	content := `# comment
+REGION=eu-west-1
+export API_URL="https://api.example.com"
+QUOTED='single # not a comment'
+INLINE=value # trailing comment
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := ParseEnvFile(path)
+	if err != nil {
+		t.Fatalf("ParseEnvFile failed: %v", err)
+	}
+	expected := map[string]string{
+		"REGION":  "eu-west-1",
+		"API_URL": "https://api.example.com",
+		"QUOTED":  "single # not a comment",
+		"INLINE":  "value",
+		"EMPTY":   "",
+	}
+	for k, want := range expected {
+		if got, ok := vars[k]; !ok || got != want {
+			t.Errorf("%s: expected %q, got %q (present: %v)", k, want, got, ok)
+		}
+	}
+}
+
+func TestParseEnvFile_InvalidLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	if err := os.WriteFile(path, []byte("NOT_AN_ASSIGNMENT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseEnvFile(path); err == nil {
+		t.Fatal("expected error for line without '='")
+	}
+}
+
+func TestLoadEnvFiles_EnvSpecificOverridesBase(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".env"), []byte("REGION=eu\nTIER=base\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".env.production"), []byte("TIER=prod\n"), 0644)
+
+	vars, err := LoadEnvFiles(dir, "production")
+	if err != nil {
+		t.Fatalf("LoadEnvFiles failed: %v", err)
+	}
+	if vars["REGION"] != "eu" || vars["TIER"] != "prod" {
+		t.Errorf("unexpected vars: %v", vars)
+	}
+}
+
+func TestLoadEnvFiles_MissingFilesSkipped(t *testing.T) {
+	vars, err := LoadEnvFiles(t.TempDir(), "staging")
+	if err != nil {
+		t.Fatalf("LoadEnvFiles failed: %v", err)
+	}
+	if len(vars) != 0 {
+		t.Errorf("expected no vars, got %v", vars)
+	}
+}
+
+func TestInterpolateVars_ProcessEnvBeatsFileVars(t *testing.T) {
+	t.Setenv("TEST_REGION", "from-env")
+
+	m := &Manifest{
+		Destinations: []DestinationConfig{
+			{Name: "d1", URL: "https://${TEST_REGION}.example.com/${TEST_PATH}"},
+		},
+	}
+	fileVars := map[string]string{"TEST_REGION": "from-file", "TEST_PATH": "hooks"}
+	if err := InterpolateVars(m, ChainLookup(os.LookupEnv, MapLookup(fileVars))); err != nil {
+		t.Fatalf("InterpolateVars failed: %v", err)
+	}
+	if m.Destinations[0].URL != "https://from-env.example.com/hooks" {
+		t.Errorf("unexpected URL: %s", m.Destinations[0].URL)
+	}
+}
@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -15,15 +16,172 @@ func LoadFile(path string) (*Manifest, error) {
 		return nil, fmt.Errorf("reading manifest: %w", err)
 	}
 
-	standardized, err := hujson.Standardize(data)
+	tree, err := hujson.Parse(data)
 	if err != nil {
 		return nil, fmt.Errorf("parsing JSONC: %w", err)
 	}
+	lines := resourceLines(data, tree)
+	tree.Standardize()
 
 	var m Manifest
-	if err := json.Unmarshal(standardized, &m); err != nil {
+	if err := json.Unmarshal(tree.Pack(), &m); err != nil {
 		return nil, fmt.Errorf("unmarshaling manifest: %w", err)
 	}
+	m.lines = lines
+
+	if err := applyRulesMerge(&m); err != nil {
+		return nil, err
+	}
+	if err := validateEndpointRefs(&m); err != nil {
+		return nil, err
+	}
+	if err := validateNameTemplates(&m); err != nil {
+		return nil, err
+	}
+	if err := validateCodeFiles(&m); err != nil {
+		return nil, err
+	}
+	if err := validateUniqueNames(&m); err != nil {
+		return nil, err
+	}
 
 	return &m, nil
 }
+
+// resourceLines returns the line each element of the top-level resource
+// arrays starts on in data, which tree was parsed from.
+func resourceLines(data []byte, tree hujson.Value) map[string][]int {
+	obj, ok := tree.Value.(*hujson.Object)
+	if !ok {
+		return nil
+	}
+	lines := map[string][]int{}
+	for _, member := range obj.Members {
+		name, ok := member.Name.Value.(hujson.Literal)
+		if !ok {
+			continue
+		}
+		arr, ok := member.Value.Value.(*hujson.Array)
+		if !ok {
+			continue
+		}
+		key := name.String()
+		for _, elem := range arr.Elements {
+			lines[key] = append(lines[key], 1+bytes.Count(data[:elem.StartOffset], []byte("\n")))
+		}
+	}
+	return lines
+}
+
+// applyRulesMerge validates rules_merge settings and propagates the
+// manifest-level default to connections that don't set their own.
+func applyRulesMerge(m *Manifest) error {
+	if !validRulesMerge(m.RulesMerge) {
+		return fmt.Errorf("invalid rules_merge %q: must be %q or %q", m.RulesMerge, RulesMergeReplace, RulesMergeByType)
+	}
+	for i := range m.Connections {
+		conn := &m.Connections[i]
+		if !validRulesMerge(conn.RulesMerge) {
+			return fmt.Errorf("connection %q: invalid rules_merge %q: must be %q or %q", conn.Name, conn.RulesMerge, RulesMergeReplace, RulesMergeByType)
+		}
+		if conn.RulesMerge == "" {
+			conn.RulesMerge = m.RulesMerge
+		}
+	}
+	return nil
+}
+
+func validRulesMerge(mode string) bool {
+	return mode == "" || mode == RulesMergeReplace || mode == RulesMergeByType
+}
+
+// validateEndpointRefs rejects connections that reference the same endpoint
+// both by name and by literal ID.
+func validateEndpointRefs(m *Manifest) error {
+	for _, conn := range m.Connections {
+		if conn.Source != "" && conn.SourceID != "" {
+			return fmt.Errorf("connection %q: source and source_id are mutually exclusive", conn.Name)
+		}
+		if conn.Destination != "" && conn.DestinationID != "" {
+			return fmt.Errorf("connection %q: destination and destination_id are mutually exclusive", conn.Name)
+		}
+	}
+	return nil
+}
+
+// validateNameTemplates renders each connection's name template for the base
+// config and every env overlay, so template mistakes fail at load time rather
+// than producing a literal "{{...}}" connection name.
+func validateNameTemplates(m *Manifest) error {
+	for i := range m.Connections {
+		conn := &m.Connections[i]
+		if _, err := RenderConnectionName(conn); err != nil {
+			return fmt.Errorf("connection %q: %w", conn.Name, err)
+		}
+		for envName := range conn.Env {
+			if _, err := RenderConnectionName(resolveConnectionOverrides(conn, envName)); err != nil {
+				return fmt.Errorf("connection %q (env %s): %w", conn.Name, envName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCodeFiles rejects transformations (or their overrides) that set
+// both code_file and code_files.
+func validateCodeFiles(m *Manifest) error {
+	for _, tr := range m.Transformations {
+		if tr.CodeFile != "" && len(tr.CodeFiles) > 0 {
+			return fmt.Errorf("transformation %q: code_file and code_files are mutually exclusive", tr.Name)
+		}
+		for envName, override := range tr.EnvOverrides {
+			if override != nil && override.CodeFile != "" && len(override.CodeFiles) > 0 {
+				return fmt.Errorf("transformation %q (env %s): code_file and code_files are mutually exclusive", tr.Name, envName)
+			}
+		}
+	}
+	return nil
+}
+
+// validateUniqueNames rejects a manifest that declares two resources of the
+// same kind with the same name, which would otherwise be upserted twice with
+// the second silently winning. Connections are compared by their
+// ConnectionFullName, as Hookdeck matches them, so connections sharing a
+// name but linking different endpoints are allowed; one referencing an
+// endpoint by ID falls back to its rendered (or derived) name.
+func validateUniqueNames(m *Manifest) error {
+	seen := map[string]bool{}
+	check := func(kind, name string) error {
+		key := kind + "/" + name
+		if seen[key] {
+			return fmt.Errorf("duplicate %s %q: defined more than once in the manifest", kind, name)
+		}
+		seen[key] = true
+		return nil
+	}
+	for _, src := range m.Sources {
+		if err := check("source", src.Name); err != nil {
+			return err
+		}
+	}
+	for _, dst := range m.Destinations {
+		if err := check("destination", dst.Name); err != nil {
+			return err
+		}
+	}
+	for _, tr := range m.Transformations {
+		if err := check("transformation", tr.Name); err != nil {
+			return err
+		}
+	}
+	for i := range m.Connections {
+		conn := m.Connections[i]
+		if name, err := RenderConnectionName(&conn); err == nil {
+			conn.Name = name
+		}
+		if err := check("connection", ConnectionFullName(&conn)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package manifest
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -70,3 +71,152 @@ func TestLoadFile_InvalidJSON(t *testing.T) {
 		t.Fatal("expected error for invalid JSON")
 	}
 }
+
+func TestLoadFile_RulesMergeDefaultPropagates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hookdeck.jsonc")
+	content := `{
+		"rules_merge": "by_type",
+		"connections": [
+			{"name": "c1", "source": "s1"},
+			{"name": "c2", "source": "s1", "rules_merge": "replace"}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if m.Connections[0].RulesMerge != RulesMergeByType {
+		t.Errorf("expected c1 to inherit by_type, got %q", m.Connections[0].RulesMerge)
+	}
+	if m.Connections[1].RulesMerge != RulesMergeReplace {
+		t.Errorf("expected c2 to keep replace, got %q", m.Connections[1].RulesMerge)
+	}
+}
+
+func TestLoadFile_RulesMergeInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hookdeck.jsonc")
+	content := `{"connections": [{"name": "c1", "source": "s1", "rules_merge": "append"}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Fatal("expected error for invalid rules_merge")
+	}
+}
+
+func TestLoadFile_EndpointNameAndIDExclusive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hookdeck.jsonc")
+	content := `{"connections": [{"name": "c1", "source": "s1", "source_id": "src_123"}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestLoadFile_InvalidNameTemplate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hookdeck.jsonc")
+	content := `{"connections": [{"name": "{{.Src}}-to-{{.Destination}}", "source": "s1", "destination": "d1"}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "name template") {
+		t.Fatalf("expected name template error, got %v", err)
+	}
+}
+
+func TestLoadFile_CodeFileAndCodeFilesExclusive(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hookdeck.jsonc")
+	content := `{"transformations": [{"name": "t1", "code_file": "a.js", "code_files": ["src/*.js"]}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got %v", err)
+	}
+}
+
+func TestLoadFile_DuplicateNames(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hookdeck.jsonc")
+	content := `{"destinations": [
+		{"name": "processor", "url": "https://a.example.com"},
+		{"name": "processor", "url": "https://b.example.com"}
+	]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), `duplicate destination "processor"`) {
+		t.Fatalf("expected duplicate destination error, got %v", err)
+	}
+
+	// The same name across kinds is fine.
+	content = `{"sources": [{"name": "orders"}], "destinations": [{"name": "orders", "url": "https://a.example.com"}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err != nil {
+		t.Fatalf("expected names to be unique per kind only, got %v", err)
+	}
+}
+
+func TestLoadFile_DuplicateConnections(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hookdeck.jsonc")
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Hookdeck tells connections apart by source and destination, so a
+	// shared name on different sources is fine.
+	write(`{
+		"sources": [{"name": "shop"}, {"name": "billing"}],
+		"destinations": [{"name": "api", "url": "https://a.example.com"}],
+		"connections": [
+			{"name": "orders", "source": "shop", "destination": "api"},
+			{"name": "orders", "source": "billing", "destination": "api"}
+		]
+	}`)
+	if _, err := LoadFile(path); err != nil {
+		t.Fatalf("expected same-named connections on different sources to load, got %v", err)
+	}
+
+	write(`{
+		"sources": [{"name": "shop"}],
+		"destinations": [{"name": "api", "url": "https://a.example.com"}],
+		"connections": [
+			{"name": "orders", "source": "shop", "destination": "api"},
+			{"name": "refunds", "source": "shop", "destination": "api"}
+		]
+	}`)
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), `duplicate connection "shop->api"`) {
+		t.Fatalf("expected duplicate connection error, got %v", err)
+	}
+
+	// Endpoints referenced by ID fall back to the name.
+	write(`{"connections": [
+		{"name": "orders", "source_id": "src_1", "destination_id": "des_1"},
+		{"name": "orders", "source_id": "src_2", "destination_id": "des_1"}
+	]}`)
+	_, err = LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), `duplicate connection "orders"`) {
+		t.Fatalf("expected duplicate connection error, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package manifest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces interpolated values in redacted output.
+const RedactedValue = "***"
+
+// minRedactLen is the shortest interpolated value that is redacted wherever
+// it appears. Masking very short values ("1", "on") inside other text would
+// mask unrelated text, so they are only redacted when they make up the whole
+// string.
+const minRedactLen = 4
+
+// Redactor remembers the values substituted by interpolation so they can be
+// masked in human-facing output. The zero value is not usable; call
+// NewRedactor.
+type Redactor struct {
+	mu     sync.Mutex
+	values map[string]bool
+	// short holds the non-empty values shorter than minRedactLen.
+	short map[string]bool
+}
+
+// NewRedactor returns an empty Redactor.
+func NewRedactor() *Redactor {
+	return &Redactor{values: map[string]bool{}, short: map[string]bool{}}
+}
+
+// Track wraps lookup so that every value it resolves is recorded.
+func (r *Redactor) Track(lookup VarLookup) VarLookup {
+	return func(name string) (string, bool) {
+		v, ok := lookup(name)
+		if ok && v != "" {
+			r.mu.Lock()
+			if len(v) >= minRedactLen {
+				r.values[v] = true
+			} else {
+				r.short[v] = true
+			}
+			r.mu.Unlock()
+		}
+		return v, ok
+	}
+}
+
+// Redact replaces every recorded value in s with RedactedValue. Longer values
+// are replaced first so a value containing another is masked whole. A value
+// shorter than minRedactLen is only masked when it is all of s.
+func (r *Redactor) Redact(s string) string {
+	r.mu.Lock()
+	if r.short[s] {
+		r.mu.Unlock()
+		return RedactedValue
+	}
+	values := make([]string, 0, len(r.values))
+	for v := range r.values {
+		values = append(values, v)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(values, func(i, j int) bool {
+		if len(values[i]) != len(values[j]) {
+			return len(values[i]) > len(values[j])
+		}
+		return values[i] < values[j]
+	})
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, RedactedValue)
+	}
+	return s
+}
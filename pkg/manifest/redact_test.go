@@ -0,0 +1,46 @@
+package manifest
+
+import "testing"
+
+func TestRedactor_RedactsInterpolatedValues(t *testing.T) {
+	r := NewRedactor()
+	m := &Manifest{
+		Destinations: []DestinationConfig{{
+			Name: "api",
+			URL:  "https://${HOST}/hooks",
+			Auth: map[string]interface{}{"api_key": "${API_KEY}"},
+		}},
+	}
+	lookup := MapLookup(map[string]string{"HOST": "api.example.com", "API_KEY": "sk_live_secret"})
+	if err := InterpolateVars(m, r.Track(lookup)); err != nil {
+		t.Fatalf("InterpolateVars failed: %v", err)
+	}
+
+	if got := r.Redact("api_key: sk_live_secret"); got != "api_key: ***" {
+		t.Errorf("expected secret to be redacted, got %q", got)
+	}
+	if got := r.Redact(m.Destinations[0].URL); got != "https://***/hooks" {
+		t.Errorf("expected interpolated host to be redacted, got %q", got)
+	}
+	if got := r.Redact("unrelated"); got != "unrelated" {
+		t.Errorf("expected unrelated text untouched, got %q", got)
+	}
+}
+
+func TestRedactor_LongestValueFirstAndShortValues(t *testing.T) {
+	r := NewRedactor()
+	lookup := r.Track(MapLookup(map[string]string{"A": "token", "B": "token-extended", "C": "on"}))
+	for _, name := range []string{"A", "B", "C"} {
+		lookup(name)
+	}
+
+	if got := r.Redact("token-extended"); got != "***" {
+		t.Errorf("expected the longer value to be masked whole, got %q", got)
+	}
+	if got := r.Redact("on token"); got != "on ***" {
+		t.Errorf("expected short values inside text to be left alone, got %q", got)
+	}
+	if got := r.Redact("on"); got != "***" {
+		t.Errorf("expected a whole short value to be masked, got %q", got)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"strings"
+	"text/template"
 )
 
 var envVarPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
@@ -16,6 +18,12 @@ func ResolveSourceEnv(src *SourceConfig, envName string) *SourceConfig {
 		Type:        src.Type,
 		Description: src.Description,
 		Config:      src.Config,
+		Enabled:     src.Enabled,
+
+		AllowedHTTPMethods: src.AllowedHTTPMethods,
+		CustomResponse:     src.CustomResponse,
+		External:           src.External,
+		DependsOn:          src.DependsOn,
 	}
 	if envName == "" || src.Env == nil {
 		return result
@@ -31,7 +39,16 @@ func ResolveSourceEnv(src *SourceConfig, envName string) *SourceConfig {
 		result.Description = override.Description
 	}
 	if override.Config != nil {
-		result.Config = override.Config
+		result.Config = mergeMaps(result.Config, override.Config)
+	}
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
+	}
+	if override.AllowedHTTPMethods != nil {
+		result.AllowedHTTPMethods = override.AllowedHTTPMethods
+	}
+	if override.CustomResponse != nil {
+		result.CustomResponse = override.CustomResponse
 	}
 	return result
 }
@@ -48,6 +65,18 @@ func ResolveDestinationEnv(dst *DestinationConfig, envName string) *DestinationC
 		Config:          dst.Config,
 		RateLimit:       dst.RateLimit,
 		RateLimitPeriod: dst.RateLimitPeriod,
+		Enabled:         dst.Enabled,
+
+		PathForwardingDisabled: dst.PathForwardingDisabled,
+		HTTPMethod:             dst.HTTPMethod,
+
+		AuthHeader:   dst.AuthHeader,
+		AuthValue:    dst.AuthValue,
+		AuthUsername: dst.AuthUsername,
+		AuthPassword: dst.AuthPassword,
+
+		External:  dst.External,
+		DependsOn: dst.DependsOn,
 	}
 	if envName == "" || dst.Env == nil {
 		return result
@@ -69,10 +98,10 @@ func ResolveDestinationEnv(dst *DestinationConfig, envName string) *DestinationC
 		result.AuthType = override.AuthType
 	}
 	if override.Auth != nil {
-		result.Auth = override.Auth
+		result.Auth = mergeMaps(result.Auth, override.Auth)
 	}
 	if override.Config != nil {
-		result.Config = override.Config
+		result.Config = mergeMaps(result.Config, override.Config)
 	}
 	if override.RateLimit != 0 {
 		result.RateLimit = override.RateLimit
@@ -80,34 +109,169 @@ func ResolveDestinationEnv(dst *DestinationConfig, envName string) *DestinationC
 	if override.RateLimitPeriod != "" {
 		result.RateLimitPeriod = override.RateLimitPeriod
 	}
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
+	}
+	if override.PathForwardingDisabled != nil {
+		result.PathForwardingDisabled = override.PathForwardingDisabled
+	}
+	if override.HTTPMethod != "" {
+		result.HTTPMethod = override.HTTPMethod
+	}
+	if override.AuthHeader != "" {
+		result.AuthHeader = override.AuthHeader
+	}
+	if override.AuthValue != "" {
+		result.AuthValue = override.AuthValue
+	}
+	if override.AuthUsername != "" {
+		result.AuthUsername = override.AuthUsername
+	}
+	if override.AuthPassword != "" {
+		result.AuthPassword = override.AuthPassword
+	}
 	return result
 }
 
-// ResolveConnectionEnv applies environment-specific overrides to a connection.
+// mergeMaps returns base with override merged in recursively: a key that
+// holds an object in both is merged the same way, so an env override can set
+// one nested key without dropping its siblings; any other override value,
+// arrays included, replaces the base value. Neither argument is modified.
+func mergeMaps(base, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		baseObj, baseOK := merged[k].(map[string]interface{})
+		overrideObj, overrideOK := v.(map[string]interface{})
+		if baseOK && overrideOK {
+			merged[k] = mergeMaps(baseObj, overrideObj)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// Destination auth types with shorthand fields (see DestinationAuth).
+const (
+	AuthTypeAPIKey      = "API_KEY"
+	AuthTypeBearerToken = "BEARER_TOKEN"
+	AuthTypeBasicAuth   = "BASIC_AUTH"
+)
+
+// DestinationAuth returns the auth object to send for dst: its Auth map with
+// the shorthand fields for its AuthType expanded into the keys Hookdeck
+// expects. API_KEY sends the key in a header ({"key", "api_key", "to"}),
+// BEARER_TOKEN sets "token", and BASIC_AUTH sets "username" and "password".
+// Shorthands take precedence over the same keys in Auth. It returns nil when
+// neither is set.
+func DestinationAuth(dst *DestinationConfig) map[string]interface{} {
+	shorthand := map[string]interface{}{}
+	switch dst.AuthType {
+	case AuthTypeAPIKey:
+		if dst.AuthHeader != "" {
+			shorthand["key"] = dst.AuthHeader
+		}
+		if dst.AuthValue != "" {
+			shorthand["api_key"] = dst.AuthValue
+		}
+		if len(shorthand) > 0 {
+			shorthand["to"] = "header"
+		}
+	case AuthTypeBearerToken:
+		if dst.AuthValue != "" {
+			shorthand["token"] = dst.AuthValue
+		}
+	case AuthTypeBasicAuth:
+		if dst.AuthUsername != "" {
+			shorthand["username"] = dst.AuthUsername
+		}
+		if dst.AuthPassword != "" {
+			shorthand["password"] = dst.AuthPassword
+		}
+	}
+	if len(shorthand) == 0 {
+		return dst.Auth
+	}
+	auth := make(map[string]interface{}, len(dst.Auth)+len(shorthand))
+	for k, v := range dst.Auth {
+		auth[k] = v
+	}
+	for k, v := range shorthand {
+		auth[k] = v
+	}
+	return auth
+}
+
+// ResolveConnectionEnv applies environment-specific overrides to a connection
+// and then renders its name template.
 func ResolveConnectionEnv(conn *ConnectionConfig, envName string) *ConnectionConfig {
+	result := resolveConnectionOverrides(conn, envName)
+	// Templates are validated at load time, so a render error here can only
+	// come from a hand-built config; keep the raw name in that case.
+	if name, err := RenderConnectionName(result); err == nil {
+		result.Name = name
+	}
+	return result
+}
+
+// resolveConnectionOverrides applies the envName overlay to a copy of conn
+// without rendering its name.
+func resolveConnectionOverrides(conn *ConnectionConfig, envName string) *ConnectionConfig {
 	result := &ConnectionConfig{
 		Name:            conn.Name,
+		Description:     conn.Description,
 		Source:          conn.Source,
 		Destination:     conn.Destination,
+		SourceID:        conn.SourceID,
+		DestinationID:   conn.DestinationID,
 		Rules:           conn.Rules,
 		Filter:          conn.Filter,
 		Transformations: conn.Transformations,
+		Delay:           conn.Delay,
+		RulesMerge:      conn.RulesMerge,
+		Disabled:        conn.Disabled,
+		Enabled:         conn.Enabled,
+		DependsOn:       conn.DependsOn,
 	}
-	if envName == "" || conn.Env == nil {
-		return result
+	if override, ok := conn.Env[envName]; ok && envName != "" {
+		applyConnectionOverride(result, conn, override)
 	}
-	override, ok := conn.Env[envName]
-	if !ok {
-		return result
+	return result
+}
+
+// applyConnectionOverride applies override on top of result, which holds the
+// base values copied from conn.
+func applyConnectionOverride(result, conn *ConnectionConfig, override *ConnectionOverride) {
+	if override.Name != "" {
+		result.Name = override.Name
 	}
+	if override.Description != "" {
+		result.Description = override.Description
+	}
+	// A name override replaces a literal ID reference for that endpoint.
 	if override.Source != "" {
 		result.Source = override.Source
+		result.SourceID = ""
 	}
 	if override.Destination != "" {
 		result.Destination = override.Destination
+		result.DestinationID = ""
 	}
 	if override.Rules != nil {
-		result.Rules = override.Rules
+		if conn.RulesMerge == RulesMergeByType {
+			result.Rules = MergeRulesByType(conn.Rules, override.Rules)
+		} else {
+			result.Rules = override.Rules
+		}
+	}
+	if len(override.RulesAppend) > 0 {
+		// Copy so appending never writes into the base config's backing array.
+		rules := make([]map[string]interface{}, 0, len(result.Rules)+len(override.RulesAppend))
+		rules = append(rules, result.Rules...)
+		result.Rules = append(rules, override.RulesAppend...)
 	}
 	if override.Filter != nil {
 		result.Filter = override.Filter
@@ -115,7 +279,163 @@ func ResolveConnectionEnv(conn *ConnectionConfig, envName string) *ConnectionCon
 	if override.Transformations != nil {
 		result.Transformations = override.Transformations
 	}
-	return result
+	if override.Delay != nil {
+		result.Delay = *override.Delay
+	}
+	if override.Disabled != nil {
+		result.Disabled = *override.Disabled
+	}
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
+	}
+}
+
+// connectionNameData is the data a connection name template is rendered with.
+type connectionNameData struct {
+	Source      string
+	Destination string
+}
+
+// RenderConnectionName renders conn.Name as a text/template with the
+// connection's Source and Destination names, e.g. "{{.Source}}-to-{{.Destination}}".
+// Names without "{{" are returned unchanged, and an empty name is replaced by
+// DefaultConnectionName. Call it on an env-resolved connection so
+// env-specific endpoint names produce the right name.
+func RenderConnectionName(conn *ConnectionConfig) (string, error) {
+	if conn.Name == "" {
+		return DefaultConnectionName(conn), nil
+	}
+	if !strings.Contains(conn.Name, "{{") {
+		return conn.Name, nil
+	}
+	tmpl, err := template.New("name").Parse(conn.Name)
+	if err != nil {
+		return "", fmt.Errorf("parsing name template %q: %w", conn.Name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, connectionNameData{Source: conn.Source, Destination: conn.Destination}); err != nil {
+		return "", fmt.Errorf("rendering name template %q: %w", conn.Name, err)
+	}
+	return b.String(), nil
+}
+
+// DefaultConnectionName returns the name used for a connection that
+// declares none: "<source>-to-<destination>", using an endpoint's literal ID
+// when it isn't referenced by name. Deriving the name keeps repeated deploys
+// upserting the same connection. It returns "" when an endpoint is missing.
+func DefaultConnectionName(conn *ConnectionConfig) string {
+	source, destination := conn.Source, conn.Destination
+	if source == "" {
+		source = conn.SourceID
+	}
+	if destination == "" {
+		destination = conn.DestinationID
+	}
+	if source == "" || destination == "" {
+		return ""
+	}
+	return source + "-to-" + destination
+}
+
+// ConnectionFullName returns the name to look conn up by in Hookdeck's
+// full_name filter: "<source>-><destination>" built from its resolved
+// endpoint names. When either endpoint is referenced by literal ID its name
+// isn't known, so the declared name is returned instead.
+func ConnectionFullName(conn *ConnectionConfig) string {
+	if conn.Source == "" || conn.Destination == "" {
+		return conn.Name
+	}
+	return conn.Source + "->" + conn.Destination
+}
+
+// ConnectionLookupNames returns the full_name values to look conn up by on
+// Hookdeck, in order: its ConnectionFullName, then its declared name when
+// that differs, for connections whose endpoints are referenced by literal ID
+// or that were created under a plain name. Every command that finds a live
+// connection tries them in this order, so two connections sharing a name but
+// linking different endpoints are told apart.
+func ConnectionLookupNames(conn *ConnectionConfig) []string {
+	fullName := ConnectionFullName(conn)
+	if conn.Name == "" || conn.Name == fullName {
+		return []string{fullName}
+	}
+	return []string{fullName, conn.Name}
+}
+
+// TransformationRefs returns the names of the transformations conn
+// references, through its transformations shorthand or a transform rule (see
+// TransformRuleName), in order and without duplicates.
+func TransformationRefs(conn *ConnectionConfig) []string {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, name := range conn.Transformations {
+		add(name)
+	}
+	for _, rule := range conn.Rules {
+		add(TransformRuleName(rule))
+	}
+	return names
+}
+
+// TransformRuleName returns the transformation a transform rule references
+// by name, written either as {"transformation": {"name": ...}} or as a
+// top-level "transformation_name". It returns "" for other rules.
+func TransformRuleName(rule map[string]interface{}) string {
+	if rule["type"] != "transform" {
+		return ""
+	}
+	if tr, ok := rule["transformation"].(map[string]interface{}); ok {
+		if name, _ := tr["name"].(string); name != "" {
+			return name
+		}
+	}
+	name, _ := rule["transformation_name"].(string)
+	return name
+}
+
+// MergeRulesByType merges override rules into base rules keyed by each rule's
+// "type". The result keeps the base order: a base rule whose type appears in
+// overrides is replaced, at the position of the first base rule of that type,
+// by all override rules of that type (further base rules of that type are
+// dropped). Override rules whose type is not in base are appended afterwards
+// in their original order.
+func MergeRulesByType(base, overrides []map[string]interface{}) []map[string]interface{} {
+	byType := make(map[string][]map[string]interface{})
+	var order []string
+	for _, rule := range overrides {
+		t, _ := rule["type"].(string)
+		if _, ok := byType[t]; !ok {
+			order = append(order, t)
+		}
+		byType[t] = append(byType[t], rule)
+	}
+
+	used := make(map[string]bool)
+	var merged []map[string]interface{}
+	for _, rule := range base {
+		t, _ := rule["type"].(string)
+		replacement, ok := byType[t]
+		if !ok {
+			merged = append(merged, rule)
+			continue
+		}
+		if !used[t] {
+			merged = append(merged, replacement...)
+			used[t] = true
+		}
+	}
+	for _, t := range order {
+		if !used[t] {
+			merged = append(merged, byType[t]...)
+		}
+	}
+	return merged
 }
 
 // ResolveTransformationEnv applies environment-specific overrides to a transformation.
@@ -124,6 +444,9 @@ func ResolveTransformationEnv(tr *TransformationConfig, envName string) *Transfo
 		Name:        tr.Name,
 		Description: tr.Description,
 		CodeFile:    tr.CodeFile,
+		CodeFiles:   tr.CodeFiles,
+		Enabled:     tr.Enabled,
+		DependsOn:   tr.DependsOn,
 	}
 	if tr.Env != nil {
 		result.Env = make(map[string]string)
@@ -141,8 +464,17 @@ func ResolveTransformationEnv(tr *TransformationConfig, envName string) *Transfo
 	if override.Description != "" {
 		result.Description = override.Description
 	}
+	// Either code form in the override replaces both base forms.
 	if override.CodeFile != "" {
 		result.CodeFile = override.CodeFile
+		result.CodeFiles = nil
+	}
+	if len(override.CodeFiles) > 0 {
+		result.CodeFile = ""
+		result.CodeFiles = override.CodeFiles
+	}
+	if override.Enabled != nil {
+		result.Enabled = override.Enabled
 	}
 	if override.Env != nil {
 		if result.Env == nil {
@@ -155,8 +487,68 @@ func ResolveTransformationEnv(tr *TransformationConfig, envName string) *Transfo
 	return result
 }
 
-// InterpolateEnvVars replaces ${ENV_VAR} patterns in all string fields of a Manifest.
+// VarLookup resolves the value of an interpolation variable, reporting
+// whether it was found. os.LookupEnv is a VarLookup.
+type VarLookup func(name string) (string, bool)
+
+// MapLookup returns a VarLookup backed by vars.
+func MapLookup(vars map[string]string) VarLookup {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// ChainLookup returns a VarLookup that consults each lookup in order and
+// returns the first value found.
+func ChainLookup(lookups ...VarLookup) VarLookup {
+	return func(name string) (string, bool) {
+		for _, lookup := range lookups {
+			if v, ok := lookup(name); ok {
+				return v, true
+			}
+		}
+		return "", false
+	}
+}
+
+// InterpolateEnvVars replaces ${ENV_VAR} patterns in all string fields of a
+// Manifest. Variables missing from the process environment fall back to the
+// manifest's Vars.
 func InterpolateEnvVars(m *Manifest) error {
+	return InterpolateEnvVarsWith(m, nil)
+}
+
+// InterpolateEnvVarsWith is like InterpolateEnvVars, but values in overrides
+// take precedence over the process environment. ${provider:key} references
+// are resolved through the registered SecretResolvers.
+func InterpolateEnvVarsWith(m *Manifest, overrides map[string]string) error {
+	secrets := &SecretLookup{}
+	err := InterpolateVars(m, ChainLookup(secrets.Lookup, MapLookup(overrides), os.LookupEnv, MapLookup(m.Vars)))
+	if secretErr := secrets.Err(); secretErr != nil {
+		return secretErr
+	}
+	return err
+}
+
+// InterpolateOptions adjusts how InterpolateVarsWithOptions treats
+// variables.
+type InterpolateOptions struct {
+	// AllowUnresolved leaves a ${VAR} that lookup can't resolve in place,
+	// for a later pipeline stage to fill, instead of failing.
+	AllowUnresolved bool
+}
+
+// InterpolateVars replaces ${VAR} patterns in all string fields of a Manifest,
+// resolving each variable through lookup. Any unresolved variable is an
+// error.
+func InterpolateVars(m *Manifest, lookup VarLookup) error {
+	return InterpolateVarsWithOptions(m, lookup, InterpolateOptions{})
+}
+
+// InterpolateVarsWithOptions is InterpolateVars with control over unresolved
+// variables.
+func InterpolateVarsWithOptions(m *Manifest, lookup VarLookup, opts InterpolateOptions) error {
 	data, err := json.Marshal(m)
 	if err != nil {
 		return err
@@ -165,7 +557,7 @@ func InterpolateEnvVars(m *Manifest) error {
 	var missing []string
 	result := envVarPattern.ReplaceAllFunc(data, func(match []byte) []byte {
 		varName := envVarPattern.FindSubmatch(match)[1]
-		val, ok := os.LookupEnv(string(varName))
+		val, ok := lookup(string(varName))
 		if !ok {
 			missing = append(missing, string(varName))
 			return match
@@ -174,7 +566,7 @@ func InterpolateEnvVars(m *Manifest) error {
 		return escaped[1 : len(escaped)-1]
 	})
 
-	if len(missing) > 0 {
+	if len(missing) > 0 && !opts.AllowUnresolved {
 		return fmt.Errorf("undefined environment variables: %v", missing)
 	}
 
@@ -2,6 +2,7 @@ package manifest
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -22,6 +23,24 @@ func TestResolveSourceEnv_WithOverride(t *testing.T) {
 	}
 }
 
+func TestResolveSourceEnv_ConfigShorthands(t *testing.T) {
+	src := SourceConfig{
+		Name:               "s1",
+		AllowedHTTPMethods: []string{"POST"},
+		CustomResponse:     &CustomResponse{ContentType: "text", Body: "ok"},
+		Env: map[string]*SourceOverride{
+			"production": {AllowedHTTPMethods: []string{"POST", "PUT"}},
+		},
+	}
+	resolved := ResolveSourceEnv(&src, "production")
+	if len(resolved.AllowedHTTPMethods) != 2 {
+		t.Errorf("expected overridden allowed_http_methods, got %v", resolved.AllowedHTTPMethods)
+	}
+	if resolved.CustomResponse == nil || resolved.CustomResponse.Body != "ok" {
+		t.Errorf("expected base custom_response to be kept, got %+v", resolved.CustomResponse)
+	}
+}
+
 func TestResolveSourceEnv_NoOverride(t *testing.T) {
 	src := SourceConfig{Name: "s1", Type: "Stripe"}
 	resolved := ResolveSourceEnv(&src, "production")
@@ -113,6 +132,30 @@ func TestResolveConnectionEnv_TransformationsOverride(t *testing.T) {
 	}
 }
 
+func TestResolveConnectionEnv_DelayOverride(t *testing.T) {
+	fiveMinutes, none := 300, 0
+	conn := ConnectionConfig{
+		Name:        "c1",
+		Source:      "src",
+		Destination: "dst",
+		Delay:       30,
+		Env: map[string]*ConnectionOverride{
+			"staging":    {Delay: &fiveMinutes},
+			"production": {Delay: &none},
+		},
+	}
+
+	if resolved := ResolveConnectionEnv(&conn, "dev"); resolved.Delay != 30 {
+		t.Errorf("dev: expected base delay 30, got %d", resolved.Delay)
+	}
+	if resolved := ResolveConnectionEnv(&conn, "staging"); resolved.Delay != 300 {
+		t.Errorf("staging: expected delay 300, got %d", resolved.Delay)
+	}
+	if resolved := ResolveConnectionEnv(&conn, "production"); resolved.Delay != 0 {
+		t.Errorf("production: expected delay removed, got %d", resolved.Delay)
+	}
+}
+
 func TestResolveConnectionEnv_NoOverride(t *testing.T) {
 	conn := ConnectionConfig{
 		Name:   "c1",
@@ -184,3 +227,496 @@ func TestInterpolateManifestEnvVars_MissingVar(t *testing.T) {
 		t.Fatal("expected error for missing env var")
 	}
 }
+
+func TestInterpolateVarsWithOptions_AllowUnresolved(t *testing.T) {
+	newManifest := func() *Manifest {
+		return &Manifest{
+			Destinations: []DestinationConfig{
+				{Name: "d1", URL: "https://${HOST}/${LATER_PATH}", AuthValue: `"${LATER_TOKEN}"`},
+			},
+		}
+	}
+	lookup := MapLookup(map[string]string{"HOST": `api.example.com`})
+
+	m := newManifest()
+	if err := InterpolateVarsWithOptions(m, lookup, InterpolateOptions{AllowUnresolved: true}); err != nil {
+		t.Fatalf("InterpolateVarsWithOptions failed: %v", err)
+	}
+	dst := m.Destinations[0]
+	if dst.URL != "https://api.example.com/${LATER_PATH}" {
+		t.Errorf("expected known var substituted and unknown kept, got %q", dst.URL)
+	}
+	if dst.AuthValue != `"${LATER_TOKEN}"` {
+		t.Errorf("expected unresolved var kept verbatim inside quotes, got %q", dst.AuthValue)
+	}
+
+	// The default stays strict.
+	if err := InterpolateVars(newManifest(), lookup); err == nil || !strings.Contains(err.Error(), "LATER_PATH") {
+		t.Errorf("expected an error naming the unresolved var, got %v", err)
+	}
+}
+
+func TestInterpolateEnvVarsWith_OverridesWin(t *testing.T) {
+	t.Setenv("TEST_HOST", "env.example.com")
+
+	m := &Manifest{
+		Destinations: []DestinationConfig{
+			{Name: "d1", URL: "https://${TEST_HOST}/${TEST_PATH}"},
+		},
+	}
+	overrides := map[string]string{"TEST_HOST": "cli.example.com", "TEST_PATH": "hooks"}
+	if err := InterpolateEnvVarsWith(m, overrides); err != nil {
+		t.Fatalf("InterpolateEnvVarsWith failed: %v", err)
+	}
+	if m.Destinations[0].URL != "https://cli.example.com/hooks" {
+		t.Errorf("expected override values, got '%s'", m.Destinations[0].URL)
+	}
+}
+
+func TestInterpolateEnvVars_ManifestVarsAreDefaults(t *testing.T) {
+	t.Setenv("TEST_STAGE", "env-stage")
+
+	m := &Manifest{
+		Vars: map[string]string{"TEST_REGION": "eu-west-1", "TEST_STAGE": "manifest-stage"},
+		Destinations: []DestinationConfig{
+			{Name: "d1", URL: "https://${TEST_REGION}.example.com/${TEST_STAGE}"},
+		},
+	}
+	if err := InterpolateEnvVars(m); err != nil {
+		t.Fatalf("InterpolateEnvVars failed: %v", err)
+	}
+	// TEST_REGION falls back to the manifest; TEST_STAGE is overridden by env.
+	if m.Destinations[0].URL != "https://eu-west-1.example.com/env-stage" {
+		t.Errorf("expected manifest var fallback and env override, got '%s'", m.Destinations[0].URL)
+	}
+
+	m = &Manifest{
+		Vars:         map[string]string{"TEST_STAGE": "manifest-stage"},
+		Destinations: []DestinationConfig{{Name: "d1", URL: "https://example.com/${TEST_STAGE}"}},
+	}
+	if err := InterpolateEnvVarsWith(m, map[string]string{"TEST_STAGE": "cli-stage"}); err != nil {
+		t.Fatalf("InterpolateEnvVarsWith failed: %v", err)
+	}
+	if m.Destinations[0].URL != "https://example.com/cli-stage" {
+		t.Errorf("expected --var override to win, got '%s'", m.Destinations[0].URL)
+	}
+}
+
+func TestResolveConnectionEnv_RulesReplacedByDefault(t *testing.T) {
+	conn := ConnectionConfig{
+		Name: "c1",
+		Rules: []map[string]interface{}{
+			{"type": "filter", "body": map[string]interface{}{"a": 1}},
+			{"type": "retry", "strategy": "linear", "count": 3},
+		},
+		Env: map[string]*ConnectionOverride{
+			"production": {Rules: []map[string]interface{}{
+				{"type": "retry", "strategy": "exponential", "count": 5},
+			}},
+		},
+	}
+
+	resolved := ResolveConnectionEnv(&conn, "production")
+	if len(resolved.Rules) != 1 {
+		t.Fatalf("expected rules to be replaced (1 rule), got %d", len(resolved.Rules))
+	}
+	if resolved.Rules[0]["strategy"] != "exponential" {
+		t.Errorf("expected override retry rule, got %v", resolved.Rules[0])
+	}
+}
+
+func TestResolveConnectionEnv_RulesMergedByType(t *testing.T) {
+	conn := ConnectionConfig{
+		Name:       "c1",
+		RulesMerge: RulesMergeByType,
+		Rules: []map[string]interface{}{
+			{"type": "filter", "body": map[string]interface{}{"a": 1}},
+			{"type": "retry", "strategy": "linear", "count": 3},
+			{"type": "transform", "transformation": map[string]interface{}{"name": "t1"}},
+		},
+		Env: map[string]*ConnectionOverride{
+			"production": {Rules: []map[string]interface{}{
+				{"type": "delay", "delay": 1000},
+				{"type": "retry", "strategy": "exponential", "count": 5},
+			}},
+		},
+	}
+
+	resolved := ResolveConnectionEnv(&conn, "production")
+	var types []string
+	for _, r := range resolved.Rules {
+		types = append(types, r["type"].(string))
+	}
+	want := []string{"filter", "retry", "transform", "delay"}
+	if len(types) != len(want) {
+		t.Fatalf("expected rule types %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("expected rule types %v, got %v", want, types)
+		}
+	}
+	if resolved.Rules[1]["strategy"] != "exponential" {
+		t.Errorf("expected retry rule from override, got %v", resolved.Rules[1])
+	}
+	if len(conn.Rules) != 3 || conn.Rules[1]["strategy"] != "linear" {
+		t.Errorf("expected base rules to be left untouched, got %v", conn.Rules)
+	}
+}
+
+func TestResolveConnectionEnv_DisabledOverride(t *testing.T) {
+	enabled := false
+	conn := ConnectionConfig{
+		Name:     "c1",
+		Disabled: true,
+		Env: map[string]*ConnectionOverride{
+			"staging": {Disabled: &enabled},
+		},
+	}
+
+	if resolved := ResolveConnectionEnv(&conn, "production"); !resolved.Disabled {
+		t.Error("production: expected base disabled=true")
+	}
+	if resolved := ResolveConnectionEnv(&conn, "staging"); resolved.Disabled {
+		t.Error("staging: expected override disabled=false")
+	}
+}
+
+func TestResolveDestinationEnv_ConfigShorthands(t *testing.T) {
+	off, on := false, true
+	dst := DestinationConfig{
+		Name:                   "d1",
+		PathForwardingDisabled: &off,
+		HTTPMethod:             "POST",
+		Env: map[string]*DestinationOverride{
+			"production": {PathForwardingDisabled: &on, HTTPMethod: "PUT"},
+		},
+	}
+	resolved := ResolveDestinationEnv(&dst, "staging")
+	if resolved.PathForwardingDisabled == nil || *resolved.PathForwardingDisabled || resolved.HTTPMethod != "POST" {
+		t.Errorf("staging: expected base shorthands, got %v %q", resolved.PathForwardingDisabled, resolved.HTTPMethod)
+	}
+	resolved = ResolveDestinationEnv(&dst, "production")
+	if resolved.PathForwardingDisabled == nil || !*resolved.PathForwardingDisabled || resolved.HTTPMethod != "PUT" {
+		t.Errorf("production: expected overridden shorthands, got %v %q", resolved.PathForwardingDisabled, resolved.HTTPMethod)
+	}
+}
+
+func TestResolveDestinationEnv_AuthDeepMerge(t *testing.T) {
+	dst := DestinationConfig{
+		Name: "d1",
+		Auth: map[string]interface{}{"key": "X-Api-Key", "api_key": "dev-key"},
+		Env: map[string]*DestinationOverride{
+			"production": {Auth: map[string]interface{}{"api_key": "prod-key"}},
+		},
+	}
+	resolved := ResolveDestinationEnv(&dst, "production")
+	if resolved.Auth["key"] != "X-Api-Key" || resolved.Auth["api_key"] != "prod-key" {
+		t.Errorf("expected api_key overridden and key kept, got %v", resolved.Auth)
+	}
+	if dst.Auth["api_key"] != "dev-key" {
+		t.Errorf("expected base auth untouched, got %v", dst.Auth)
+	}
+}
+
+func TestResolveEnv_ConfigNestedDeepMerge(t *testing.T) {
+	src := SourceConfig{
+		Name: "s1",
+		Config: map[string]interface{}{
+			"auth": map[string]interface{}{"header_key": "X-Signature", "api_key": "${API_KEY}"},
+			"path": "/hooks",
+		},
+		Env: map[string]*SourceOverride{
+			"production": {Config: map[string]interface{}{
+				"auth": map[string]interface{}{"header_key": "X-Prod-Signature"},
+			}},
+		},
+	}
+	resolved := ResolveSourceEnv(&src, "production")
+	auth, _ := resolved.Config["auth"].(map[string]interface{})
+	if auth["header_key"] != "X-Prod-Signature" || auth["api_key"] != "${API_KEY}" || resolved.Config["path"] != "/hooks" {
+		t.Errorf("source: expected nested override with siblings kept, got %v", resolved.Config)
+	}
+	if base := src.Config["auth"].(map[string]interface{}); base["header_key"] != "X-Signature" {
+		t.Errorf("source: expected base config untouched, got %v", src.Config)
+	}
+
+	dst := DestinationConfig{
+		Name: "d1",
+		Config: map[string]interface{}{
+			"auth": map[string]interface{}{"header_key": "X-Signature", "api_key": "${API_KEY}"},
+		},
+		Env: map[string]*DestinationOverride{
+			"production": {Config: map[string]interface{}{
+				"auth": map[string]interface{}{"header_key": "X-Prod-Signature"},
+			}},
+		},
+	}
+	resolvedDst := ResolveDestinationEnv(&dst, "production")
+	auth, _ = resolvedDst.Config["auth"].(map[string]interface{})
+	if auth["header_key"] != "X-Prod-Signature" || auth["api_key"] != "${API_KEY}" {
+		t.Errorf("destination: expected nested override with siblings kept, got %v", resolvedDst.Config)
+	}
+}
+
+func TestResolveEnv_EnabledOverride(t *testing.T) {
+	off, on := false, true
+	dst := DestinationConfig{
+		Name: "d1",
+		Env:  map[string]*DestinationOverride{"production": {Enabled: &off}},
+	}
+	if resolved := ResolveDestinationEnv(&dst, "staging"); !IsEnabled(resolved.Enabled) {
+		t.Error("staging: expected destination to be enabled by default")
+	}
+	if resolved := ResolveDestinationEnv(&dst, "production"); IsEnabled(resolved.Enabled) {
+		t.Error("production: expected override enabled=false")
+	}
+
+	src := SourceConfig{
+		Name:    "s1",
+		Enabled: &off,
+		Env:     map[string]*SourceOverride{"staging": {Enabled: &on}},
+	}
+	if resolved := ResolveSourceEnv(&src, "production"); IsEnabled(resolved.Enabled) {
+		t.Error("production: expected base enabled=false")
+	}
+	if resolved := ResolveSourceEnv(&src, "staging"); !IsEnabled(resolved.Enabled) {
+		t.Error("staging: expected override enabled=true")
+	}
+
+	conn := ConnectionConfig{
+		Name: "c1",
+		Env:  map[string]*ConnectionOverride{"production": {Enabled: &off}},
+	}
+	if resolved := ResolveConnectionEnv(&conn, "production"); IsEnabled(resolved.Enabled) || resolved.Disabled {
+		t.Error("production: expected connection excluded but not paused")
+	}
+
+	tr := TransformationConfig{
+		Name:         "t1",
+		EnvOverrides: map[string]*TransformationOverride{"production": {Enabled: &off}},
+	}
+	if resolved := ResolveTransformationEnv(&tr, "production"); IsEnabled(resolved.Enabled) {
+		t.Error("production: expected transformation override enabled=false")
+	}
+}
+
+func TestResolveConnectionEnv_NameTemplate(t *testing.T) {
+	conn := ConnectionConfig{
+		Name:        "{{.Source}}-to-{{.Destination}}",
+		Source:      "stripe",
+		Destination: "api",
+		Env: map[string]*ConnectionOverride{
+			"staging": {Destination: "api-staging"},
+		},
+	}
+
+	if got := ResolveConnectionEnv(&conn, "").Name; got != "stripe-to-api" {
+		t.Errorf("base: expected stripe-to-api, got %q", got)
+	}
+	if got := ResolveConnectionEnv(&conn, "staging").Name; got != "stripe-to-api-staging" {
+		t.Errorf("staging: expected stripe-to-api-staging, got %q", got)
+	}
+	if conn.Name != "{{.Source}}-to-{{.Destination}}" {
+		t.Errorf("expected base template to be left untouched, got %q", conn.Name)
+	}
+}
+
+func TestResolveConnectionEnv_LiteralNameUnchanged(t *testing.T) {
+	conn := ConnectionConfig{Name: "orders-{.Source}", Source: "s1", Destination: "d1"}
+	if got := ResolveConnectionEnv(&conn, "").Name; got != "orders-{.Source}" {
+		t.Errorf("expected literal name to pass through, got %q", got)
+	}
+}
+
+func TestResolveConnectionEnv_DescriptionOverride(t *testing.T) {
+	conn := ConnectionConfig{
+		Name:        "c1",
+		Description: "base",
+		Env: map[string]*ConnectionOverride{
+			"production": {Description: "prod"},
+		},
+	}
+	if got := ResolveConnectionEnv(&conn, "staging").Description; got != "base" {
+		t.Errorf("staging: expected base description, got %q", got)
+	}
+	if got := ResolveConnectionEnv(&conn, "production").Description; got != "prod" {
+		t.Errorf("production: expected override description, got %q", got)
+	}
+}
+
+func TestRenderConnectionName_Errors(t *testing.T) {
+	for _, name := range []string{"{{.Source", "{{.Sauce}}-to-{{.Destination}}"} {
+		if _, err := RenderConnectionName(&ConnectionConfig{Name: name, Source: "s", Destination: "d"}); err == nil {
+			t.Errorf("expected error for template %q", name)
+		}
+	}
+}
+
+func TestDefaultConnectionName(t *testing.T) {
+	tests := []struct {
+		conn ConnectionConfig
+		want string
+	}{
+		{ConnectionConfig{Source: "shop", Destination: "processor"}, "shop-to-processor"},
+		{ConnectionConfig{SourceID: "src_1", Destination: "processor"}, "src_1-to-processor"},
+		{ConnectionConfig{Source: "shop"}, ""},
+	}
+	for _, tt := range tests {
+		if got := DefaultConnectionName(&tt.conn); got != tt.want {
+			t.Errorf("DefaultConnectionName(%+v) = %q, want %q", tt.conn, got, tt.want)
+		}
+	}
+
+	// The env-resolved endpoints decide the derived name.
+	conn := ConnectionConfig{
+		Source: "shop", Destination: "processor",
+		Env: map[string]*ConnectionOverride{"production": {Destination: "processor-prod"}},
+	}
+	if got := ResolveConnectionEnv(&conn, "production").Name; got != "shop-to-processor-prod" {
+		t.Errorf("expected derived name from env-resolved endpoints, got %q", got)
+	}
+}
+
+func TestConnectionFullName(t *testing.T) {
+	tests := []struct {
+		conn ConnectionConfig
+		want string
+	}{
+		{ConnectionConfig{Name: "orders", Source: "shop", Destination: "processor"}, "shop->processor"},
+		{ConnectionConfig{Name: "orders", SourceID: "src_1", Destination: "processor"}, "orders"},
+		{ConnectionConfig{Name: "orders", Source: "shop", DestinationID: "des_1"}, "orders"},
+	}
+	for _, tt := range tests {
+		if got := ConnectionFullName(&tt.conn); got != tt.want {
+			t.Errorf("ConnectionFullName(%+v) = %q, want %q", tt.conn, got, tt.want)
+		}
+	}
+}
+
+func TestConnectionLookupNames(t *testing.T) {
+	tests := []struct {
+		conn ConnectionConfig
+		want string
+	}{
+		{ConnectionConfig{Name: "orders", Source: "shop", Destination: "processor"}, "shop->processor,orders"},
+		{ConnectionConfig{Source: "shop", Destination: "processor"}, "shop->processor"},
+		{ConnectionConfig{Name: "orders", SourceID: "src_1", Destination: "processor"}, "orders"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(ConnectionLookupNames(&tt.conn), ","); got != tt.want {
+			t.Errorf("ConnectionLookupNames(%+v) = %q, want %q", tt.conn, got, tt.want)
+		}
+	}
+}
+
+func TestTransformationRefs(t *testing.T) {
+	conn := &ConnectionConfig{
+		Transformations: []string{"normalize", "enrich"},
+		Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "linear"},
+			{"type": "transform", "transformation": map[string]interface{}{"name": "enrich"}},
+			{"type": "transform", "transformation": map[string]interface{}{"name": "redact"}},
+			{"type": "transform", "transformation_id": "trs_123"},
+			{"type": "transform", "transformation_name": "sign"},
+		},
+	}
+	got := TransformationRefs(conn)
+	want := []string{"normalize", "enrich", "redact", "sign"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestResolveConnectionEnv_RulesAppend(t *testing.T) {
+	retry := map[string]interface{}{"type": "retry", "strategy": "linear", "count": 3}
+	conn := ConnectionConfig{
+		Name:  "c1",
+		Rules: []map[string]interface{}{retry},
+		Env: map[string]*ConnectionOverride{
+			"production": {
+				RulesAppend: []map[string]interface{}{
+					{"type": "filter", "body": map[string]interface{}{"type": "order.created"}},
+				},
+			},
+		},
+	}
+
+	resolved := ResolveConnectionEnv(&conn, "production")
+	if len(resolved.Rules) != 2 {
+		t.Fatalf("expected 2 rules, got %v", resolved.Rules)
+	}
+	if resolved.Rules[0]["type"] != "retry" || resolved.Rules[1]["type"] != "filter" {
+		t.Errorf("expected base retry followed by appended filter, got %v", resolved.Rules)
+	}
+	if len(conn.Rules) != 1 {
+		t.Errorf("expected base rules to be left untouched, got %v", conn.Rules)
+	}
+
+	if staging := ResolveConnectionEnv(&conn, "staging"); len(staging.Rules) != 1 {
+		t.Errorf("staging: expected only the base rule, got %v", staging.Rules)
+	}
+}
+
+func TestResolveConnectionEnv_RulesAppendAfterReplace(t *testing.T) {
+	conn := ConnectionConfig{
+		Name:  "c1",
+		Rules: []map[string]interface{}{{"type": "retry", "strategy": "linear"}},
+		Env: map[string]*ConnectionOverride{
+			"production": {
+				Rules:       []map[string]interface{}{{"type": "delay", "delay": 1000}},
+				RulesAppend: []map[string]interface{}{{"type": "filter"}},
+			},
+		},
+	}
+
+	resolved := ResolveConnectionEnv(&conn, "production")
+	if len(resolved.Rules) != 2 || resolved.Rules[0]["type"] != "delay" || resolved.Rules[1]["type"] != "filter" {
+		t.Errorf("expected replaced delay rule followed by appended filter, got %v", resolved.Rules)
+	}
+}
+
+func TestResolveTransformationEnv_CodeFilesOverride(t *testing.T) {
+	tr := TransformationConfig{
+		Name:     "t1",
+		CodeFile: "dist/index.js",
+		EnvOverrides: map[string]*TransformationOverride{
+			"staging": {CodeFiles: []string{"src/**/*.js"}},
+		},
+	}
+
+	resolved := ResolveTransformationEnv(&tr, "staging")
+	if resolved.CodeFile != "" || len(resolved.CodeFiles) != 1 || resolved.CodeFiles[0] != "src/**/*.js" {
+		t.Errorf("expected code_files override to replace code_file, got code_file=%q code_files=%v", resolved.CodeFile, resolved.CodeFiles)
+	}
+	if resolved := ResolveTransformationEnv(&tr, "production"); resolved.CodeFile != "dist/index.js" {
+		t.Errorf("production: expected base code_file, got %q", resolved.CodeFile)
+	}
+}
+
+func TestResolveConnectionEnv_NameOverride(t *testing.T) {
+	conn := ConnectionConfig{
+		Name:        "orders",
+		Source:      "s1",
+		Destination: "d1",
+		Env: map[string]*ConnectionOverride{
+			"staging":    {Name: "orders-staging"},
+			"production": {Name: "{{.Source}}-to-{{.Destination}}", Destination: "d-prod"},
+		},
+	}
+
+	if got := ResolveConnectionEnv(&conn, "staging").Name; got != "orders-staging" {
+		t.Errorf("staging: expected orders-staging, got %q", got)
+	}
+	if got := ResolveConnectionEnv(&conn, "production").Name; got != "s1-to-d-prod" {
+		t.Errorf("production: expected rendered s1-to-d-prod, got %q", got)
+	}
+	if got := ResolveConnectionEnv(&conn, "dev").Name; got != "orders" {
+		t.Errorf("dev: expected base name, got %q", got)
+	}
+}
@@ -0,0 +1,140 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/tailscale/hujson"
+	"github.com/toppynl/hookdeck-deploy-cli/schemas"
+)
+
+// SchemaViolation is one place where a manifest doesn't match the JSON
+// Schema. Path is a JSON pointer into the manifest ("" for the root) and
+// Line is its 1-based line, or 0 when it couldn't be located.
+type SchemaViolation struct {
+	Path    string
+	Line    int
+	Message string
+}
+
+func (v *SchemaViolation) Error() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+	if v.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", v.Line, path, v.Message)
+	}
+	return fmt.Sprintf("%s: %s", path, v.Message)
+}
+
+// ValidateSchemaFile reads a JSONC manifest and validates it against the
+// embedded deploy manifest schema. See ValidateSchema.
+func ValidateSchemaFile(path string) ([]error, error) {
+	return validateFile(path, ValidateSchema)
+}
+
+// ValidateProjectSchemaFile reads a JSONC project config and validates it
+// against the embedded project schema. See ValidateSchema.
+func ValidateProjectSchemaFile(path string) ([]error, error) {
+	return validateFile(path, ValidateProjectSchema)
+}
+
+func validateFile(path string, validate func([]byte) ([]error, error)) ([]error, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading manifest: %w", err)
+	}
+	standardized, err := hujson.Standardize(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing JSONC: %w", err)
+	}
+	return validate(standardized)
+}
+
+// ValidateSchema validates standardized manifest JSON (as produced by
+// hujson.Standardize) against the embedded deploy manifest schema. Each
+// violation is returned as a *SchemaViolation, ordered by line. Standardizing
+// keeps byte offsets, so lines match the original JSONC file. The returned
+// error is only set when the input or schema can't be processed at all.
+func ValidateSchema(standardized []byte) ([]error, error) {
+	return validateAgainst(standardized, "hookdeck-deploy.schema.json", schemas.DeploySchema)
+}
+
+// ValidateProjectSchema is ValidateSchema for a project config, checked
+// against the embedded project schema.
+func ValidateProjectSchema(standardized []byte) ([]error, error) {
+	return validateAgainst(standardized, "hookdeck-project.schema.json", schemas.ProjectSchema)
+}
+
+func validateAgainst(standardized []byte, name, source string) ([]error, error) {
+	schema, err := jsonschema.CompileString(name, source)
+	if err != nil {
+		return nil, fmt.Errorf("compiling %s: %w", name, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(standardized))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return nil, fmt.Errorf("validating manifest: %w", err)
+	}
+
+	root, _ := hujson.Parse(standardized)
+
+	// Only leaf errors are reported; their parents just say "doesn't validate".
+	var violations []*SchemaViolation
+	seen := map[string]bool{}
+	var collect func(e *jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) > 0 {
+			for _, c := range e.Causes {
+				collect(c)
+			}
+			return
+		}
+		key := e.InstanceLocation + "\x00" + e.Message
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		violations = append(violations, &SchemaViolation{
+			Path:    e.InstanceLocation,
+			Line:    lineOf(&root, standardized, e.InstanceLocation),
+			Message: e.Message,
+		})
+	}
+	collect(verr)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Line < violations[j].Line
+	})
+	errs := make([]error, len(violations))
+	for i, v := range violations {
+		errs[i] = v
+	}
+	return errs, nil
+}
+
+// lineOf returns the 1-based line in data of the value at the JSON pointer
+// ptr within root, or 0 if it can't be found.
+func lineOf(root *hujson.Value, data []byte, ptr string) int {
+	v := root.Find(ptr)
+	if v == nil {
+		return 0
+	}
+	return bytes.Count(data[:v.StartOffset], []byte("\n")) + 1
+}
@@ -0,0 +1,91 @@
+package manifest
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tailscale/hujson"
+)
+
+func TestValidateSchema_Valid(t *testing.T) {
+	data, err := hujson.Standardize([]byte(`{
+		// comment
+		"sources": [{"name": "src", "type": "WEBHOOK"}],
+		"destinations": [{"name": "dst", "url": "https://example.com"}],
+		"connections": [{"name": "conn", "source": "src", "destination": "dst"}],
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := ValidateSchema(data)
+	if err != nil {
+		t.Fatalf("ValidateSchema failed: %v", err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("expected no violations, got %v", errs)
+	}
+}
+
+func TestValidateSchema_ReportsPathAndLine(t *testing.T) {
+	data, err := hujson.Standardize([]byte(`{
+	// the name must be a string
+	"sources": [
+		{"name": 42}
+	]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := ValidateSchema(data)
+	if err != nil {
+		t.Fatalf("ValidateSchema failed: %v", err)
+	}
+	if len(errs) == 0 {
+		t.Fatal("expected a violation")
+	}
+	v, ok := errs[0].(*SchemaViolation)
+	if !ok {
+		t.Fatalf("expected *SchemaViolation, got %T", errs[0])
+	}
+	if v.Path != "/sources/0/name" || v.Line != 4 {
+		t.Errorf("expected /sources/0/name on line 4, got %s on line %d", v.Path, v.Line)
+	}
+	if !strings.HasPrefix(v.Error(), "line 4: /sources/0/name: ") {
+		t.Errorf("unexpected message: %s", v.Error())
+	}
+}
+
+func TestValidateSchemaFile_Examples(t *testing.T) {
+	paths, err := filepath.Glob("../../example/*/*/hookdeck.jsonc")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example manifests found: %v", err)
+	}
+	for _, path := range paths {
+		errs, err := ValidateSchemaFile(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(errs) != 0 {
+			t.Errorf("%s: unexpected violations: %v", path, errs)
+		}
+	}
+}
+
+func TestValidateProjectSchema(t *testing.T) {
+	errs, err := ValidateProjectSchemaFile("../../example/hookdeck.project.jsonc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("example project config: unexpected violations: %v", errs)
+	}
+
+	errs, err = ValidateProjectSchema([]byte(`{"version": "2", "env": {"staging": {"profle": "x"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 1 || errs[0].(*SchemaViolation).Path != "/env/staging" {
+		t.Errorf("expected one violation at /env/staging, got %v", errs)
+	}
+}
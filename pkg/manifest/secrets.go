@@ -0,0 +1,112 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// SecretResolver resolves the key of a ${provider:key} reference, such as
+// ${vault:payments/stripe#secret}, for the provider it is registered under.
+type SecretResolver interface {
+	ResolveSecret(key string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to a SecretResolver.
+type SecretResolverFunc func(key string) (string, error)
+
+// ResolveSecret calls f(key).
+func (f SecretResolverFunc) ResolveSecret(key string) (string, error) {
+	return f(key)
+}
+
+var (
+	secretResolversMu sync.RWMutex
+	secretResolvers   = map[string]SecretResolver{
+		"env": SecretResolverFunc(resolveEnvSecret),
+	}
+)
+
+// RegisterSecretResolver makes r resolve ${provider:key} references.
+// Registering a provider again replaces its resolver; a nil r unregisters
+// it. The "env" provider, which reads the process environment, is registered
+// by default.
+func RegisterSecretResolver(provider string, r SecretResolver) {
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+	if r == nil {
+		delete(secretResolvers, provider)
+		return
+	}
+	secretResolvers[provider] = r
+}
+
+// secretResolver returns the resolver registered for provider, if any.
+func secretResolver(provider string) (SecretResolver, bool) {
+	secretResolversMu.RLock()
+	defer secretResolversMu.RUnlock()
+	r, ok := secretResolvers[provider]
+	return r, ok
+}
+
+// resolveEnvSecret resolves ${env:NAME} from the process environment.
+func resolveEnvSecret(key string) (string, error) {
+	if v, ok := os.LookupEnv(key); ok {
+		return v, nil
+	}
+	return "", fmt.Errorf("environment variable %s is not set", key)
+}
+
+// SecretLookup resolves ${provider:key} references through the registered
+// SecretResolvers. Use its Lookup in a ChainLookup and check Err after
+// interpolating, since a VarLookup can't report why a value is missing.
+// Each reference is resolved at most once.
+type SecretLookup struct {
+	mu     sync.Mutex
+	values map[string]string
+	failed map[string]bool
+	errs   []error
+}
+
+// Lookup is a VarLookup for "provider:key" names whose provider has a
+// registered resolver. Other names are not found, and neither is a
+// reference whose resolver fails; the failure is recorded for Err.
+func (s *SecretLookup) Lookup(name string) (string, bool) {
+	provider, key, ok := strings.Cut(name, ":")
+	if !ok {
+		return "", false
+	}
+	r, ok := secretResolver(provider)
+	if !ok {
+		return "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.values[name]; ok {
+		return v, true
+	}
+	if s.failed[name] {
+		return "", false
+	}
+	if s.values == nil {
+		s.values, s.failed = map[string]string{}, map[string]bool{}
+	}
+	v, err := r.ResolveSecret(key)
+	if err != nil {
+		s.failed[name] = true
+		s.errs = append(s.errs, fmt.Errorf("resolving ${%s}: %w", name, err))
+		return "", false
+	}
+	s.values[name] = v
+	return v, true
+}
+
+// Err returns the resolver failures recorded by Lookup, or nil.
+func (s *SecretLookup) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return errors.Join(s.errs...)
+}
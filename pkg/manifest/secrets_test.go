@@ -0,0 +1,59 @@
+package manifest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInterpolateEnvVars_SecretResolvers(t *testing.T) {
+	calls := 0
+	RegisterSecretResolver("vault", SecretResolverFunc(func(key string) (string, error) {
+		calls++
+		if key == "missing" {
+			return "", errors.New("no such secret")
+		}
+		return "vault-" + key, nil
+	}))
+	t.Cleanup(func() { RegisterSecretResolver("vault", nil) })
+	t.Setenv("SECRET_TEST_TOKEN", "from-env")
+
+	m := &Manifest{Destinations: []DestinationConfig{{
+		Name:      "dst",
+		URL:       "https://example.com/${vault:payments/url}",
+		AuthValue: "${env:SECRET_TEST_TOKEN}",
+		AuthType:  "${vault:payments/url}",
+	}}}
+	if err := InterpolateEnvVars(m); err != nil {
+		t.Fatalf("InterpolateEnvVars failed: %v", err)
+	}
+	dst := m.Destinations[0]
+	if dst.URL != "https://example.com/vault-payments/url" {
+		t.Errorf("expected vault reference resolved, got %q", dst.URL)
+	}
+	if dst.AuthValue != "from-env" {
+		t.Errorf("expected env reference resolved, got %q", dst.AuthValue)
+	}
+	if calls != 1 {
+		t.Errorf("expected a repeated reference to be resolved once, got %d calls", calls)
+	}
+
+	m = &Manifest{Sources: []SourceConfig{{Name: "${vault:missing}"}}}
+	err := InterpolateEnvVars(m)
+	if err == nil || !strings.Contains(err.Error(), "${vault:missing}") || !strings.Contains(err.Error(), "no such secret") {
+		t.Errorf("expected resolver error naming the reference, got %v", err)
+	}
+}
+
+func TestSecretLookup_UnregisteredProvider(t *testing.T) {
+	s := &SecretLookup{}
+	if _, ok := s.Lookup("aws:payments"); ok {
+		t.Error("expected an unregistered provider not to be found")
+	}
+	if _, ok := s.Lookup("PLAIN_VAR"); ok {
+		t.Error("expected a plain variable not to be found")
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
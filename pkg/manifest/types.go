@@ -2,11 +2,45 @@ package manifest
 
 // Manifest is the top-level structure of a hookdeck.jsonc file.
 type Manifest struct {
-	Schema          string                `json:"$schema,omitempty"`
-	Sources         []SourceConfig        `json:"sources,omitempty"`
-	Destinations    []DestinationConfig   `json:"destinations,omitempty"`
+	Schema string `json:"$schema,omitempty"`
+	// Version is the manifest format version (see ManifestVersion). It is
+	// optional; manifests without one are read as the current version.
+	Version         string                 `json:"version,omitempty"`
+	Sources         []SourceConfig         `json:"sources,omitempty"`
+	Destinations    []DestinationConfig    `json:"destinations,omitempty"`
 	Transformations []TransformationConfig `json:"transformations,omitempty"`
-	Connections     []ConnectionConfig    `json:"connections,omitempty"`
+	Connections     []ConnectionConfig     `json:"connections,omitempty"`
+	// RulesMerge sets the default rules_merge mode for every connection in
+	// this manifest. Connections may override it individually.
+	RulesMerge string `json:"rules_merge,omitempty"`
+	// APIBaseURL overrides the Hookdeck API base URL for commands run
+	// against this manifest (e.g. for another region).
+	APIBaseURL string `json:"api_base_url,omitempty"`
+	// Vars are default values for ${VAR} interpolation, used when a variable
+	// isn't set by --var, the process environment, or an env file. They are
+	// never sent to Hookdeck.
+	Vars map[string]string `json:"vars,omitempty"`
+
+	// lines holds the 1-based line of each resource in the file the
+	// manifest was loaded from, keyed by its array ("sources", ...).
+	lines map[string][]int
+}
+
+// Line returns the line of the i-th resource in the manifest's key array
+// ("sources", "destinations", "transformations" or "connections") in the
+// file it was loaded from, or 0 when unknown.
+func (m *Manifest) Line(key string, i int) int {
+	if i < 0 || i >= len(m.lines[key]) {
+		return 0
+	}
+	return m.lines[key][i]
+}
+
+// IsEnabled reports whether a resource with the given Enabled setting should
+// be deployed. Resources are enabled unless Enabled is explicitly false, in
+// the base config or the env overlay.
+func IsEnabled(enabled *bool) bool {
+	return enabled == nil || *enabled
 }
 
 // SourceConfig defines a Hookdeck source (aligned with API schema).
@@ -15,7 +49,30 @@ type SourceConfig struct {
 	Type        string                       `json:"type,omitempty"`
 	Description string                       `json:"description,omitempty"`
 	Config      map[string]interface{}       `json:"config,omitempty"`
+	Enabled     *bool                        `json:"enabled,omitempty"`
 	Env         map[string]*SourceOverride   `json:"env,omitempty"`
+
+	// Shorthands for common config keys. They are written into config and
+	// take precedence over the same key in Config.
+	AllowedHTTPMethods []string        `json:"allowed_http_methods,omitempty"`
+	CustomResponse     *CustomResponse `json:"custom_response,omitempty"`
+
+	// External marks a placeholder for a source managed elsewhere, such as
+	// another repository. Connections may reference it, but it is never
+	// deployed; the API resolves the reference by name.
+	External bool `json:"external,omitempty"`
+
+	// DependsOn names resources to deploy before this one in project mode,
+	// beyond those it references. A plain name is a resource of the same
+	// kind; "kind:name" (e.g. "destination:audit") names another kind.
+	DependsOn []string `json:"depends_on,omitempty"`
+}
+
+// CustomResponse is the response a source returns to the webhook sender
+// instead of Hookdeck's default.
+type CustomResponse struct {
+	ContentType string `json:"content_type,omitempty"` // "json", "text", or "xml"
+	Body        string `json:"body,omitempty"`
 }
 
 // SourceOverride holds per-environment overrides for a source.
@@ -23,6 +80,10 @@ type SourceOverride struct {
 	Type        string                 `json:"type,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Config      map[string]interface{} `json:"config,omitempty"`
+	Enabled     *bool                  `json:"enabled,omitempty"`
+
+	AllowedHTTPMethods []string        `json:"allowed_http_methods,omitempty"`
+	CustomResponse     *CustomResponse `json:"custom_response,omitempty"`
 }
 
 // DestinationConfig defines a Hookdeck destination (aligned with API schema).
@@ -36,7 +97,28 @@ type DestinationConfig struct {
 	Config          map[string]interface{}             `json:"config,omitempty"`
 	RateLimit       int                                `json:"rate_limit,omitempty"`
 	RateLimitPeriod string                             `json:"rate_limit_period,omitempty"`
+	Enabled         *bool                              `json:"enabled,omitempty"`
 	Env             map[string]*DestinationOverride    `json:"env,omitempty"`
+
+	// Shorthands for common config keys. They are written into config and
+	// take precedence over the same key in Config.
+	PathForwardingDisabled *bool  `json:"path_forwarding_disabled,omitempty"`
+	HTTPMethod             string `json:"http_method,omitempty"`
+
+	// Shorthands for the auth object of common schemes, expanded by
+	// DestinationAuth: auth_header and auth_value for API_KEY, auth_value
+	// for BEARER_TOKEN, and auth_username and auth_password for BASIC_AUTH.
+	AuthHeader   string `json:"auth_header,omitempty"`
+	AuthValue    string `json:"auth_value,omitempty"`
+	AuthUsername string `json:"auth_username,omitempty"`
+	AuthPassword string `json:"auth_password,omitempty"`
+
+	// External marks a placeholder for a destination managed elsewhere; see
+	// SourceConfig.External.
+	External bool `json:"external,omitempty"`
+
+	// DependsOn: see SourceConfig.DependsOn.
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 // DestinationOverride holds per-environment overrides for a destination.
@@ -49,41 +131,98 @@ type DestinationOverride struct {
 	Config          map[string]interface{} `json:"config,omitempty"`
 	RateLimit       int                    `json:"rate_limit,omitempty"`
 	RateLimitPeriod string                 `json:"rate_limit_period,omitempty"`
+	Enabled         *bool                  `json:"enabled,omitempty"`
+
+	PathForwardingDisabled *bool  `json:"path_forwarding_disabled,omitempty"`
+	HTTPMethod             string `json:"http_method,omitempty"`
+
+	AuthHeader   string `json:"auth_header,omitempty"`
+	AuthValue    string `json:"auth_value,omitempty"`
+	AuthUsername string `json:"auth_username,omitempty"`
+	AuthPassword string `json:"auth_password,omitempty"`
 }
 
 // ConnectionConfig defines a Hookdeck connection between a source and destination (aligned with API schema).
 type ConnectionConfig struct {
+	// Name may be a text/template such as "{{.Source}}-to-{{.Destination}}",
+	// rendered with the env-resolved endpoint names (see RenderConnectionName).
 	Name        string                   `json:"name,omitempty"`
+	Description string                   `json:"description,omitempty"`
 	Source      string                   `json:"source,omitempty"`
 	Destination string                   `json:"destination,omitempty"`
 	Rules       []map[string]interface{} `json:"rules,omitempty"`
+
+	// SourceID and DestinationID reference an endpoint by literal ID (e.g. one
+	// in another Hookdeck project), bypassing name resolution. Each is
+	// mutually exclusive with its name-based counterpart.
+	SourceID      string `json:"source_id,omitempty"`
+	DestinationID string `json:"destination_id,omitempty"`
+
 	// Shorthand fields — converted to rules during deploy
-	Filter          map[string]interface{}          `json:"filter,omitempty"`
-	Transformations []string                        `json:"transformations,omitempty"`
-	Env             map[string]*ConnectionOverride  `json:"env,omitempty"`
+	Filter          map[string]interface{} `json:"filter,omitempty"`
+	Transformations []string               `json:"transformations,omitempty"`
+	// Delay holds each event for this many seconds before delivery. It is
+	// sent as a delay rule, which Hookdeck takes in milliseconds.
+	Delay int                            `json:"delay,omitempty"`
+	Env   map[string]*ConnectionOverride `json:"env,omitempty"`
+	// RulesMerge controls how env override rules combine with the base rules:
+	// RulesMergeReplace (default) or RulesMergeByType.
+	RulesMerge string `json:"rules_merge,omitempty"`
+	// Disabled deploys the connection in a paused state.
+	Disabled bool `json:"disabled,omitempty"`
+	// Enabled set to false leaves the connection out of deploys entirely
+	// (see IsEnabled), unlike Disabled which deploys it paused.
+	Enabled *bool `json:"enabled,omitempty"`
+	// DependsOn: see SourceConfig.DependsOn.
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
+// Rules merge modes for ConnectionConfig.RulesMerge.
+const (
+	// RulesMergeReplace replaces the base rules wholesale with the override's rules.
+	RulesMergeReplace = "replace"
+	// RulesMergeByType merges override rules into the base rules keyed by their "type".
+	RulesMergeByType = "by_type"
+)
+
 // ConnectionOverride holds per-environment overrides for a connection.
+// Name may be a name template like ConnectionConfig.Name. RulesAppend rules
+// are added after the (possibly overridden) rules instead of replacing them.
 type ConnectionOverride struct {
+	Name            string                   `json:"name,omitempty"`
+	Description     string                   `json:"description,omitempty"`
 	Source          string                   `json:"source,omitempty"`
 	Destination     string                   `json:"destination,omitempty"`
 	Rules           []map[string]interface{} `json:"rules,omitempty"`
+	RulesAppend     []map[string]interface{} `json:"rules_append,omitempty"`
 	Filter          map[string]interface{}   `json:"filter,omitempty"`
 	Transformations []string                 `json:"transformations,omitempty"`
+	Delay           *int                     `json:"delay,omitempty"` // 0 removes the base delay
+	Disabled        *bool                    `json:"disabled,omitempty"`
+	Enabled         *bool                    `json:"enabled,omitempty"`
 }
 
-// TransformationConfig defines a Hookdeck transformation.
+// TransformationConfig defines a Hookdeck transformation. Its code comes from
+// CodeFile or CodeFiles (mutually exclusive); both accept globs such as
+// "src/**/*.js", and matched files are concatenated in sorted order.
 type TransformationConfig struct {
 	Name         string                                `json:"name,omitempty"`
 	Description  string                                `json:"description,omitempty"`
 	CodeFile     string                                `json:"code_file,omitempty"`
+	CodeFiles    []string                              `json:"code_files,omitempty"`
+	Enabled      *bool                                 `json:"enabled,omitempty"`
 	Env          map[string]string                     `json:"env,omitempty"`
 	EnvOverrides map[string]*TransformationOverride    `json:"env_overrides,omitempty"`
+
+	// DependsOn: see SourceConfig.DependsOn.
+	DependsOn []string `json:"depends_on,omitempty"`
 }
 
 // TransformationOverride holds per-environment config overrides for a transformation.
 type TransformationOverride struct {
 	Description string            `json:"description,omitempty"`
 	CodeFile    string            `json:"code_file,omitempty"`
+	CodeFiles   []string          `json:"code_files,omitempty"`
+	Enabled     *bool             `json:"enabled,omitempty"`
 	Env         map[string]string `json:"env,omitempty"`
 }
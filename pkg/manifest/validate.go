@@ -0,0 +1,494 @@
+package manifest
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceTypes lists the source types accepted by the Hookdeck API.
+var SourceTypes = []string{
+	"WEBHOOK", "HTTP", "MANAGED", "PUBLISH_API", "HOOKDECK_OUTPOST",
+	"ADYEN", "AIPRISE", "AIRTABLE", "AIRWALLEX", "AKENEO", "ALIPAY", "ASANA",
+	"ASCEND", "AWS_SNS", "BIGCOMMERCE", "BONDSMITH", "BRIDGE_API", "BRIDGE_XYZ",
+	"CHARGEBEE_BILLING", "CIRCLE", "CLIO", "CLOUDSIGNAL", "COINBASE",
+	"COMMERCELAYER", "COURIER", "CURSOR", "CUSTOMERIO", "DISCORD", "DOCUSIGN",
+	"EBAY", "ENODE", "ETHOCA", "EXACT_ONLINE", "FACEBOOK", "FASTSPRING",
+	"FAUNDIT", "FAVRO", "FIREBLOCKS", "FISERV", "FLEXPORT", "FRONTAPP",
+	"FUSIONAUTH", "GITHUB", "GITLAB", "GOCARDLESS", "HUBSPOT", "INTERCOM",
+	"LINEAR", "LINKEDIN", "LITHIC", "MAILCHIMP", "MAILGUN", "MERAKI", "MONDAY",
+	"NMI", "NUVEMSHOP", "NYLAS", "OKTA", "OPENAI", "ORB", "OURA", "PADDLE",
+	"PAYPAL", "PAYPRO_GLOBAL", "PERSONA", "PICQER", "PIPEDRIVE", "POLAR",
+	"PORTAL", "POSTMARK", "PRAXIS", "PROPERTY-FINDER", "PYLON", "RAZORPAY",
+	"RECHARGE", "RECURLY", "REPAY", "REPLICATE", "RESEND", "RING_CENTRAL",
+	"SANITY", "SENDGRID", "SHOPIFY", "SHOPLINE", "SLACK", "SMARTCAR", "SMILE",
+	"SOLIDGATE", "SQUARE", "STRAVA", "STRIPE", "SVIX", "SYNCTERA", "TALLY",
+	"TEBEX", "TELNYX", "THREE_D_EYE", "TIKTOK", "TIKTOK_SHOP", "TOKENIO",
+	"TREEZOR", "TRELLO", "TWILIO", "TWITCH", "TWITTER", "TYPEFORM", "UPOLLO",
+	"USPS", "UTILA", "VERCEL", "VERCEL_LOG_DRAINS", "WHATSAPP", "WIX",
+	"WOOCOMMERCE", "WORKOS", "XERO", "ZENDESK", "ZEROHASH", "ZIFT", "ZOOM",
+}
+
+// DestinationTypes lists the destination types accepted by the Hookdeck API.
+var DestinationTypes = []string{"HTTP", "CLI", "MOCK_API", "HOOKDECK_OUTPOST"}
+
+// ValidateTypes checks every source and destination type in m against the
+// known Hookdeck types. Matching is case-sensitive; an empty type is left for
+// the API to default. Each error suggests the closest known type if one is
+// near enough.
+func ValidateTypes(m *Manifest) []error {
+	var errs []error
+	for _, src := range m.Sources {
+		if err := checkType("source", src.Name, src.Type, SourceTypes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, dst := range m.Destinations {
+		if err := checkType("destination", dst.Name, dst.Type, DestinationTypes); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ValidateURLs checks that every destination URL parses and has an http or
+// https scheme and a host, which catches typos such as "htpps://". Only
+// HTTP destinations (including those with no type) are checked, and a URL
+// still holding a ${VAR} reference is left for a later stage to fill in.
+func ValidateURLs(m *Manifest) []error {
+	var errs []error
+	for _, dst := range m.Destinations {
+		if !hasCheckableURL(dst) {
+			continue
+		}
+		u, err := url.Parse(dst.URL)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Errorf("destination %q: invalid url: %v", dst.Name, err))
+		case u.Scheme == "" || u.Host == "":
+			errs = append(errs, fmt.Errorf("destination %q: url %q must include a scheme and host, e.g. https://example.com/webhooks", dst.Name, dst.URL))
+		case u.Scheme != "http" && u.Scheme != "https":
+			errs = append(errs, fmt.Errorf("destination %q: url %q has unsupported scheme %q; expected https", dst.Name, dst.URL, u.Scheme))
+		}
+	}
+	return errs
+}
+
+// InsecureURLs returns a problem for every destination URL checked by
+// ValidateURLs that uses plain http to a host other than localhost or a
+// loopback address. Callers treat these as warnings unless told to be strict.
+func InsecureURLs(m *Manifest) []error {
+	var errs []error
+	for _, dst := range m.Destinations {
+		if !hasCheckableURL(dst) {
+			continue
+		}
+		u, err := url.Parse(dst.URL)
+		if err != nil || u.Scheme != "http" || isLocalHost(u.Hostname()) {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("destination %q: url %q uses plain http; use https for endpoints that aren't local", dst.Name, dst.URL))
+	}
+	return errs
+}
+
+// hasCheckableURL reports whether dst is an HTTP destination with a fully
+// interpolated URL. Other destination types don't take a URL.
+func hasCheckableURL(dst DestinationConfig) bool {
+	if dst.URL == "" || envVarPattern.MatchString(dst.URL) {
+		return false
+	}
+	return dst.Type == "" || dst.Type == "HTTP"
+}
+
+// isLocalHost reports whether host is localhost or a loopback address.
+func isLocalHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ValidateAuth checks that every destination's auth shorthand fields belong
+// to its auth_type (see DestinationAuth), so a mistyped or missing auth_type
+// doesn't silently drop them.
+func ValidateAuth(m *Manifest) []error {
+	allowed := map[string]map[string]bool{
+		AuthTypeAPIKey:      {"auth_header": true, "auth_value": true},
+		AuthTypeBearerToken: {"auth_value": true},
+		AuthTypeBasicAuth:   {"auth_username": true, "auth_password": true},
+	}
+	var errs []error
+	for _, dst := range m.Destinations {
+		set := map[string]bool{
+			"auth_header":   dst.AuthHeader != "",
+			"auth_value":    dst.AuthValue != "",
+			"auth_username": dst.AuthUsername != "",
+			"auth_password": dst.AuthPassword != "",
+		}
+		for _, field := range []string{"auth_header", "auth_value", "auth_username", "auth_password"} {
+			if !set[field] || allowed[dst.AuthType][field] {
+				continue
+			}
+			if dst.AuthType == "" {
+				errs = append(errs, fmt.Errorf("destination %q: %s requires auth_type", dst.Name, field))
+			} else {
+				errs = append(errs, fmt.Errorf("destination %q: %s is not used by auth_type %q", dst.Name, field, dst.AuthType))
+			}
+		}
+	}
+	return errs
+}
+
+// Retry rule limits enforced by the Hookdeck API.
+const (
+	MaxRetryCount    = 50
+	MaxRetryInterval = 24 * 60 * 60 * 1000     // milliseconds (one day)
+	MaxRetryWindow   = 7 * 24 * 60 * 60 * 1000 // milliseconds (one week)
+)
+
+// DefaultExponentialRetryInterval is the base interval, in milliseconds, sent
+// for an exponential retry rule that doesn't set one.
+const DefaultExponentialRetryInterval = 60 * 1000
+
+// RetryStrategies lists the accepted retry rule strategies.
+var RetryStrategies = []string{"linear", "exponential"}
+
+// ValidateRules checks the rules of every connection in m. Only retry rules
+// are inspected: strategy must be known, count a positive integer up to
+// MaxRetryCount, and interval (when set) a positive number of milliseconds
+// up to MaxRetryInterval. Exponential rules may also set max_interval, which
+// must be within the same bounds and at least interval, and count times
+// interval must fit in MaxRetryWindow. response_status_codes, when set, must
+// list status code patterns (see checkStatusCodePattern). Errors name the
+// connection and rule index. The delay shorthand must not be negative, nor
+// be combined with an explicit delay rule.
+func ValidateRules(m *Manifest) []error {
+	var errs []error
+	for _, conn := range m.Connections {
+		if conn.Delay < 0 {
+			errs = append(errs, fmt.Errorf("connection %q: delay must not be negative, got %d", conn.Name, conn.Delay))
+		}
+		for i, rule := range conn.Rules {
+			if rule["type"] == "delay" && conn.Delay != 0 {
+				errs = append(errs, fmt.Errorf("connection %q rules[%d]: delay rule conflicts with the delay shorthand; use one or the other", conn.Name, i))
+			}
+			if rule["type"] != "retry" {
+				continue
+			}
+			for _, problem := range checkRetryRule(rule) {
+				errs = append(errs, fmt.Errorf("connection %q rules[%d]: %s", conn.Name, i, problem))
+			}
+		}
+	}
+	return errs
+}
+
+func checkRetryRule(rule map[string]interface{}) []string {
+	var problems []string
+
+	strategy, _ := rule["strategy"].(string)
+	known := false
+	for _, s := range RetryStrategies {
+		if strategy == s {
+			known = true
+		}
+	}
+	if !known {
+		problems = append(problems, fmt.Sprintf("retry strategy must be one of %s, got %v",
+			strings.Join(RetryStrategies, ", "), formatRuleValue(rule["strategy"])))
+	}
+
+	if count, ok := ruleInt(rule["count"]); !ok || count < 1 || count > MaxRetryCount {
+		problems = append(problems, fmt.Sprintf("retry count must be an integer between 1 and %d, got %v",
+			MaxRetryCount, formatRuleValue(rule["count"])))
+	}
+
+	interval, intervalOK := 0, true
+	if v, set := rule["interval"]; set {
+		if interval, intervalOK = ruleInt(v); !intervalOK || interval < 1 || interval > MaxRetryInterval {
+			intervalOK = false
+			problems = append(problems, fmt.Sprintf("retry interval must be an integer number of milliseconds between 1 and %d, got %v",
+				MaxRetryInterval, formatRuleValue(v)))
+		}
+	}
+
+	if v, set := rule["response_status_codes"]; set {
+		problems = append(problems, checkStatusCodes(v)...)
+	}
+
+	// The remaining checks only apply to exponential rules, and need a
+	// valid interval to compare against.
+	if strategy != "exponential" || !intervalOK {
+		return problems
+	}
+	if interval == 0 {
+		interval = DefaultExponentialRetryInterval
+	}
+	if v, set := rule["max_interval"]; set {
+		if maxInterval, ok := ruleInt(v); !ok || maxInterval < 1 || maxInterval > MaxRetryInterval {
+			problems = append(problems, fmt.Sprintf("retry max_interval must be an integer number of milliseconds between 1 and %d, got %v",
+				MaxRetryInterval, formatRuleValue(v)))
+		} else if maxInterval < interval {
+			problems = append(problems, fmt.Sprintf("retry max_interval (%d) must not be less than interval (%d)", maxInterval, interval))
+		}
+	}
+	if count, ok := ruleInt(rule["count"]); ok && count >= 1 && count*interval > MaxRetryWindow {
+		problems = append(problems, fmt.Sprintf("retry count * interval (%d * %d ms) exceeds Hookdeck's retry window of %d ms (7 days)",
+			count, interval, MaxRetryWindow))
+	}
+	return problems
+}
+
+// checkStatusCodes checks a retry rule's response_status_codes: a list of
+// strings, each a status code pattern.
+func checkStatusCodes(v interface{}) []string {
+	codes, ok := v.([]interface{})
+	if !ok {
+		return []string{fmt.Sprintf("retry response_status_codes must be a list of strings, got %v", formatRuleValue(v))}
+	}
+	var problems []string
+	for i, code := range codes {
+		s, ok := code.(string)
+		if !ok || !checkStatusCodePattern(s) {
+			problems = append(problems, fmt.Sprintf("retry response_status_codes[%d] must be a status code (\"404\"), range (\"500-599\"), or comparison (\">=400\"), optionally negated with \"!\", got %v",
+				i, formatRuleValue(code)))
+		}
+	}
+	return problems
+}
+
+// checkStatusCodePattern reports whether s is a status code pattern Hookdeck
+// accepts: a code, a "low-high" range, or a code after >, >=, < or <=, each
+// optionally prefixed with "!". Codes must be between 100 and 599.
+func checkStatusCodePattern(s string) bool {
+	s = strings.TrimPrefix(s, "!")
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if rest, ok := strings.CutPrefix(s, op); ok {
+			_, ok := statusCode(rest)
+			return ok
+		}
+	}
+	if low, high, ok := strings.Cut(s, "-"); ok {
+		lo, okLow := statusCode(low)
+		hi, okHigh := statusCode(high)
+		return okLow && okHigh && lo <= hi
+	}
+	_, ok := statusCode(s)
+	return ok
+}
+
+// statusCode parses s as a three-digit HTTP status code between 100 and
+// 599.
+func statusCode(s string) (int, bool) {
+	if len(s) != 3 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 100 || n > 599 {
+		return 0, false
+	}
+	return n, true
+}
+
+// FilterOperators lists the operators Hookdeck's filter syntax supports.
+var FilterOperators = []string{
+	"$eq", "$neq", "$gt", "$gte", "$lt", "$lte", "$in", "$nin",
+	"$startsWith", "$endsWith", "$exist", "$or", "$and", "$not", "$ref",
+}
+
+// filterRuleFields are the parts of a request an explicit filter rule can
+// match on.
+var filterRuleFields = []string{"body", "headers", "query", "path"}
+
+// ValidateFilters checks the operators in every connection's filter
+// shorthand and explicit filter rules against FilterOperators. Filters are
+// still sent as written; this only catches a mistyped operator (which the
+// API would not apply) or an operand of the wrong shape. Errors name the
+// connection and the JSON path of the offending key.
+func ValidateFilters(m *Manifest) []error {
+	var errs []error
+	for _, conn := range m.Connections {
+		var problems []string
+		if conn.Filter != nil {
+			problems = checkFilter("filter", conn.Filter, problems)
+		}
+		for i, rule := range conn.Rules {
+			if rule["type"] != "filter" {
+				continue
+			}
+			for _, field := range filterRuleFields {
+				if v, ok := rule[field]; ok {
+					problems = checkFilter(fmt.Sprintf("rules[%d].%s", i, field), v, problems)
+				}
+			}
+		}
+		for _, problem := range problems {
+			errs = append(errs, fmt.Errorf("connection %q %s", conn.Name, problem))
+		}
+	}
+	return errs
+}
+
+// checkFilter appends the problems found in the filter value v at path.
+// Keys starting with "$" must be known operators with a well-formed operand;
+// any other key is a field name, whose value is checked in turn.
+func checkFilter(path string, v interface{}, problems []string) []string {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return problems
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		val := obj[key]
+		keyPath := path + "." + key
+		if !strings.HasPrefix(key, "$") {
+			problems = checkFilter(keyPath, val, problems)
+			continue
+		}
+		switch key {
+		case "$or", "$and":
+			items, ok := val.([]interface{})
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: %s takes an array of filters, got %v", keyPath, key, formatRuleValue(val)))
+				continue
+			}
+			for i, item := range items {
+				if _, ok := item.(map[string]interface{}); !ok {
+					problems = append(problems, fmt.Sprintf("%s[%d]: %s takes an array of filters, got %v", keyPath, i, key, formatRuleValue(item)))
+					continue
+				}
+				problems = checkFilter(fmt.Sprintf("%s[%d]", keyPath, i), item, problems)
+			}
+		case "$not":
+			problems = checkFilter(keyPath, val, problems)
+		case "$gt", "$gte", "$lt", "$lte":
+			switch val.(type) {
+			case float64, int, string:
+			default:
+				problems = append(problems, fmt.Sprintf("%s: %s takes a number or string, got %v", keyPath, key, formatRuleValue(val)))
+			}
+		case "$startsWith", "$endsWith", "$ref":
+			if _, ok := val.(string); !ok {
+				problems = append(problems, fmt.Sprintf("%s: %s takes a string, got %v", keyPath, key, formatRuleValue(val)))
+			}
+		case "$exist":
+			if _, ok := val.(bool); !ok {
+				problems = append(problems, fmt.Sprintf("%s: $exist takes true or false, got %v", keyPath, formatRuleValue(val)))
+			}
+		case "$eq", "$neq", "$in", "$nin":
+			problems = checkFilter(keyPath, val, problems)
+		default:
+			problem := fmt.Sprintf("%s: unknown filter operator %q", keyPath, key)
+			if suggestion := closestOperator(key); suggestion != "" {
+				problem += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// closestOperator suggests the filter operator nearest to op, ignoring case.
+func closestOperator(op string) string {
+	upper := make([]string, len(FilterOperators))
+	for i, known := range FilterOperators {
+		upper[i] = strings.ToUpper(known)
+	}
+	match := closestMatch(op, upper)
+	for i, u := range upper {
+		if u == match {
+			return FilterOperators[i]
+		}
+	}
+	return ""
+}
+
+// ruleInt returns v as an integer if it is a whole number. Numbers decoded
+// from JSON arrive as float64.
+func ruleInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		if n != float64(int(n)) {
+			return 0, false
+		}
+		return int(n), true
+	}
+	return 0, false
+}
+
+// formatRuleValue renders a rule value for an error message.
+func formatRuleValue(v interface{}) string {
+	if v == nil {
+		return "nothing"
+	}
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprint(v)
+}
+
+func checkType(kind, name, typ string, known []string) error {
+	if typ == "" {
+		return nil
+	}
+	for _, k := range known {
+		if typ == k {
+			return nil
+		}
+	}
+	if suggestion := closestMatch(typ, known); suggestion != "" {
+		return fmt.Errorf("%s %q: unknown type %q (did you mean %q?)", kind, name, typ, suggestion)
+	}
+	return fmt.Errorf("%s %q: unknown type %q", kind, name, typ)
+}
+
+// closestMatch returns the candidate with the smallest edit distance to s
+// (compared case-insensitively, since miscasing is the most common mistake),
+// or "" if none is close enough to be a plausible typo.
+func closestMatch(s string, candidates []string) string {
+	upper := strings.ToUpper(s)
+	maxDist := len(s) / 3
+	if maxDist < 2 {
+		maxDist = 2
+	}
+
+	best, bestDist := "", maxDist+1
+	for _, c := range candidates {
+		if d := levenshtein(upper, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,329 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTypes_Valid(t *testing.T) {
+	m := &Manifest{
+		Sources:      []SourceConfig{{Name: "s1", Type: "STRIPE"}, {Name: "s2"}},
+		Destinations: []DestinationConfig{{Name: "d1", Type: "HTTP"}, {Name: "d2"}},
+	}
+	if errs := ValidateTypes(m); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateTypes_SuggestsClosestMatch(t *testing.T) {
+	tests := []struct {
+		typ  string
+		want string
+	}{
+		{"stripe", `did you mean "STRIPE"?`},
+		{"Shopify", `did you mean "SHOPIFY"?`},
+		{"GITHBU", `did you mean "GITHUB"?`},
+	}
+	for _, tt := range tests {
+		errs := ValidateTypes(&Manifest{Sources: []SourceConfig{{Name: "s", Type: tt.typ}}})
+		if len(errs) != 1 {
+			t.Fatalf("%s: expected 1 error, got %v", tt.typ, errs)
+		}
+		if !strings.Contains(errs[0].Error(), tt.want) {
+			t.Errorf("%s: expected %q in error, got %q", tt.typ, tt.want, errs[0])
+		}
+	}
+}
+
+func TestValidateTypes_NoSuggestionForUnrelatedType(t *testing.T) {
+	errs := ValidateTypes(&Manifest{Destinations: []DestinationConfig{{Name: "d", Type: "CARRIER_PIGEON"}}})
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error, got %v", errs)
+	}
+	if strings.Contains(errs[0].Error(), "did you mean") {
+		t.Errorf("expected no suggestion, got %q", errs[0])
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"STRIPE", "STRIPE", 0},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestValidateRules_Retry(t *testing.T) {
+	m := &Manifest{Connections: []ConnectionConfig{
+		{Name: "ok", Rules: []map[string]interface{}{
+			{"type": "filter", "body": map[string]interface{}{"a": 1}},
+			{"type": "retry", "strategy": "exponential", "count": float64(5), "interval": float64(60000)},
+		}},
+		{Name: "bad", Rules: []map[string]interface{}{
+			{"type": "delay", "delay": float64(1000)},
+			{"type": "retry", "strategy": "fibonacci", "count": float64(500), "interval": float64(-1)},
+		}},
+		{Name: "fractional", Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "linear", "count": 2.5},
+		}},
+	}}
+
+	errs := ValidateRules(m)
+	if len(errs) != 4 {
+		t.Fatalf("expected 4 errors, got %d: %v", len(errs), errs)
+	}
+	wants := []string{
+		`connection "bad" rules[1]: retry strategy`,
+		`connection "bad" rules[1]: retry count`,
+		`connection "bad" rules[1]: retry interval`,
+		`connection "fractional" rules[0]: retry count`,
+	}
+	for i, want := range wants {
+		if !strings.HasPrefix(errs[i].Error(), want) {
+			t.Errorf("error %d: expected prefix %q, got %q", i, want, errs[i])
+		}
+	}
+}
+
+func TestValidateRules_RetryRequiresCount(t *testing.T) {
+	m := &Manifest{Connections: []ConnectionConfig{
+		{Name: "c", Rules: []map[string]interface{}{{"type": "retry", "strategy": "linear"}}},
+	}}
+	errs := ValidateRules(m)
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "got nothing") {
+		t.Errorf("expected missing count error, got %v", errs)
+	}
+}
+
+func TestValidateRules_RetryStatusCodes(t *testing.T) {
+	retry := func(codes interface{}) map[string]interface{} {
+		return map[string]interface{}{"type": "retry", "strategy": "linear", "count": float64(3), "response_status_codes": codes}
+	}
+	m := &Manifest{Connections: []ConnectionConfig{
+		{Name: "ok", Rules: []map[string]interface{}{
+			retry([]interface{}{"404", "500-599", ">=400", "<300", "!401", "!500-504"}),
+		}},
+		{Name: "bad", Rules: []map[string]interface{}{
+			{"type": "delay", "delay": float64(1000)},
+			retry([]interface{}{"500", "6xx", "599-500", float64(404)}),
+		}},
+		{Name: "not-a-list", Rules: []map[string]interface{}{retry("5xx")}},
+	}}
+
+	errs := ValidateRules(m)
+	wants := []string{
+		`connection "bad" rules[1]: retry response_status_codes[1] must be a status code ("404"), range ("500-599"), or comparison (">=400"), optionally negated with "!", got "6xx"`,
+		`connection "bad" rules[1]: retry response_status_codes[2] must be`,
+		`connection "bad" rules[1]: retry response_status_codes[3] must be`,
+		`connection "not-a-list" rules[0]: retry response_status_codes must be a list of strings, got "5xx"`,
+	}
+	if len(errs) != len(wants) {
+		t.Fatalf("expected %d errors, got %d: %v", len(wants), len(errs), errs)
+	}
+	for i, want := range wants {
+		if !strings.HasPrefix(errs[i].Error(), want) {
+			t.Errorf("error %d: expected prefix %q, got %q", i, want, errs[i])
+		}
+	}
+}
+
+func TestValidateRules_DelayShorthand(t *testing.T) {
+	m := &Manifest{Connections: []ConnectionConfig{
+		{Name: "ok", Delay: 30},
+		{Name: "negative", Delay: -5},
+		{Name: "both", Delay: 30, Rules: []map[string]interface{}{{"type": "delay", "delay": float64(1000)}}},
+	}}
+
+	errs := ValidateRules(m)
+	wants := []string{
+		`connection "negative": delay must not be negative, got -5`,
+		`connection "both" rules[0]: delay rule conflicts with the delay shorthand; use one or the other`,
+	}
+	if len(errs) != len(wants) {
+		t.Fatalf("expected %d errors, got %d: %v", len(wants), len(errs), errs)
+	}
+	for i, want := range wants {
+		if errs[i].Error() != want {
+			t.Errorf("error %d: expected %q, got %q", i, want, errs[i])
+		}
+	}
+}
+
+func TestValidateURLs(t *testing.T) {
+	m := &Manifest{Destinations: []DestinationConfig{
+		{Name: "ok", URL: "https://example.com/webhooks"},
+		{Name: "local", URL: "http://localhost:8080/hook"},
+		{Name: "typo", URL: "htpps://example.com/webhooks"},
+		{Name: "no-scheme", URL: "example.com/webhooks"},
+		{Name: "bad-host", URL: "https://exa mple.com"},
+		{Name: "cli", Type: "CLI", URL: "not a url"},
+		{Name: "unresolved", URL: "https://${API_HOST}/webhooks"},
+		{Name: "empty"},
+	}}
+
+	errs := ValidateURLs(m)
+	wants := []string{
+		`destination "typo": url "htpps://example.com/webhooks" has unsupported scheme "htpps"; expected https`,
+		`destination "no-scheme": url "example.com/webhooks" must include a scheme and host, e.g. https://example.com/webhooks`,
+		`destination "bad-host": invalid url: parse "https://exa mple.com": invalid character " " in host name`,
+	}
+	if len(errs) != len(wants) {
+		t.Fatalf("expected %d errors, got %d: %v", len(wants), len(errs), errs)
+	}
+	for i, want := range wants {
+		if errs[i].Error() != want {
+			t.Errorf("error %d: expected %q, got %q", i, want, errs[i])
+		}
+	}
+}
+
+func TestInsecureURLs(t *testing.T) {
+	m := &Manifest{Destinations: []DestinationConfig{
+		{Name: "prod", URL: "http://api.example.com/webhooks"},
+		{Name: "secure", URL: "https://api.example.com/webhooks"},
+		{Name: "localhost", URL: "http://localhost:3000/hook"},
+		{Name: "sub-localhost", URL: "http://app.localhost/hook"},
+		{Name: "loopback", URL: "http://127.0.0.1:3000/hook"},
+		{Name: "loopback-v6", URL: "http://[::1]:3000/hook"},
+		{Name: "mock", Type: "MOCK_API", URL: "http://api.example.com"},
+	}}
+
+	errs := InsecureURLs(m)
+	want := `destination "prod": url "http://api.example.com/webhooks" uses plain http; use https for endpoints that aren't local`
+	if len(errs) != 1 || errs[0].Error() != want {
+		t.Errorf("expected only %q, got %v", want, errs)
+	}
+}
+
+func TestValidateAuth(t *testing.T) {
+	m := &Manifest{Destinations: []DestinationConfig{
+		{Name: "api-key", AuthType: "API_KEY", AuthHeader: "X-API-Key", AuthValue: "k"},
+		{Name: "bearer", AuthType: "BEARER_TOKEN", AuthValue: "t"},
+		{Name: "basic", AuthType: "BASIC_AUTH", AuthUsername: "u", AuthPassword: "p"},
+		{Name: "raw", AuthType: "CUSTOM_SIGNATURE", Auth: map[string]interface{}{"key": "X-Sig"}},
+		{Name: "no-type", AuthValue: "t"},
+		{Name: "wrong-type", AuthType: "BEARER_TOKEN", AuthHeader: "X-API-Key", AuthValue: "t"},
+	}}
+
+	errs := ValidateAuth(m)
+	wants := []string{
+		`destination "no-type": auth_value requires auth_type`,
+		`destination "wrong-type": auth_header is not used by auth_type "BEARER_TOKEN"`,
+	}
+	if len(errs) != len(wants) {
+		t.Fatalf("expected %d errors, got %d: %v", len(wants), len(errs), errs)
+	}
+	for i, want := range wants {
+		if errs[i].Error() != want {
+			t.Errorf("error %d = %q, want %q", i, errs[i], want)
+		}
+	}
+}
+
+func TestValidateRules_ExponentialRetry(t *testing.T) {
+	m := &Manifest{Connections: []ConnectionConfig{
+		{Name: "ok", Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "exponential", "count": float64(10), "interval": float64(60000), "max_interval": float64(3600000)},
+		}},
+		{Name: "default-interval", Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "exponential", "count": float64(50)},
+		}},
+		{Name: "cap-below-interval", Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "exponential", "count": float64(3), "interval": float64(60000), "max_interval": float64(1000)},
+		}},
+		{Name: "bad-cap", Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "exponential", "count": float64(3), "max_interval": "1h"},
+		}},
+		{Name: "window", Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "exponential", "count": float64(50), "interval": float64(86400000)},
+		}},
+		// Linear rules aren't held to the exponential checks.
+		{Name: "linear", Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "linear", "count": float64(50), "interval": float64(86400000), "max_interval": float64(1)},
+		}},
+	}}
+
+	errs := ValidateRules(m)
+	wants := []string{
+		`connection "cap-below-interval" rules[0]: retry max_interval (1000) must not be less than interval (60000)`,
+		`connection "bad-cap" rules[0]: retry max_interval must be`,
+		`connection "window" rules[0]: retry count * interval (50 * 86400000 ms) exceeds`,
+	}
+	if len(errs) != len(wants) {
+		t.Fatalf("expected %d errors, got %d: %v", len(wants), len(errs), errs)
+	}
+	for i, want := range wants {
+		if !strings.HasPrefix(errs[i].Error(), want) {
+			t.Errorf("error %d: expected prefix %q, got %q", i, want, errs[i])
+		}
+	}
+}
+
+func TestValidateFilters_Valid(t *testing.T) {
+	m := &Manifest{Connections: []ConnectionConfig{{
+		Name: "c",
+		Filter: map[string]interface{}{
+			"$or": []interface{}{
+				map[string]interface{}{"headers.x-event-type": "order.created"},
+				map[string]interface{}{"$and": []interface{}{
+					map[string]interface{}{"amount": map[string]interface{}{"$gte": 100.0, "$lt": 1000.0}},
+					map[string]interface{}{"status": map[string]interface{}{"$in": []interface{}{"paid", "refunded"}}},
+					map[string]interface{}{"email": map[string]interface{}{"$not": map[string]interface{}{"$endsWith": "@example.com"}}},
+					map[string]interface{}{"body.status": map[string]interface{}{"$exist": true}},
+				}},
+			},
+		},
+		Rules: []map[string]interface{}{
+			{"type": "filter", "headers": map[string]interface{}{"x-source": map[string]interface{}{"$startsWith": "shop"}}},
+		},
+	}}}
+	if errs := ValidateFilters(m); len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestValidateFilters_Invalid(t *testing.T) {
+	m := &Manifest{Connections: []ConnectionConfig{{
+		Name: "c",
+		Filter: map[string]interface{}{
+			"$or": []interface{}{
+				map[string]interface{}{"status": map[string]interface{}{"$exsit": true}},
+				"paid",
+			},
+			"amount": map[string]interface{}{"$gte": []interface{}{1.0}},
+		},
+		Rules: []map[string]interface{}{
+			{"type": "retry", "strategy": "linear", "count": 1.0},
+			{"type": "filter", "body": map[string]interface{}{"$and": map[string]interface{}{"a": 1.0}, "b": map[string]interface{}{"$exist": "yes"}}},
+		},
+	}}}
+	errs := ValidateFilters(m)
+	want := []string{
+		`connection "c" filter.$or[0].status.$exsit: unknown filter operator "$exsit" (did you mean "$exist"?)`,
+		`connection "c" filter.$or[1]: $or takes an array of filters, got "paid"`,
+		`connection "c" filter.amount.$gte: $gte takes a number or string, got [1]`,
+		`connection "c" rules[1].body.$and: $and takes an array of filters, got map[a:1]`,
+		`connection "c" rules[1].body.b.$exist: $exist takes true or false, got "yes"`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %v", len(want), errs)
+	}
+	got := map[string]bool{}
+	for _, err := range errs {
+		got[err.Error()] = true
+	}
+	for _, w := range want {
+		if !got[w] {
+			t.Errorf("missing error %q in %v", w, errs)
+		}
+	}
+}
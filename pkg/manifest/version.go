@@ -0,0 +1,43 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ManifestVersion is the newest manifest format version this CLI understands.
+const ManifestVersion = "1"
+
+// supportedManifestVersions lists every manifest format version this CLI
+// loads, oldest first.
+var supportedManifestVersions = []string{"1"}
+
+// deprecatedManifestVersions maps supported versions that are on their way
+// out to upgrade guidance.
+var deprecatedManifestVersions = map[string]string{}
+
+// CheckVersion reports a problem with the format version m declares: one
+// newer than this CLI supports, one it doesn't know, or a deprecated one. It
+// returns nil when the version is current or m declares none.
+func CheckVersion(m *Manifest) error {
+	v := m.Version
+	if v == "" {
+		return nil
+	}
+	for _, supported := range supportedManifestVersions {
+		if v != supported {
+			continue
+		}
+		if guidance, ok := deprecatedManifestVersions[v]; ok {
+			return fmt.Errorf("manifest version %q is deprecated: %s", v, guidance)
+		}
+		return nil
+	}
+	if n, err := strconv.Atoi(v); err == nil {
+		if latest, _ := strconv.Atoi(ManifestVersion); n > latest {
+			return fmt.Errorf("manifest version %q is newer than this CLI supports (up to %q); upgrade hookdeck-deploy to use this manifest", v, ManifestVersion)
+		}
+	}
+	return fmt.Errorf("unsupported manifest version %q (supported: %s); set \"version\": %q or remove it", v, strings.Join(supportedManifestVersions, ", "), ManifestVersion)
+}
@@ -0,0 +1,38 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr string
+	}{
+		{"", ""},
+		{ManifestVersion, ""},
+		{"99", "newer than this CLI supports"},
+		{"0", "unsupported manifest version"},
+		{"v1", "unsupported manifest version"},
+	}
+	for _, tt := range tests {
+		err := CheckVersion(&Manifest{Version: tt.version})
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("version %q: unexpected error: %v", tt.version, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("version %q: expected error containing %q, got %v", tt.version, tt.wantErr, err)
+		}
+	}
+
+	deprecatedManifestVersions[ManifestVersion] = "move to version 2"
+	t.Cleanup(func() { delete(deprecatedManifestVersions, ManifestVersion) })
+	err := CheckVersion(&Manifest{Version: ManifestVersion})
+	if err == nil || !strings.Contains(err.Error(), "deprecated: move to version 2") {
+		t.Errorf("expected deprecation guidance, got %v", err)
+	}
+}
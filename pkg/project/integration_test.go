@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy/deploytest"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
 )
 
@@ -257,6 +258,60 @@ func TestIntegration_TransformationCodeFileResolution(t *testing.T) {
 	}
 }
 
+func TestIntegration_TransformationCodeFileEnvOverride(t *testing.T) {
+	// A per-env code_file override must survive registry resolution and be
+	// the file that is read, resolved relative to the declaring manifest.
+	dir := t.TempDir()
+
+	writeFile(t, dir, "hookdeck.project.jsonc", `{
+		"version": "2",
+		"env": {"staging": {}, "production": {}}
+	}`)
+	writeFile(t, dir, "transformations/enrich/hookdeck.jsonc", `{
+		"transformations": [{
+			"name": "enrich",
+			"code_file": "dist/prod.js",
+			"env_overrides": {"staging": {"code_file": "dist/staging.js"}}
+		}]
+	}`)
+	writeFile(t, dir, "transformations/enrich/dist/prod.js", `// production build`)
+	writeFile(t, dir, "transformations/enrich/dist/staging.js", `// staging build`)
+
+	proj, err := LoadProject(filepath.Join(dir, "hookdeck.project.jsonc"))
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+
+	tests := []struct {
+		env, file, code string
+	}{
+		{"staging", "staging.js", "// staging build"},
+		{"production", "prod.js", "// production build"},
+	}
+	for _, tt := range tests {
+		env, want := tt.env, tt.code
+		input := buildDeployInput(proj.Registry, env)
+		wantPath := filepath.Join(dir, "transformations", "enrich", "dist", tt.file)
+		if got := input.Transformations[0].CodeFile; got != wantPath {
+			t.Errorf("%s: CodeFile: expected %q, got %q", env, wantPath, got)
+		}
+
+		// The dry-run must accept the resolved input...
+		if _, err := deploy.Deploy(context.Background(), nil, input, deploy.Options{DryRun: true}); err != nil {
+			t.Fatalf("%s: Deploy dry-run failed: %v", env, err)
+		}
+		// ...and the deploy must read the env's file.
+		client := &deploytest.FakeClient{}
+		if _, err := deploy.Deploy(context.Background(), client, input, deploy.Options{}); err != nil {
+			t.Fatalf("%s: Deploy failed: %v", env, err)
+		}
+		reqs := client.TransformationRequests()
+		if len(reqs) != 1 || reqs[0].Code != want {
+			t.Errorf("%s: expected code %q, got %+v", env, want, reqs)
+		}
+	}
+}
+
 func TestIntegration_ConnectionEnvOverrides(t *testing.T) {
 	dir := t.TempDir()
 
@@ -336,3 +391,65 @@ func TestIntegration_ConnectionEnvOverrides(t *testing.T) {
 		}
 	}
 }
+
+func TestIntegration_CrossFileTransformationReference(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile(t, dir, "hookdeck.project.jsonc", `{"version": "2"}`)
+	// The connection's manifest is discovered before the transformation's.
+	writeFile(t, dir, "a-orders/hookdeck.jsonc", `{
+		"sources": [{"name": "orders-src"}],
+		"destinations": [{"name": "orders-dst", "url": "https://orders.example.com"}],
+		"connections": [{
+			"name": "orders",
+			"source": "orders-src",
+			"destination": "orders-dst",
+			"transformations": ["normalize"],
+			"rules": [{"type": "transform", "transformation": {"name": "enrich"}}]
+		}]
+	}`)
+	writeFile(t, dir, "z-shared/hookdeck.jsonc", `{
+		"transformations": [
+			{"name": "normalize", "code_file": "normalize.js"},
+			{"name": "enrich", "code_file": "enrich.js"}
+		]
+	}`)
+	writeFile(t, dir, "z-shared/normalize.js", `addHandler("transform", (req) => req);`)
+	writeFile(t, dir, "z-shared/enrich.js", `addHandler("transform", (req) => req);`)
+
+	proj, err := LoadProject(filepath.Join(dir, "hookdeck.project.jsonc"))
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+	input, err := SortDeployInput(buildDeployInput(proj.Registry, ""))
+	if err != nil {
+		t.Fatalf("SortDeployInput failed: %v", err)
+	}
+
+	// Deploying only the connection's manifest still upserts the referenced
+	// transformations first, so the full deploy and --only behave the same.
+	only, err := SelectByFile(input, proj.Registry, dir, "a-orders/**")
+	if err != nil {
+		t.Fatalf("SelectByFile failed: %v", err)
+	}
+	for name, in := range map[string]*deploy.DeployInput{"full": input, "only": only} {
+		client := &deploytest.FakeClient{}
+		if _, err := deploy.Deploy(context.Background(), client, in, deploy.Options{}); err != nil {
+			t.Fatalf("%s: Deploy failed: %v", name, err)
+		}
+		conns := client.ConnectionRequests()
+		if len(conns) != 1 {
+			t.Fatalf("%s: expected 1 connection request, got %d", name, len(conns))
+		}
+		ids := map[string]interface{}{}
+		for _, rule := range conns[0].Rules {
+			if rule["type"] == "transform" {
+				tr := rule["transformation"].(map[string]interface{})
+				ids[tr["name"].(string)] = rule["transformation_id"]
+			}
+		}
+		if ids["normalize"] != "trs_normalize" || ids["enrich"] != "trs_enrich" {
+			t.Errorf("%s: expected transformation_id injected for both cross-file transformations, got %v", name, ids)
+		}
+	}
+}
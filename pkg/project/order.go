@@ -0,0 +1,239 @@
+package project
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// Resource kinds used as graph node kinds, listed in their default tier order.
+const (
+	KindSource         = "source"
+	KindTransformation = "transformation"
+	KindDestination    = "destination"
+	KindConnection     = "connection"
+)
+
+// kindRank is the tie-breaking rank for each kind. When several resources are
+// ready to deploy, lower ranks go first, which reproduces the classic
+// source -> transformation -> destination -> connection ordering whenever
+// references don't demand otherwise.
+var kindRank = map[string]int{
+	KindSource:         0,
+	KindTransformation: 1,
+	KindDestination:    2,
+	KindConnection:     3,
+}
+
+// Node identifies a single resource in the deploy graph.
+type Node struct {
+	Kind  string
+	Name  string
+	index int // position within its kind's list in the DeployInput
+}
+
+func (n Node) String() string {
+	return fmt.Sprintf("%s %q", n.Kind, n.Name)
+}
+
+// DeployOrder computes a deterministic topological order of every resource in
+// input, such that each resource comes after the resources it references.
+// References to resources not present in input are ignored (they are resolved
+// by name at the API). An error naming the cycle is returned if the
+// references are circular.
+func DeployOrder(input *deploy.DeployInput) ([]Node, error) {
+	var nodes []Node
+	byName := map[string]map[string]int{
+		KindSource:         {},
+		KindTransformation: {},
+		KindDestination:    {},
+		KindConnection:     {},
+	}
+	add := func(kind, name string, index int) {
+		byName[kind][name] = len(nodes)
+		nodes = append(nodes, Node{Kind: kind, Name: name, index: index})
+	}
+	for i, src := range input.Sources {
+		add(KindSource, src.Name, i)
+	}
+	for i, tr := range input.Transformations {
+		add(KindTransformation, tr.Name, i)
+	}
+	for i, dst := range input.Destinations {
+		add(KindDestination, dst.Name, i)
+	}
+	for i, conn := range input.Connections {
+		add(KindConnection, conn.Name, i)
+	}
+
+	// deps[i] lists the node indices that node i depends on.
+	deps := make([][]int, len(nodes))
+	dependOn := func(from int, kind, name string) {
+		if to, ok := byName[kind][name]; ok && name != "" {
+			deps[from] = append(deps[from], to)
+		}
+	}
+	// Nodes were appended kind by kind, so each list's node indices are
+	// offset by the lists before it (names may be empty or repeated).
+	trBase := len(input.Sources)
+	dstBase := trBase + len(input.Transformations)
+	connBase := dstBase + len(input.Destinations)
+	for i, conn := range input.Connections {
+		from := connBase + i
+		dependOn(from, KindSource, conn.Source)
+		dependOn(from, KindDestination, conn.Destination)
+		for _, trName := range manifest.TransformationRefs(conn) {
+			dependOn(from, KindTransformation, trName)
+		}
+	}
+
+	// Explicit depends_on hints add edges on top of the references, and may
+	// name a resource of any kind.
+	explicit := func(from int, refs []string) {
+		for _, ref := range refs {
+			kind, name := DependencyTarget(nodes[from].Kind, ref)
+			dependOn(from, kind, name)
+		}
+	}
+	for i, src := range input.Sources {
+		explicit(i, src.DependsOn)
+	}
+	for i, tr := range input.Transformations {
+		explicit(trBase+i, tr.DependsOn)
+	}
+	for i, dst := range input.Destinations {
+		explicit(dstBase+i, dst.DependsOn)
+	}
+	for i, conn := range input.Connections {
+		explicit(connBase+i, conn.DependsOn)
+	}
+
+	return topoSort(nodes, deps)
+}
+
+// DependencyTarget splits a depends_on entry of a resource of the given kind
+// into the kind and name it refers to: "kind:name" when it starts with a
+// known kind, otherwise a resource of the same kind.
+func DependencyTarget(kind, ref string) (string, string) {
+	if prefix, name, ok := strings.Cut(ref, ":"); ok {
+		if _, known := kindRank[prefix]; known {
+			return prefix, name
+		}
+	}
+	return kind, ref
+}
+
+// topoSort runs Kahn's algorithm, always picking the ready node with the
+// lowest (kind rank, list index) so the result is stable across runs.
+func topoSort(nodes []Node, deps [][]int) ([]Node, error) {
+	indegree := make([]int, len(nodes))
+	dependents := make([][]int, len(nodes))
+	for from, tos := range deps {
+		for _, to := range tos {
+			indegree[from]++
+			dependents[to] = append(dependents[to], from)
+		}
+	}
+
+	less := func(a, b int) bool {
+		if kindRank[nodes[a].Kind] != kindRank[nodes[b].Kind] {
+			return kindRank[nodes[a].Kind] < kindRank[nodes[b].Kind]
+		}
+		return nodes[a].index < nodes[b].index
+	}
+
+	var ready []int
+	for i := range nodes {
+		if indegree[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	order := make([]Node, 0, len(nodes))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return less(ready[i], ready[j]) })
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, nodes[next])
+		for _, d := range dependents[next] {
+			indegree[d]--
+			if indegree[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	if len(order) < len(nodes) {
+		return nil, fmt.Errorf("dependency cycle detected: %s", describeCycle(nodes, deps, indegree))
+	}
+	return order, nil
+}
+
+// describeCycle walks dependencies among the nodes left unsorted (non-zero
+// indegree) until a node repeats, and renders that loop.
+func describeCycle(nodes []Node, deps [][]int, indegree []int) string {
+	start := -1
+	for i := range nodes {
+		if indegree[i] > 0 {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return "unknown"
+	}
+
+	seen := make(map[int]int)
+	var path []int
+	cur := start
+	for {
+		if pos, ok := seen[cur]; ok {
+			path = append(path[pos:], cur)
+			break
+		}
+		seen[cur] = len(path)
+		path = append(path, cur)
+		for _, to := range deps[cur] {
+			if indegree[to] > 0 {
+				cur = to
+				break
+			}
+		}
+	}
+
+	parts := make([]string, len(path))
+	for i, n := range path {
+		parts[i] = nodes[n].String()
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// SortDeployInput returns a copy of input whose resource lists are ordered
+// according to DeployOrder, which is also recorded in its Order so that
+// Deploy honours depends_on entries naming a later kind. Disabled and
+// external resources are carried over unchanged.
+func SortDeployInput(input *deploy.DeployInput) (*deploy.DeployInput, error) {
+	order, err := DeployOrder(input)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := &deploy.DeployInput{Disabled: input.Disabled, External: input.External}
+	for _, n := range order {
+		sorted.Order = append(sorted.Order, deploy.ResourceRef{Kind: n.Kind, Name: n.Name})
+		switch n.Kind {
+		case KindSource:
+			sorted.Sources = append(sorted.Sources, input.Sources[n.index])
+		case KindTransformation:
+			sorted.Transformations = append(sorted.Transformations, input.Transformations[n.index])
+		case KindDestination:
+			sorted.Destinations = append(sorted.Destinations, input.Destinations[n.index])
+		case KindConnection:
+			sorted.Connections = append(sorted.Connections, input.Connections[n.index])
+		}
+	}
+	return sorted, nil
+}
@@ -0,0 +1,195 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func TestDeployOrder_DefaultTierOrdering(t *testing.T) {
+	input := &deploy.DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "src-a"}, {Name: "src-b"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "tr"}},
+		Destinations:    []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections: []*manifest.ConnectionConfig{
+			{Name: "conn-b", Source: "src-b", Destination: "dst"},
+			{Name: "conn-a", Source: "src-a", Destination: "dst", Transformations: []string{"tr"}},
+		},
+	}
+
+	order, err := DeployOrder(input)
+	if err != nil {
+		t.Fatalf("DeployOrder failed: %v", err)
+	}
+
+	var got []string
+	for _, n := range order {
+		got = append(got, n.Kind+":"+n.Name)
+	}
+	want := []string{
+		"source:src-a",
+		"source:src-b",
+		"transformation:tr",
+		"destination:dst",
+		"connection:conn-b",
+		"connection:conn-a",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected order:\n got  %v\n want %v", got, want)
+	}
+}
+
+func TestDeployOrder_IgnoresExternalReferences(t *testing.T) {
+	input := &deploy.DeployInput{
+		Connections: []*manifest.ConnectionConfig{
+			{Name: "conn", Source: "defined-elsewhere", Destination: "also-elsewhere"},
+		},
+	}
+
+	order, err := DeployOrder(input)
+	if err != nil {
+		t.Fatalf("DeployOrder failed: %v", err)
+	}
+	if len(order) != 1 || order[0].Name != "conn" {
+		t.Errorf("unexpected order: %v", order)
+	}
+}
+
+func TestDeployOrder_Deterministic(t *testing.T) {
+	input := &deploy.DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "s1"}, {Name: "s2"}, {Name: "s3"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "d1"}, {Name: "d2"}},
+		Connections: []*manifest.ConnectionConfig{
+			{Name: "c3", Source: "s3", Destination: "d2"},
+			{Name: "c1", Source: "s1", Destination: "d1"},
+			{Name: "c2", Source: "s2", Destination: "d1"},
+		},
+	}
+
+	first, err := DeployOrder(input)
+	if err != nil {
+		t.Fatalf("DeployOrder failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		again, err := DeployOrder(input)
+		if err != nil {
+			t.Fatalf("DeployOrder failed: %v", err)
+		}
+		for j := range first {
+			if first[j] != again[j] {
+				t.Fatalf("order differs between runs at %d: %v vs %v", j, first[j], again[j])
+			}
+		}
+	}
+}
+
+func TestTopoSort_CycleNamed(t *testing.T) {
+	nodes := []Node{
+		{Kind: KindDestination, Name: "a", index: 0},
+		{Kind: KindDestination, Name: "b", index: 1},
+		{Kind: KindDestination, Name: "c", index: 2},
+	}
+	// a -> b -> a, c is independent
+	deps := [][]int{{1}, {0}, nil}
+
+	_, err := topoSort(nodes, deps)
+	if err == nil {
+		t.Fatal("expected cycle error, got nil")
+	}
+	want := `dependency cycle detected: destination "a" -> destination "b" -> destination "a"`
+	if err.Error() != want {
+		t.Errorf("unexpected error:\n got  %s\n want %s", err.Error(), want)
+	}
+}
+
+func TestSortDeployInput_PreservesAllResources(t *testing.T) {
+	input := &deploy.DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections: []*manifest.ConnectionConfig{
+			{Source: "src", Destination: "dst"},
+			{Name: "named", Source: "src", Destination: "dst"},
+		},
+		Disabled: []deploy.DisabledResource{{Kind: KindSource, Name: "off"}},
+	}
+
+	sorted, err := SortDeployInput(input)
+	if err != nil {
+		t.Fatalf("SortDeployInput failed: %v", err)
+	}
+	if len(sorted.Sources) != 1 || len(sorted.Destinations) != 1 || len(sorted.Connections) != 2 {
+		t.Fatalf("unexpected sorted input: %+v", sorted)
+	}
+	if sorted.Connections[0] != input.Connections[0] || sorted.Connections[1] != input.Connections[1] {
+		t.Error("expected connection order to be preserved")
+	}
+	if len(sorted.Disabled) != 1 || sorted.Disabled[0].Name != "off" {
+		t.Errorf("expected disabled resources to be carried over, got %v", sorted.Disabled)
+	}
+}
+
+func TestDeployOrder_DependsOnSameKind(t *testing.T) {
+	input := &deploy.DeployInput{
+		Destinations: []*manifest.DestinationConfig{
+			{Name: "api", DependsOn: []string{"gateway"}},
+			{Name: "gateway"},
+		},
+	}
+
+	sorted, err := SortDeployInput(input)
+	if err != nil {
+		t.Fatalf("SortDeployInput failed: %v", err)
+	}
+	if sorted.Destinations[0].Name != "gateway" || sorted.Destinations[1].Name != "api" {
+		t.Errorf("expected gateway before api, got %s, %s", sorted.Destinations[0].Name, sorted.Destinations[1].Name)
+	}
+}
+
+func TestDeployOrder_DependsOnOtherKind(t *testing.T) {
+	input := &deploy.DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst", DependsOn: []string{"source:src"}}},
+	}
+	if _, err := DeployOrder(input); err != nil {
+		t.Fatalf("DeployOrder failed: %v", err)
+	}
+
+	// A source may depend on a destination: the sorted input's Order puts
+	// the destination first for Deploy to follow.
+	input = &deploy.DeployInput{
+		Sources:      []*manifest.SourceConfig{{Name: "src", DependsOn: []string{"destination:dst"}}, {Name: "plain"}},
+		Destinations: []*manifest.DestinationConfig{{Name: "dst"}},
+	}
+	sorted, err := SortDeployInput(input)
+	if err != nil {
+		t.Fatalf("SortDeployInput failed: %v", err)
+	}
+	want := []deploy.ResourceRef{
+		{Kind: KindSource, Name: "plain"},
+		{Kind: KindDestination, Name: "dst"},
+		{Kind: KindSource, Name: "src"},
+	}
+	if len(sorted.Order) != len(want) {
+		t.Fatalf("expected order %v, got %v", want, sorted.Order)
+	}
+	for i := range want {
+		if sorted.Order[i] != want[i] {
+			t.Errorf("order[%d]: expected %v, got %v", i, want[i], sorted.Order[i])
+		}
+	}
+}
+
+func TestDeployOrder_DependsOnCycle(t *testing.T) {
+	input := &deploy.DeployInput{
+		Destinations: []*manifest.DestinationConfig{
+			{Name: "a", DependsOn: []string{"b"}},
+			{Name: "b", DependsOn: []string{"destination:a"}},
+		},
+	}
+	_, err := DeployOrder(input)
+	if err == nil || !strings.Contains(err.Error(), "dependency cycle detected") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+}
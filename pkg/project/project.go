@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/tailscale/hujson"
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
@@ -15,6 +17,54 @@ import (
 type ProjectConfig struct {
 	Version string                `json:"version"`
 	Env     map[string]*EnvConfig `json:"env,omitempty"`
+	// APIBaseURL overrides the Hookdeck API base URL (e.g. for another region).
+	APIBaseURL string `json:"api_base_url,omitempty"`
+	// ProtectedEnvironments lists environments that deploy only targets
+	// after explicit confirmation.
+	ProtectedEnvironments []string `json:"protected_environments,omitempty"`
+	// Client tunes the Hookdeck API client for every command run against the
+	// project. Command-line flags take precedence.
+	Client *ClientConfig `json:"client,omitempty"`
+}
+
+// ClientConfig holds the API client settings a project can declare. Zero
+// values keep the built-in defaults.
+type ClientConfig struct {
+	// RetryAttempts is how often a request failing with 429, a 5xx status,
+	// or a network error is retried.
+	RetryAttempts int `json:"retry_attempts,omitempty"`
+	// RetryBaseDelayMS is the backoff before the first retry, doubling after
+	// each one.
+	RetryBaseDelayMS int `json:"retry_base_delay_ms,omitempty"`
+	// RateLimitRPS caps the requests sent per second.
+	RateLimitRPS float64 `json:"rate_limit_rps,omitempty"`
+	// TimeoutMS bounds each request.
+	TimeoutMS int `json:"timeout_ms,omitempty"`
+}
+
+// validate rejects negative settings.
+func (c *ClientConfig) validate() error {
+	switch {
+	case c.RetryAttempts < 0:
+		return fmt.Errorf("client.retry_attempts must not be negative, got %d", c.RetryAttempts)
+	case c.RetryBaseDelayMS < 0:
+		return fmt.Errorf("client.retry_base_delay_ms must not be negative, got %d", c.RetryBaseDelayMS)
+	case c.RateLimitRPS < 0:
+		return fmt.Errorf("client.rate_limit_rps must not be negative, got %g", c.RateLimitRPS)
+	case c.TimeoutMS < 0:
+		return fmt.Errorf("client.timeout_ms must not be negative, got %d", c.TimeoutMS)
+	}
+	return nil
+}
+
+// IsProtected reports whether envName is one of the protected environments.
+func (c *ProjectConfig) IsProtected(envName string) bool {
+	for _, name := range c.ProtectedEnvironments {
+		if name == envName {
+			return true
+		}
+	}
+	return false
 }
 
 // EnvConfig holds per-environment settings within a project config.
@@ -27,6 +77,10 @@ type Project struct {
 	Config   *ProjectConfig
 	Registry *Registry
 	RootDir  string
+
+	// Warnings are problems that don't stop the project from loading, such
+	// as a manifest declaring a deprecated format version.
+	Warnings []string
 }
 
 // LoadProjectConfig reads and parses a hookdeck.project.jsonc file.
@@ -46,11 +100,32 @@ func LoadProjectConfig(path string) (*ProjectConfig, error) {
 		return nil, fmt.Errorf("unmarshaling project config: %w", err)
 	}
 
+	// A manifest parses as an empty project config, which would silently
+	// deploy whatever manifests happen to sit next to it.
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(standardized, &keys); err == nil {
+		for _, key := range manifestKeys {
+			if _, ok := keys[key]; ok {
+				return nil, fmt.Errorf("%s is a manifest (it declares %q), not a project config", path, key)
+			}
+		}
+	}
+
+	if cfg.Client != nil {
+		if err := cfg.Client.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
 	return &cfg, nil
 }
 
+// manifestKeys are the top-level keys of a manifest that a project config
+// never has.
+var manifestKeys = []string{"sources", "destinations", "transformations", "connections"}
+
 // DiscoverManifests recursively walks a directory tree and returns the paths of
-// all files named hookdeck.jsonc or hookdeck.json.
+// all files named hookdeck.jsonc or hookdeck.json, in lexical walk order.
 func DiscoverManifests(root string) ([]string, error) {
 	var paths []string
 	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
@@ -72,10 +147,44 @@ func DiscoverManifests(root string) ([]string, error) {
 	return paths, nil
 }
 
+// ValidationErrors is the error LoadProject returns when the registry fails
+// validation. It lists one "file:line: message" per line, and unwraps to the
+// individual *ValidationError values for callers that want them.
+type ValidationErrors []error
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "validation errors:\n  " + strings.Join(msgs, "\n  ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	return e
+}
+
+// LoadOptions controls how LoadProjectWithOptions reads manifest files.
+type LoadOptions struct {
+	// Parallelism is the number of manifest files parsed concurrently. 1
+	// loads them serially; values below 1 use one worker per CPU.
+	Parallelism int
+
+	// StrictVersion fails the load on a manifest version problem (see
+	// manifest.CheckVersion) instead of adding it to Project.Warnings.
+	StrictVersion bool
+}
+
 // LoadProject loads the project config from projectPath, discovers all manifests
 // in the same directory tree, loads each manifest, registers resources, validates
 // references, and returns the fully loaded Project or an error.
 func LoadProject(projectPath string) (*Project, error) {
+	return LoadProjectWithOptions(projectPath, LoadOptions{Parallelism: 1})
+}
+
+// LoadProjectWithOptions is LoadProject with control over how manifests are
+// read. The result is the same for any Parallelism.
+func LoadProjectWithOptions(projectPath string, opts LoadOptions) (*Project, error) {
 	cfg, err := LoadProjectConfig(projectPath)
 	if err != nil {
 		return nil, err
@@ -88,16 +197,25 @@ func LoadProject(projectPath string) (*Project, error) {
 		return nil, err
 	}
 
-	registry := NewRegistry()
+	manifests, errs := loadManifests(manifestPaths, opts.Parallelism)
 
-	var loadErrors []string
-	for _, mp := range manifestPaths {
-		m, err := manifest.LoadFile(mp)
-		if err != nil {
-			loadErrors = append(loadErrors, fmt.Sprintf("%s: %v", mp, err))
+	// Registration stays sequential and in discovery order, so collision
+	// errors don't depend on which worker finished first.
+	registry := NewRegistry()
+	var loadErrors, warnings []string
+	for i, mp := range manifestPaths {
+		if errs[i] != nil {
+			loadErrors = append(loadErrors, fmt.Sprintf("%s: %v", mp, errs[i]))
 			continue
 		}
-		registry.AddManifest(mp, m)
+		if err := manifest.CheckVersion(manifests[i]); err != nil {
+			if opts.StrictVersion {
+				loadErrors = append(loadErrors, fmt.Sprintf("%s: %v", mp, err))
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("%s: %v", mp, err))
+		}
+		registry.AddManifest(mp, manifests[i])
 	}
 
 	if len(loadErrors) > 0 {
@@ -105,16 +223,46 @@ func LoadProject(projectPath string) (*Project, error) {
 	}
 
 	if errs := registry.Validate(); len(errs) > 0 {
-		msgs := make([]string, len(errs))
-		for i, e := range errs {
-			msgs[i] = e.Error()
-		}
-		return nil, fmt.Errorf("validation errors:\n  %s", strings.Join(msgs, "\n  "))
+		return nil, ValidationErrors(errs)
 	}
 
 	return &Project{
 		Config:   cfg,
 		Registry: registry,
 		RootDir:  rootDir,
+		Warnings: warnings,
 	}, nil
 }
+
+// loadManifests parses each of paths with up to parallelism workers. Results
+// and errors are returned at the index of their path.
+func loadManifests(paths []string, parallelism int) ([]*manifest.Manifest, []error) {
+	manifests := make([]*manifest.Manifest, len(paths))
+	errs := make([]error, len(paths))
+
+	workers := parallelism
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				manifests[i], errs[i] = manifest.LoadFile(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return manifests, errs
+}
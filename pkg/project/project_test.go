@@ -1,6 +1,8 @@
 package project
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,7 +13,7 @@ import (
 
 // writeFile is a helper that creates a file with the given content inside dir,
 // creating any intermediate directories as needed.
-func writeFile(t *testing.T, dir, name, content string) string {
+func writeFile(t testing.TB, dir, name, content string) string {
 	t.Helper()
 	path := filepath.Join(dir, name)
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
@@ -56,6 +58,69 @@ func TestLoadProjectConfig_Basic(t *testing.T) {
 	}
 }
 
+func TestLoadProjectConfig_APIBaseURL(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "hookdeck.project.jsonc", `{
+		"version": "2",
+		"api_base_url": "https://api.eu.example.com/2025-07-01"
+	}`)
+
+	cfg, err := LoadProjectConfig(filepath.Join(dir, "hookdeck.project.jsonc"))
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	if cfg.APIBaseURL != "https://api.eu.example.com/2025-07-01" {
+		t.Errorf("expected api_base_url to be loaded, got %q", cfg.APIBaseURL)
+	}
+}
+
+func TestLoadProjectConfig_Client(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "hookdeck.project.jsonc", `{
+		"version": "2",
+		"client": {
+			"retry_attempts": 3,
+			"retry_base_delay_ms": 200,
+			"rate_limit_rps": 5.5,
+			"timeout_ms": 10000
+		}
+	}`)
+
+	cfg, err := LoadProjectConfig(filepath.Join(dir, "hookdeck.project.jsonc"))
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	want := ClientConfig{RetryAttempts: 3, RetryBaseDelayMS: 200, RateLimitRPS: 5.5, TimeoutMS: 10000}
+	if cfg.Client == nil || *cfg.Client != want {
+		t.Errorf("expected client %+v, got %+v", want, cfg.Client)
+	}
+
+	writeFile(t, dir, "hookdeck.project.jsonc", `{"version": "2", "client": {"retry_attempts": -1}}`)
+	_, err = LoadProjectConfig(filepath.Join(dir, "hookdeck.project.jsonc"))
+	if err == nil || !strings.Contains(err.Error(), "client.retry_attempts") {
+		t.Errorf("expected an error naming client.retry_attempts, got %v", err)
+	}
+}
+
+func TestLoadProjectConfig_ProtectedEnvironments(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "hookdeck.project.jsonc", `{
+		"version": "2",
+		"protected_environments": ["production"]
+	}`)
+
+	cfg, err := LoadProjectConfig(filepath.Join(dir, "hookdeck.project.jsonc"))
+	if err != nil {
+		t.Fatalf("LoadProjectConfig failed: %v", err)
+	}
+	if !cfg.IsProtected("production") {
+		t.Error("expected production to be protected")
+	}
+	if cfg.IsProtected("staging") || cfg.IsProtected("") {
+		t.Error("expected only listed environments to be protected")
+	}
+}
+
 func TestLoadProjectConfig_FileNotFound(t *testing.T) {
 	_, err := LoadProjectConfig("/nonexistent/hookdeck.project.jsonc")
 	if err == nil {
@@ -79,6 +144,16 @@ func TestLoadProjectConfig_Minimal(t *testing.T) {
 	}
 }
 
+func TestLoadProjectConfig_RejectsManifest(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "hookdeck.jsonc", `{"sources": [{"name": "src-a"}]}`)
+
+	_, err := LoadProjectConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "is a manifest") {
+		t.Fatalf("expected a manifest to be rejected as a project config, got %v", err)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // DiscoverManifests tests
 // ---------------------------------------------------------------------------
@@ -188,6 +263,75 @@ func TestRegistry_ValidRefs(t *testing.T) {
 	}
 }
 
+func TestRegistry_LiteralIDsSkipRefChecks(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("file1.jsonc", &manifest.Manifest{
+		Connections: []manifest.ConnectionConfig{{
+			Name:          "conn-ext",
+			SourceID:      "src_other_project",
+			DestinationID: "des_other_project",
+		}},
+	})
+
+	if errs := r.Validate(); len(errs) != 0 {
+		t.Errorf("expected no errors for literal IDs, got %v", errs)
+	}
+}
+
+func TestRegistry_ExternalSourceRef(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("file1.jsonc", &manifest.Manifest{
+		Sources:      []manifest.SourceConfig{{Name: "shared-ingress", External: true}},
+		Destinations: []manifest.DestinationConfig{{Name: "dst-a", URL: "https://example.com"}},
+		Connections: []manifest.ConnectionConfig{{
+			Name:        "conn-a",
+			Source:      "shared-ingress",
+			Destination: "dst-a",
+		}},
+	})
+
+	if errs := r.Validate(); len(errs) != 0 {
+		t.Errorf("expected no errors for an external source, got %v", errs)
+	}
+}
+
+func TestRegistry_ExternalSharedAcrossManifests(t *testing.T) {
+	r := NewRegistry()
+	for _, file := range []string{"a/hookdeck.jsonc", "b/hookdeck.jsonc"} {
+		r.AddManifest(file, &manifest.Manifest{
+			Sources:      []manifest.SourceConfig{{Name: "shared", External: true}},
+			Destinations: []manifest.DestinationConfig{{Name: "dst-" + file[:1], URL: "https://example.com"}},
+			Connections: []manifest.ConnectionConfig{{
+				Name:        "conn-" + file[:1],
+				Source:      "shared",
+				Destination: "dst-" + file[:1],
+			}},
+		})
+	}
+
+	if errs := r.Validate(); len(errs) != 0 {
+		t.Errorf("expected no errors for a placeholder declared twice, got %v", errs)
+	}
+	if len(r.SourceList) != 1 {
+		t.Errorf("expected the placeholder listed once, got %d sources", len(r.SourceList))
+	}
+}
+
+func TestRegistry_ExternalCollidesWithDefinition(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("a/hookdeck.jsonc", &manifest.Manifest{
+		Sources: []manifest.SourceConfig{{Name: "shared", External: true}},
+	})
+	r.AddManifest("b/hookdeck.jsonc", &manifest.Manifest{
+		Sources: []manifest.SourceConfig{{Name: "shared", Type: "WEBHOOK"}},
+	})
+
+	errs := r.Validate()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `source "shared" is both defined and declared external`) {
+		t.Errorf("expected an external/defined collision, got %v", errs)
+	}
+}
+
 func TestRegistry_NamingCollision(t *testing.T) {
 	r := NewRegistry()
 	r.AddManifest("file1.jsonc", &manifest.Manifest{
@@ -209,6 +353,53 @@ func TestRegistry_NamingCollision(t *testing.T) {
 	}
 }
 
+func TestRegistry_DerivedConnectionNameCollision(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("file1.jsonc", &manifest.Manifest{
+		Connections: []manifest.ConnectionConfig{{Source: "src-a", Destination: "dst-a"}},
+	})
+	r.AddManifest("file2.jsonc", &manifest.Manifest{
+		Connections: []manifest.ConnectionConfig{
+			{Source: "src-a", Destination: "dst-b"},
+			{Source: "src-a", Destination: "dst-a"},
+		},
+	})
+
+	var collisions []string
+	for _, err := range r.Validate() {
+		if strings.Contains(err.Error(), "duplicate connection") {
+			collisions = append(collisions, err.Error())
+		}
+	}
+	if len(collisions) != 1 {
+		t.Fatalf("expected 1 connection collision, got %v", collisions)
+	}
+	if !strings.Contains(collisions[0], `"src-a-to-dst-a"`) || !strings.Contains(collisions[0], "derived") {
+		t.Errorf("expected collision on the derived name, got %q", collisions[0])
+	}
+}
+
+func TestRegistry_Vars(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("file1.jsonc", &manifest.Manifest{Vars: map[string]string{"REGION": "eu", "STAGE": "dev"}})
+	r.AddManifest("file2.jsonc", &manifest.Manifest{Vars: map[string]string{"REGION": "eu", "TEAM": "orders"}})
+	if errs := r.Validate(); len(errs) != 0 {
+		t.Fatalf("expected matching vars to merge, got %v", errs)
+	}
+	if r.Vars["REGION"] != "eu" || r.Vars["STAGE"] != "dev" || r.Vars["TEAM"] != "orders" {
+		t.Errorf("unexpected merged vars: %v", r.Vars)
+	}
+
+	r.AddManifest("file3.jsonc", &manifest.Manifest{Vars: map[string]string{"REGION": "us"}})
+	errs := r.Validate()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `conflicting var "REGION"`) {
+		t.Fatalf("expected a conflicting var error, got %v", errs)
+	}
+	if !strings.Contains(errs[0].Error(), "file1.jsonc") || !strings.Contains(errs[0].Error(), "file3.jsonc") {
+		t.Errorf("expected error to name both files, got %q", errs[0].Error())
+	}
+}
+
 func TestRegistry_CrossTypeAllowed(t *testing.T) {
 	// Same name across different resource types is allowed.
 	r := NewRegistry()
@@ -321,6 +512,46 @@ func TestRegistry_MultiFileRefs(t *testing.T) {
 	}
 }
 
+func TestRegistry_FileFor(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("services/a/hookdeck.jsonc", &manifest.Manifest{
+		Sources: []manifest.SourceConfig{{Name: "src-a"}},
+	})
+	r.AddManifest("services/b/hookdeck.jsonc", &manifest.Manifest{
+		Connections: []manifest.ConnectionConfig{{Name: "conn-b", Source: "src-a", Destination: "dst"}},
+	})
+
+	if got := r.FileFor(KindSource, "src-a"); got != "services/a/hookdeck.jsonc" {
+		t.Errorf("source file = %q, want services/a/hookdeck.jsonc", got)
+	}
+	if got := r.FileFor(KindConnection, "conn-b"); got != "services/b/hookdeck.jsonc" {
+		t.Errorf("connection file = %q, want services/b/hookdeck.jsonc", got)
+	}
+	if got := r.FileFor(KindDestination, "dst"); got != "" {
+		t.Errorf("unregistered destination file = %q, want empty", got)
+	}
+}
+
+func TestRegistry_TemplatedConnectionNames(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("a/hookdeck.jsonc", &manifest.Manifest{
+		Sources:      []manifest.SourceConfig{{Name: "stripe"}},
+		Destinations: []manifest.DestinationConfig{{Name: "api"}},
+		Connections:  []manifest.ConnectionConfig{{Name: "{{.Source}}-to-{{.Destination}}", Source: "stripe", Destination: "api"}},
+	})
+	r.AddManifest("b/hookdeck.jsonc", &manifest.Manifest{
+		Sources:     []manifest.SourceConfig{{Name: "shopify"}},
+		Connections: []manifest.ConnectionConfig{{Name: "{{.Source}}-to-{{.Destination}}", Source: "shopify", Destination: "api"}},
+	})
+
+	if errs := r.Validate(); len(errs) != 0 {
+		t.Fatalf("expected no collisions for distinct rendered names, got %v", errs)
+	}
+	if got := r.FileFor(KindConnection, "shopify-to-api"); got != "b/hookdeck.jsonc" {
+		t.Errorf("expected shopify-to-api from b/hookdeck.jsonc, got %q", got)
+	}
+}
+
 func TestRegistry_CollisionAllTypes(t *testing.T) {
 	r := NewRegistry()
 	r.AddManifest("a.jsonc", &manifest.Manifest{
@@ -428,6 +659,25 @@ func TestLoadProject_ValidationErrors(t *testing.T) {
 	}
 }
 
+func TestLoadProject_ManifestVersion(t *testing.T) {
+	dir := t.TempDir()
+	projectPath := writeFile(t, dir, "hookdeck.project.jsonc", `{"version": "2"}`)
+	writeFile(t, dir, "hookdeck.jsonc", `{"version": "99", "sources": [{"name": "src-a"}]}`)
+
+	proj, err := LoadProject(projectPath)
+	if err != nil {
+		t.Fatalf("LoadProject failed: %v", err)
+	}
+	if len(proj.Warnings) != 1 || !strings.Contains(proj.Warnings[0], "newer than this CLI supports") {
+		t.Errorf("expected a version warning, got %v", proj.Warnings)
+	}
+
+	_, err = LoadProjectWithOptions(projectPath, LoadOptions{Parallelism: 1, StrictVersion: true})
+	if err == nil || !strings.Contains(err.Error(), "newer than this CLI supports") {
+		t.Errorf("expected a version error under StrictVersion, got %v", err)
+	}
+}
+
 func TestLoadProject_NoManifests(t *testing.T) {
 	dir := t.TempDir()
 	writeFile(t, dir, "hookdeck.project.jsonc", `{"version": "1.0"}`)
@@ -466,3 +716,128 @@ func TestLoadProject_CollisionAcrossManifests(t *testing.T) {
 		t.Errorf("expected 'duplicate source' error, got %q", err.Error())
 	}
 }
+
+func TestLoadProject_ValidationErrorLocations(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "hookdeck.project.jsonc", `{"version": "1.0"}`)
+	writeFile(t, dir, "a/hookdeck.jsonc", `{
+		"sources": [{"name": "shared-src"}]
+	}`)
+	writeFile(t, dir, "b/hookdeck.jsonc", `{
+		// comments count towards line numbers
+		"sources": [
+			{"name": "other-src"},
+			{"name": "shared-src"}
+		],
+		"connections": [
+			{"name": "conn", "source": "missing-src", "destination": "missing-dst"}
+		]
+	}`)
+
+	_, err := LoadProject(filepath.Join(dir, "hookdeck.project.jsonc"))
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	a, b := filepath.Join(dir, "a/hookdeck.jsonc"), filepath.Join(dir, "b/hookdeck.jsonc")
+	for _, want := range []string{
+		b + `:5: duplicate source "shared-src": also defined at ` + a + `:2`,
+		b + `:8: connection "conn" references undefined source "missing-src"`,
+		b + `:8: connection "conn" references undefined destination "missing-dst"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%s", want, err)
+		}
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.File != b || verr.Line != 5 {
+		t.Errorf("expected a *ValidationError at %s:5, got %#v", b, verr)
+	}
+}
+
+func TestRegistry_UndefinedDependsOn(t *testing.T) {
+	r := NewRegistry()
+	r.AddManifest("file1.jsonc", &manifest.Manifest{
+		Sources: []manifest.SourceConfig{{Name: "src"}},
+		Destinations: []manifest.DestinationConfig{
+			{Name: "dst", DependsOn: []string{"source:src", "gateway"}},
+		},
+	})
+
+	errs := r.Validate()
+	if len(errs) != 1 || errs[0].Error() != `file1.jsonc: destination "dst" depends on undefined destination "gateway"` {
+		t.Fatalf("expected one undefined depends_on error, got %v", errs)
+	}
+}
+
+// writeSyntheticProject creates a project with n manifests, each defining a
+// source, destination, and connection.
+func writeSyntheticProject(tb testing.TB, n int) string {
+	tb.Helper()
+	dir := tb.TempDir()
+	writeFile(tb, dir, "hookdeck.project.jsonc", `{"version": "2025-01-01"}`)
+	for i := 0; i < n; i++ {
+		writeFile(tb, dir, fmt.Sprintf("services/svc-%03d/hookdeck.jsonc", i), fmt.Sprintf(`{
+			// service %[1]d
+			"sources": [{"name": "src-%[1]d"}],
+			"destinations": [{"name": "dst-%[1]d", "url": "https://example.com/%[1]d"}],
+			"connections": [{"name": "conn-%[1]d", "source": "src-%[1]d", "destination": "dst-%[1]d"}]
+		}`, i))
+	}
+	return filepath.Join(dir, "hookdeck.project.jsonc")
+}
+
+func TestLoadProjectWithOptions_ParallelMatchesSerial(t *testing.T) {
+	path := writeSyntheticProject(t, 50)
+
+	serial, err := LoadProject(path)
+	if err != nil {
+		t.Fatalf("serial load failed: %v", err)
+	}
+	parallel, err := LoadProjectWithOptions(path, LoadOptions{Parallelism: 8})
+	if err != nil {
+		t.Fatalf("parallel load failed: %v", err)
+	}
+	if len(parallel.Registry.ConnectionList) != 50 {
+		t.Fatalf("expected 50 connections, got %d", len(parallel.Registry.ConnectionList))
+	}
+	for i := range serial.Registry.ConnectionList {
+		if got, want := parallel.Registry.ConnectionList[i].Name, serial.Registry.ConnectionList[i].Name; got != want {
+			t.Fatalf("connection %d: expected %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestLoadProjectWithOptions_ParallelCollisionsAreDeterministic(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "hookdeck.project.jsonc", `{"version": "2025-01-01"}`)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		writeFile(t, dir, name+"/hookdeck.jsonc", `{"sources": [{"name": "shared"}]}`)
+	}
+	path := filepath.Join(dir, "hookdeck.project.jsonc")
+
+	_, want := LoadProject(path)
+	if want == nil {
+		t.Fatal("expected collision error")
+	}
+	for i := 0; i < 10; i++ {
+		_, err := LoadProjectWithOptions(path, LoadOptions{Parallelism: 4})
+		if err == nil || err.Error() != want.Error() {
+			t.Fatalf("expected %q, got %v", want, err)
+		}
+	}
+}
+
+func BenchmarkLoadProject(b *testing.B) {
+	path := writeSyntheticProject(b, 300)
+	for _, parallelism := range []int{1, 8} {
+		name := fmt.Sprintf("workers=%d", parallelism)
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := LoadProjectWithOptions(path, LoadOptions{Parallelism: parallelism}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
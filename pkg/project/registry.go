@@ -3,13 +3,43 @@ package project
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 
 	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
 )
 
-// fileRef records the file where a named resource was defined.
+// fileRef records the file where a named resource was defined, and the line
+// it starts on when known.
 type fileRef struct {
 	FilePath string
+	Line     int
+}
+
+// String returns "file:line", or just the file when the line is unknown.
+func (f fileRef) String() string {
+	if f.Line == 0 {
+		return f.FilePath
+	}
+	return fmt.Sprintf("%s:%d", f.FilePath, f.Line)
+}
+
+// ValidationError is a collision or broken reference found by
+// Registry.Validate, located at the resource it concerns.
+type ValidationError struct {
+	File    string
+	Line    int // 0 when unknown
+	Message string
+}
+
+// Error returns "file:line: message", the form editors and terminals turn
+// into a link.
+func (e *ValidationError) Error() string {
+	return fileRef{FilePath: e.File, Line: e.Line}.String() + ": " + e.Message
+}
+
+// newValidationError returns a ValidationError located at ref.
+func newValidationError(ref fileRef, format string, args ...interface{}) *ValidationError {
+	return &ValidationError{File: ref.FilePath, Line: ref.Line, Message: fmt.Sprintf(format, args...)}
 }
 
 // Registry accumulates resources from multiple manifest files and detects
@@ -28,7 +58,19 @@ type Registry struct {
 	// TransformationFiles maps transformation name to the resolved code_file path.
 	TransformationFiles map[string]string
 
+	// Vars merges the vars blocks of every manifest. A variable may be
+	// declared in several manifests only with the same value.
+	Vars     map[string]string
+	varFiles map[string]string
+
 	collisionErrors []error
+
+	// external holds the "kind/name" keys of registered external
+	// placeholders, which several manifests may declare.
+	external map[string]bool
+
+	// connectionRefs holds where each entry of ConnectionList was defined.
+	connectionRefs []fileRef
 }
 
 // NewRegistry creates an empty Registry ready to receive manifests.
@@ -39,40 +81,42 @@ func NewRegistry() *Registry {
 		Transformations:     make(map[string]fileRef),
 		Connections:         make(map[string]fileRef),
 		TransformationFiles: make(map[string]string),
+		Vars:                make(map[string]string),
+		varFiles:            make(map[string]string),
+		external:            make(map[string]bool),
 	}
 }
 
 // AddManifest registers all resources from a manifest loaded from filePath,
-// detecting naming collisions within each resource type.
+// detecting naming collisions within each resource type. An external
+// placeholder may be declared by several manifests, but not share its name
+// with a real definition.
 func (r *Registry) AddManifest(filePath string, m *manifest.Manifest) {
 	manifestDir := filepath.Dir(filePath)
 
-	for _, s := range m.Sources {
-		if existing, ok := r.Sources[s.Name]; ok {
-			r.collisionErrors = append(r.collisionErrors,
-				fmt.Errorf("duplicate source %q: defined in %s and %s", s.Name, existing.FilePath, filePath))
-		} else {
-			r.Sources[s.Name] = fileRef{FilePath: filePath}
+	for i, s := range m.Sources {
+		ref := fileRef{FilePath: filePath, Line: m.Line("sources", i)}
+		if !r.register(r.Sources, KindSource, s.Name, s.External, ref) {
+			continue
 		}
 		r.SourceList = append(r.SourceList, s)
 	}
 
-	for _, d := range m.Destinations {
-		if existing, ok := r.Destinations[d.Name]; ok {
-			r.collisionErrors = append(r.collisionErrors,
-				fmt.Errorf("duplicate destination %q: defined in %s and %s", d.Name, existing.FilePath, filePath))
-		} else {
-			r.Destinations[d.Name] = fileRef{FilePath: filePath}
+	for i, d := range m.Destinations {
+		ref := fileRef{FilePath: filePath, Line: m.Line("destinations", i)}
+		if !r.register(r.Destinations, KindDestination, d.Name, d.External, ref) {
+			continue
 		}
 		r.DestinationList = append(r.DestinationList, d)
 	}
 
-	for _, tr := range m.Transformations {
+	for i, tr := range m.Transformations {
+		ref := fileRef{FilePath: filePath, Line: m.Line("transformations", i)}
 		if existing, ok := r.Transformations[tr.Name]; ok {
 			r.collisionErrors = append(r.collisionErrors,
-				fmt.Errorf("duplicate transformation %q: defined in %s and %s", tr.Name, existing.FilePath, filePath))
+				newValidationError(ref, "duplicate transformation %q: also defined at %s", tr.Name, existing))
 		} else {
-			r.Transformations[tr.Name] = fileRef{FilePath: filePath}
+			r.Transformations[tr.Name] = ref
 		}
 		r.TransformationList = append(r.TransformationList, tr)
 		if tr.CodeFile != "" {
@@ -80,40 +124,147 @@ func (r *Registry) AddManifest(filePath string, m *manifest.Manifest) {
 		}
 	}
 
-	for _, c := range m.Connections {
-		if existing, ok := r.Connections[c.Name]; ok {
-			r.collisionErrors = append(r.collisionErrors,
-				fmt.Errorf("duplicate connection %q: defined in %s and %s", c.Name, existing.FilePath, filePath))
+	for i, c := range m.Connections {
+		ref := fileRef{FilePath: filePath, Line: m.Line("connections", i)}
+		// Templated names are keyed by their base rendering so that
+		// "{{.Source}}-to-{{.Destination}}" can be reused across files.
+		name := c.Name
+		if rendered, err := manifest.RenderConnectionName(&c); err == nil {
+			name = rendered
+		}
+		if existing, ok := r.Connections[name]; ok {
+			if c.Name == "" {
+				r.collisionErrors = append(r.collisionErrors,
+					newValidationError(ref, "duplicate connection %q (name derived from its source and destination; set \"name\" to tell them apart): also defined at %s", name, existing))
+			} else {
+				r.collisionErrors = append(r.collisionErrors,
+					newValidationError(ref, "duplicate connection %q: also defined at %s", name, existing))
+			}
 		} else {
-			r.Connections[c.Name] = fileRef{FilePath: filePath}
+			r.Connections[name] = ref
 		}
 		r.ConnectionList = append(r.ConnectionList, c)
+		r.connectionRefs = append(r.connectionRefs, ref)
 	}
+
+	for _, name := range sortedKeys(m.Vars) {
+		value := m.Vars[name]
+		if existing, ok := r.varFiles[name]; ok {
+			if r.Vars[name] != value {
+				r.collisionErrors = append(r.collisionErrors,
+					newValidationError(fileRef{FilePath: filePath}, "conflicting var %q: defined differently in %s", name, existing))
+			}
+			continue
+		}
+		r.Vars[name] = value
+		r.varFiles[name] = filePath
+	}
+}
+
+// register records the source or destination name defined at ref in refs,
+// recording a collision error if the name is already taken. It returns false
+// when the resource repeats an external placeholder already registered, so
+// the caller lists it only once.
+func (r *Registry) register(refs map[string]fileRef, kind, name string, external bool, ref fileRef) bool {
+	key := kind + "/" + name
+	existing, ok := refs[name]
+	switch {
+	case !ok:
+		refs[name] = ref
+		if external {
+			r.external[key] = true
+		}
+	case external && r.external[key]:
+		return false
+	case external || r.external[key]:
+		r.collisionErrors = append(r.collisionErrors,
+			newValidationError(ref, "%s %q is both defined and declared external: also at %s", kind, name, existing))
+	default:
+		r.collisionErrors = append(r.collisionErrors,
+			newValidationError(ref, "duplicate %s %q: also defined at %s", kind, name, existing))
+	}
+	return true
+}
+
+// sortedKeys returns the keys of m in sorted order, so collision errors are
+// reported deterministically.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // Validate returns all accumulated collision errors plus any broken references
-// from connections to sources, destinations, or transformations.
+// from connections to sources, destinations, or transformations, or from
+// depends_on entries, each a *ValidationError.
 func (r *Registry) Validate() []error {
 	var errs []error
 	errs = append(errs, r.collisionErrors...)
 
-	for _, c := range r.ConnectionList {
+	for i, c := range r.ConnectionList {
+		var ref fileRef
+		if i < len(r.connectionRefs) {
+			ref = r.connectionRefs[i]
+		}
 		if c.Source != "" {
 			if _, ok := r.Sources[c.Source]; !ok {
-				errs = append(errs, fmt.Errorf("connection %q references undefined source %q", c.Name, c.Source))
+				errs = append(errs, newValidationError(ref, "connection %q references undefined source %q", c.Name, c.Source))
 			}
 		}
 		if c.Destination != "" {
 			if _, ok := r.Destinations[c.Destination]; !ok {
-				errs = append(errs, fmt.Errorf("connection %q references undefined destination %q", c.Name, c.Destination))
+				errs = append(errs, newValidationError(ref, "connection %q references undefined destination %q", c.Name, c.Destination))
 			}
 		}
 		for _, trName := range c.Transformations {
 			if _, ok := r.Transformations[trName]; !ok {
-				errs = append(errs, fmt.Errorf("connection %q references undefined transformation %q", c.Name, trName))
+				errs = append(errs, newValidationError(ref, "connection %q references undefined transformation %q", c.Name, trName))
 			}
 		}
+		errs = append(errs, r.dependencyErrors(ref, KindConnection, c.Name, c.DependsOn)...)
+	}
+	for _, s := range r.SourceList {
+		errs = append(errs, r.dependencyErrors(r.Sources[s.Name], KindSource, s.Name, s.DependsOn)...)
+	}
+	for _, tr := range r.TransformationList {
+		errs = append(errs, r.dependencyErrors(r.Transformations[tr.Name], KindTransformation, tr.Name, tr.DependsOn)...)
+	}
+	for _, d := range r.DestinationList {
+		errs = append(errs, r.dependencyErrors(r.Destinations[d.Name], KindDestination, d.Name, d.DependsOn)...)
 	}
 
 	return errs
 }
+
+// dependencyErrors reports the depends_on entries of the named resource that
+// don't name a resource in the project.
+func (r *Registry) dependencyErrors(ref fileRef, kind, name string, dependsOn []string) []error {
+	var errs []error
+	for _, dep := range dependsOn {
+		depKind, depName := DependencyTarget(kind, dep)
+		if r.FileFor(depKind, depName) == "" {
+			errs = append(errs, newValidationError(ref, "%s %q depends on undefined %s %q", kind, name, depKind, depName))
+		}
+	}
+	return errs
+}
+
+// FileFor returns the manifest file that defined the named resource of the
+// given kind (one of the Kind* constants), or "" if it is not registered.
+func (r *Registry) FileFor(kind, name string) string {
+	var refs map[string]fileRef
+	switch kind {
+	case KindSource:
+		refs = r.Sources
+	case KindTransformation:
+		refs = r.Transformations
+	case KindDestination:
+		refs = r.Destinations
+	case KindConnection:
+		refs = r.Connections
+	}
+	return refs[name].FilePath
+}
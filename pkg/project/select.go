@@ -0,0 +1,83 @@
+package project
+
+import (
+	"path/filepath"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/glob"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+// SelectByFile returns the subset of input whose resources are defined in
+// manifest files matching pattern. Paths are matched relative to rootDir
+// using forward slashes. Sources, destinations, and transformations that a
+// selected connection references are included even when their own file
+// doesn't match, so the connection can be deployed.
+func SelectByFile(input *deploy.DeployInput, reg *Registry, rootDir, pattern string) (*deploy.DeployInput, error) {
+	re, err := glob.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	refsByKind := map[string]map[string]fileRef{
+		KindSource:         reg.Sources,
+		KindTransformation: reg.Transformations,
+		KindDestination:    reg.Destinations,
+		KindConnection:     reg.Connections,
+	}
+	return Select(input, func(kind, name string) bool {
+		ref, ok := refsByKind[kind][name]
+		if !ok {
+			return false
+		}
+		rel, err := filepath.Rel(rootDir, ref.FilePath)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(filepath.ToSlash(rel))
+	}), nil
+}
+
+// Select returns the subset of input for which keep reports true, plus the
+// sources, destinations, and transformations that a kept connection
+// references, so the connection can be deployed. Disabled resources are
+// kept when keep reports true for them, so they are still reported as
+// skipped. External placeholders are always kept.
+func Select(input *deploy.DeployInput, keep func(kind, name string) bool) *deploy.DeployInput {
+	selected := &deploy.DeployInput{External: input.External}
+	neededSources := make(map[string]bool)
+	neededDestinations := make(map[string]bool)
+	neededTransformations := make(map[string]bool)
+	for _, conn := range input.Connections {
+		if !keep(KindConnection, conn.Name) {
+			continue
+		}
+		selected.Connections = append(selected.Connections, conn)
+		neededSources[conn.Source] = true
+		neededDestinations[conn.Destination] = true
+		for _, trName := range manifest.TransformationRefs(conn) {
+			neededTransformations[trName] = true
+		}
+	}
+
+	for _, src := range input.Sources {
+		if neededSources[src.Name] || keep(KindSource, src.Name) {
+			selected.Sources = append(selected.Sources, src)
+		}
+	}
+	for _, tr := range input.Transformations {
+		if neededTransformations[tr.Name] || keep(KindTransformation, tr.Name) {
+			selected.Transformations = append(selected.Transformations, tr)
+		}
+	}
+	for _, dst := range input.Destinations {
+		if neededDestinations[dst.Name] || keep(KindDestination, dst.Name) {
+			selected.Destinations = append(selected.Destinations, dst)
+		}
+	}
+	for _, d := range input.Disabled {
+		if keep(d.Kind, d.Name) {
+			selected.Disabled = append(selected.Disabled, d)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,93 @@
+package project
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/deploy"
+	"github.com/toppynl/hookdeck-deploy-cli/pkg/manifest"
+)
+
+func TestSelectByFile(t *testing.T) {
+	root := "/proj"
+	reg := NewRegistry()
+	reg.AddManifest(filepath.Join(root, "shared", "hookdeck.jsonc"), &manifest.Manifest{
+		Sources:      []manifest.SourceConfig{{Name: "shared-src"}, {Name: "unused-src"}},
+		Destinations: []manifest.DestinationConfig{{Name: "shared-dst"}},
+	})
+	reg.AddManifest(filepath.Join(root, "services", "payments", "hookdeck.jsonc"), &manifest.Manifest{
+		Destinations: []manifest.DestinationConfig{{Name: "payments-dst"}},
+		Connections: []manifest.ConnectionConfig{
+			{Name: "payments", Source: "shared-src", Destination: "payments-dst"},
+		},
+	})
+	reg.AddManifest(filepath.Join(root, "services", "orders", "hookdeck.jsonc"), &manifest.Manifest{
+		Connections: []manifest.ConnectionConfig{
+			{Name: "orders", Source: "shared-src", Destination: "shared-dst"},
+		},
+	})
+
+	input := &deploy.DeployInput{}
+	for i := range reg.SourceList {
+		input.Sources = append(input.Sources, &reg.SourceList[i])
+	}
+	for i := range reg.DestinationList {
+		input.Destinations = append(input.Destinations, &reg.DestinationList[i])
+	}
+	for i := range reg.ConnectionList {
+		input.Connections = append(input.Connections, &reg.ConnectionList[i])
+	}
+	input.Disabled = []deploy.DisabledResource{
+		{Kind: "destination", Name: "payments-dst"},
+		{Kind: "source", Name: "unused-src"},
+	}
+	input.External = []deploy.ExternalResource{{Kind: "source", Name: "partner-src"}}
+
+	selected, err := SelectByFile(input, reg, root, "services/payments/**")
+	if err != nil {
+		t.Fatalf("SelectByFile failed: %v", err)
+	}
+
+	if len(selected.Connections) != 1 || selected.Connections[0].Name != "payments" {
+		t.Errorf("expected only the payments connection, got %v", selected.Connections)
+	}
+	// shared-src is pulled in because the selected connection references it.
+	if len(selected.Sources) != 1 || selected.Sources[0].Name != "shared-src" {
+		t.Errorf("expected referenced source shared-src, got %v", selected.Sources)
+	}
+	if len(selected.Destinations) != 1 || selected.Destinations[0].Name != "payments-dst" {
+		t.Errorf("expected only payments-dst, got %v", selected.Destinations)
+	}
+	if len(selected.Disabled) != 1 || selected.Disabled[0].Name != "payments-dst" {
+		t.Errorf("expected only the disabled payments-dst, got %v", selected.Disabled)
+	}
+	if len(selected.External) != 1 || selected.External[0].Name != "partner-src" {
+		t.Errorf("expected external placeholders to be kept, got %v", selected.External)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	input := &deploy.DeployInput{
+		Sources:         []*manifest.SourceConfig{{Name: "src"}, {Name: "other-src"}},
+		Transformations: []*manifest.TransformationConfig{{Name: "tr"}, {Name: "other-tr"}},
+		Destinations:    []*manifest.DestinationConfig{{Name: "dst"}},
+		Connections: []*manifest.ConnectionConfig{
+			{Name: "conn", Source: "src", Destination: "dst", Transformations: []string{"tr"}},
+		},
+	}
+
+	// Keeping only a transformation selects nothing else.
+	selected := Select(input, func(kind, name string) bool { return kind == KindTransformation && name == "other-tr" })
+	if len(selected.Transformations) != 1 || len(selected.Sources)+len(selected.Destinations)+len(selected.Connections) != 0 {
+		t.Errorf("expected only other-tr, got %+v", selected)
+	}
+
+	// Keeping a connection pulls in everything it references.
+	selected = Select(input, func(kind, name string) bool { return kind == KindConnection })
+	if len(selected.Connections) != 1 || len(selected.Sources) != 1 || len(selected.Destinations) != 1 || len(selected.Transformations) != 1 {
+		t.Fatalf("expected the connection and its references, got %+v", selected)
+	}
+	if selected.Sources[0].Name != "src" || selected.Transformations[0].Name != "tr" {
+		t.Errorf("expected src and tr, got %s and %s", selected.Sources[0].Name, selected.Transformations[0].Name)
+	}
+}
@@ -10,6 +10,16 @@ import (
 	"github.com/tailscale/hujson"
 )
 
+// TargetEnv returns the wrangler environment a deploy syncs the source URL
+// into: override when set, otherwise the deploy's own environment. It returns
+// "" when neither is set, in which case there is no environment to target.
+func TargetEnv(override, deployEnv string) string {
+	if override != "" {
+		return override
+	}
+	return deployEnv
+}
+
 // SyncSourceURL writes the Hookdeck source URL into the given wrangler.jsonc
 // file under env.<envName>.vars.HOOKDECK_SOURCE_URL.
 //
@@ -82,3 +82,19 @@ func TestSyncSourceURL_CreatesNestedStructure(t *testing.T) {
 		t.Error("expected HOOKDECK_SOURCE_URL in output")
 	}
 }
+
+func TestTargetEnv(t *testing.T) {
+	tests := []struct {
+		override, deployEnv, want string
+	}{
+		{"", "production", "production"},
+		{"preview", "production", "preview"},
+		{"preview", "", "preview"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		if got := TargetEnv(tt.override, tt.deployEnv); got != tt.want {
+			t.Errorf("TargetEnv(%q, %q) = %q, want %q", tt.override, tt.deployEnv, got, tt.want)
+		}
+	}
+}